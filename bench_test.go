@@ -167,3 +167,33 @@ func BenchmarkPipeline(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkContextWatch(b *testing.B) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: fakeServerDialer(),
+	})
+	defer sdb.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bm := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"background", context.Background()},
+		{"cancelable", ctx},
+	}
+
+	for _, bm := range bm {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := sdb.Ping(bm.ctx).Err(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
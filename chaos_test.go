@@ -0,0 +1,135 @@
+package ssdb_test
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/chaos"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+// TestChaos runs commands through conns that fail at random and checks that
+// the replies are either correct or errors, and that the pool keeps track of
+// every conn.
+func TestChaos(t *testing.T) {
+	srv := ssdbtest.NewServer()
+	defer srv.Close()
+	const keys = 100
+	for i := 0; i < keys; i++ {
+		srv.Do("set", "key"+strconv.Itoa(i), "val"+strconv.Itoa(i))
+	}
+
+	// A TCP server, so that the pool checks the idle conns on the socket.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	go srv.Serve(l)
+
+	dialer := chaos.NewDialer((&net.Dialer{}).DialContext, chaos.Options{
+		Seed:         42,
+		Latency:      100 * time.Microsecond,
+		DialFailure:  0.05,
+		PartialWrite: 0.02,
+		TornRead:     0.2,
+		EOF:          0.02,
+		Reset:        0.02,
+	})
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:            l.Addr().String(),
+		Dialer:          dialer.Dial,
+		PoolSize:        4,
+		MaxRetries:      2,
+		MinRetryBackoff: time.Millisecond,
+		MaxRetryBackoff: 5 * time.Millisecond,
+	})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var ok, failed int
+	check := func(cmd *ssdb.Cmd, key int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cmd.Err() != nil {
+			failed++
+			return
+		}
+		ok++
+		want := "val" + strconv.Itoa(key)
+		if got := cmd.Val(); !reflect.DeepEqual(got, []string{want}) {
+			t.Errorf("%s: got %q, wanted %q", cmd.Args(), got, want)
+		}
+	}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := (g*100 + i) % keys
+				if i%5 != 0 {
+					check(sdb.Do(ctx, "get", "key"+strconv.Itoa(key)), key)
+					continue
+				}
+
+				cmds := make([]*ssdb.Cmd, 3)
+				_, _ = sdb.Pipelined(ctx, func(pipe ssdb.Pipeliner) error {
+					for j := range cmds {
+						cmds[j] = pipe.Do(ctx, "get", "key"+strconv.Itoa((key+j)%keys))
+					}
+					return nil
+				})
+				for j, cmd := range cmds {
+					check(cmd, (key+j)%keys)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := dialer.Stats()
+	if stats.DialFailures+stats.PartialWrites+stats.EOFs+stats.Resets == 0 || stats.TornReads == 0 {
+		t.Fatalf("got %+v, wanted faults injected", stats)
+	}
+	if ok == 0 || failed == 0 {
+		t.Fatalf("got %d replies and %d errors", ok, failed)
+	}
+
+	// The pool recovers once the network is healthy. After PoolSize dial
+	// failures in a row, it returns the last one until a dial in the
+	// background succeeds.
+	dialer.SetEnabled(false)
+	deadline := time.Now().Add(5 * time.Second)
+	for sdb.Do(ctx, "get", "key1").Err() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the pool did not recover")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 2*4; i++ {
+		if got, err := sdb.Do(ctx, "get", "key1").Result(); err != nil || !reflect.DeepEqual(got, []string{"val1"}) {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	}
+
+	// Every conn that is open is in the pool and idle.
+	ps := sdb.PoolStats()
+	if ps.InUse != 0 || ps.TotalConns != ps.IdleConns || ps.TotalConns > 4 {
+		t.Fatalf("got %+v", ps)
+	}
+	if stats := dialer.Stats(); stats.Open != ps.TotalConns {
+		t.Fatalf("got %d open conns, wanted the %d conns of the pool", stats.Open, ps.TotalConns)
+	}
+
+	if err := sdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := dialer.Stats(); stats.Open != 0 || stats.Closed != stats.Dials {
+		t.Fatalf("got %+v, wanted every conn closed", stats)
+	}
+}
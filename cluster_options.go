@@ -0,0 +1,91 @@
+package ssdb
+
+// Role selects the nodes that serve the commands of a client connected to
+// several nodes.
+type Role string
+
+const (
+	// RoleMaster sends the commands to the master.
+	RoleMaster Role = "master"
+	// RoleReplica sends the commands to the replicas. The node clients
+	// are read only.
+	RoleReplica Role = "replica"
+	// RoleAny sends the commands to any node, master or replica.
+	RoleAny Role = "any"
+)
+
+// ClusterOptions are the settings of a set of SSDB nodes, e.g. a master and
+// its replicas. A cluster uses all the nodes at the same time, and a
+// failover setup the first node of Addrs that is reachable and has the
+// requested role: they share the same settings, parsed by ParseClusterURL
+// and ParseFailoverURL.
+//
+// This package does not provide a cluster or failover client yet:
+// NodeOptions returns the options of a Client connected to one of the nodes.
+type ClusterOptions struct {
+	// Addrs are the addresses of the nodes, in the order a failover client
+	// tries them.
+	Addrs []string
+	// Role of the nodes the commands are sent to. The clients of the
+	// replicas are read only; the clients of RoleMaster and RoleAny are not.
+	// Default is RoleMaster.
+	Role Role
+
+	// Options are shared by the node clients. Options.Addr is ignored.
+	Options
+}
+
+// NodeOptions returns the options of the client connected to the node addr.
+func (opt *ClusterOptions) NodeOptions(addr string) *Options {
+	return nodeOptions(&opt.Options, opt.Role, addr)
+}
+
+func nodeOptions(shared *Options, role Role, addr string) *Options {
+	o := shared.clone()
+	o.Addr = addr
+	if role == RoleReplica {
+		o.ReadOnly = true
+	}
+	if o.TLSConfig != nil {
+		o.TLSConfig = o.TLSConfig.Clone()
+		if o.TLSConfig.ServerName == "" {
+			o.TLSConfig.ServerName = hostname(addr)
+		}
+	}
+	return o
+}
+
+// ParseClusterURL parses an URL with one or more hosts into ClusterOptions:
+//
+//	ssdbs://:password@a:8888,b:8888,c:8888?role=replica&read_timeout=1s
+//
+// It accepts the same parameters as ParseURL.
+func ParseClusterURL(ssdbURL string) (*ClusterOptions, error) {
+	uo, err := parseURL(ssdbURL)
+	if err != nil {
+		return nil, err
+	}
+	return &ClusterOptions{
+		Addrs:   uo.addrs,
+		Role:    uo.role,
+		Options: *uo.opt,
+	}, nil
+}
+
+// ParseFailoverURL parses an URL with one or more hosts, in the order they
+// are tried, into ClusterOptions:
+//
+//	ssdb://:password@primary:8888,standby:8888?role=master
+//
+// It accepts the same parameters as ParseURL.
+func ParseFailoverURL(ssdbURL string) (*ClusterOptions, error) {
+	uo, err := parseURL(ssdbURL)
+	if err != nil {
+		return nil, err
+	}
+	return &ClusterOptions{
+		Addrs:   uo.addrs,
+		Role:    uo.role,
+		Options: *uo.opt,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+var errUnbalancedQuotes = errors.New("unbalanced quotes")
+
+// splitArgs splits line into arguments separated by spaces. An argument can
+// be quoted with double quotes, in which \n, \r, \t, \\, \" and \xHH are
+// unescaped, or with single quotes, in which only \' is.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var b strings.Builder
+
+	for i := 0; i < len(line); {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+
+		b.Reset()
+		quoted := false
+		for i < len(line) && !isSpace(line[i]) {
+			switch c := line[i]; c {
+			case '"', '\'':
+				n, err := unquote(&b, line[i:])
+				if err != nil {
+					return nil, err
+				}
+				i += n
+				quoted = true
+			default:
+				b.WriteByte(c)
+				i++
+			}
+		}
+		if b.Len() > 0 || quoted {
+			args = append(args, b.String())
+		}
+	}
+
+	return args, nil
+}
+
+// unquote writes the unescaped content of the quoted string at the start of
+// s to b and returns the length of the quoted string.
+func unquote(b *strings.Builder, s string) (int, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			n := unescape(b, quote, s[i+1:])
+			i += n
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, errUnbalancedQuotes
+}
+
+// unescape writes the character escaped at the start of s, which follows a
+// backslash, and returns the number of bytes it used.
+func unescape(b *strings.Builder, quote byte, s string) int {
+	c := s[0]
+	if quote == '\'' {
+		if c != '\'' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+		return 1
+	}
+
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'x':
+		if len(s) >= 3 && isHex(s[1]) && isHex(s[2]) {
+			b.WriteByte(fromHex(s[1])<<4 | fromHex(s[2]))
+			return 3
+		}
+		b.WriteByte(c)
+	default:
+		b.WriteByte(c)
+	}
+	return 1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func fromHex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	default:
+		return c - 'a' + 10
+	}
+}
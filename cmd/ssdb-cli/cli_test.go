@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/term"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+func TestSplitArgs(t *testing.T) {
+	cases := []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{"  get   key ", []string{"get", "key"}},
+		{`set key "hello world"`, []string{"set", "key", "hello world"}},
+		{`set key "a\"b\n\x41"`, []string{"set", "key", "a\"b\nA"}},
+		{`set key 'it\'s \n'`, []string{"set", "key", `it's \n`}},
+		{`set key ""`, []string{"set", "key", ""}},
+		{`set k"e"y v`, []string{"set", "key", "v"}},
+	}
+	for _, tc := range cases {
+		args, err := splitArgs(tc.line)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.line, err)
+		}
+		if !reflect.DeepEqual(args, tc.args) {
+			t.Errorf("%q: got %q, wanted %q", tc.line, args, tc.args)
+		}
+	}
+
+	if _, err := splitArgs(`set key "value`); err != errUnbalancedQuotes {
+		t.Fatalf("got %v, wanted errUnbalancedQuotes", err)
+	}
+}
+
+func newCmd(val []string, err error, args ...interface{}) *ssdb.Cmd {
+	cmd := ssdb.NewCmd(context.Background(), args...)
+	cmd.SetVal(val)
+	cmd.SetErr(err)
+	return cmd
+}
+
+func TestFormat(t *testing.T) {
+	hgetall := newCmd([]string{"name", "alice", "age", "30"}, nil, "hgetall", "h")
+	incr := newCmd([]string{"2"}, nil, "incr", "n")
+	missing := newCmd(nil, ssdb.Nil, "get", "missing")
+	failed := newCmd([]string{"disk failure"}, &ssdb.ServerError{Status: "error", Message: "disk failure", Cmd: "set"}, "set", "k", "v")
+
+	cases := []struct {
+		format formatFunc
+		cmd    *ssdb.Cmd
+		out    string
+	}{
+		{formatText, hgetall, "1) \"name\" => \"alice\"\n2) \"age\" => \"30\"\n"},
+		{formatText, incr, "(integer) 2\n"},
+		{formatText, newCmd(nil, nil, "set", "k", "v"), "ok\n"},
+		{formatText, newCmd([]string{"1"}, nil, "exists", "k"), "(boolean) true\n"},
+		{formatText, newCmd(nil, nil, "keys", "", "", 10), "(empty list)\n"},
+		{formatText, missing, "(not_found)\n"},
+		{formatText, failed, "(error) disk failure\n"},
+
+		{formatJSON, hgetall, `{"command":"hgetall","status":"ok","value":[{"key":"name","value":"alice"},{"key":"age","value":"30"}]}` + "\n"},
+		{formatJSON, incr, `{"command":"incr","status":"ok","value":2}` + "\n"},
+		{formatJSON, missing, `{"command":"get","status":"nil"}` + "\n"},
+		{formatJSON, failed, `{"command":"set","status":"error","error":"disk failure"}` + "\n"},
+
+		{formatRaw, incr, "2\nok\n1\n2\n\n"},
+		{formatRaw, missing, "9\nnot_found\n\n"},
+		{formatRaw, failed, "5\nerror\n12\ndisk failure\n\n"},
+	}
+	for _, tc := range cases {
+		if out := tc.format(tc.cmd); out != tc.out {
+			t.Errorf("%s: got %q, wanted %q", tc.cmd.Name(), out, tc.out)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	fn := complete([]string{"get", "getbit", "getset", "hget", "set"})
+
+	cases := []struct {
+		line    string
+		pos     int
+		newLine string
+		newPos  int
+		ok      bool
+	}{
+		{"hg", 2, "hget ", 5, true},
+		{"ge", 2, "get", 3, true},
+		{"GETS", 4, "getset ", 7, true},
+		{"  s key", 3, "  set key", 5, true},
+		{"get k", 5, "", 0, false},
+		{"x", 1, "", 0, false},
+	}
+	for _, tc := range cases {
+		newLine, newPos, ok := fn(tc.line, tc.pos, '\t')
+		if ok != tc.ok || newLine != tc.newLine || newPos != tc.newPos {
+			t.Errorf("%q: got %q, %d, %v, wanted %q, %d, %v",
+				tc.line, newLine, newPos, ok, tc.newLine, tc.newPos, tc.ok)
+		}
+	}
+
+	if _, _, ok := fn("ge", 2, 'x'); ok {
+		t.Fatal("completed a key other than tab")
+	}
+}
+
+func TestPipe(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: func(context.Context, string, string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go serveEcho(server)
+			return client, nil
+		},
+	})
+	defer sdb.Close()
+
+	var out strings.Builder
+	c := &cli{sdb: sdb, out: &out, format: formatText}
+	if err := c.pipe(context.Background(), strings.NewReader("qrange q 0 -1\n\nget \"a key\"\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := "1) \"q\"\n2) \"0\"\n3) \"-1\"\n\"a key\"\n"
+	if out.String() != wanted {
+		t.Fatalf("got %q, wanted %q", out.String(), wanted)
+	}
+}
+
+// serveEcho replies to every request with the arguments of the command.
+func serveEcho(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		var args []string
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if line == "\n" {
+				break
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(line))
+			if err != nil {
+				return
+			}
+			b := make([]byte, n+1)
+			if _, err := io.ReadFull(rd, b); err != nil {
+				return
+			}
+			args = append(args, string(b[:n]))
+		}
+
+		var b []byte
+		for _, block := range append([]string{"ok"}, args[1:]...) {
+			b = strconv.AppendInt(b, int64(len(block)), 10)
+			b = append(b, '\n')
+			b = append(b, block...)
+			b = append(b, '\n')
+		}
+		b = append(b, '\n')
+		if _, err := conn.Write(b); err != nil {
+			return
+		}
+	}
+}
+
+func TestHistoryReplay(t *testing.T) {
+	h := &history{}
+	for _, entry := range []string{"get a", "set a \x1b[Ab", "hgetall h"} {
+		h.Add(entry)
+	}
+	input, lines := h.replay()
+	if lines != 2 {
+		t.Fatalf("got %d lines, wanted the entry with an escape skipped", lines)
+	}
+
+	// Up twice then enter recalls the entry before the last one.
+	tt := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(input + "\x1b[A\x1b[A\r"), io.Discard}, "> ")
+	for i := 0; i < lines; i++ {
+		if _, err := tt.ReadLine(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if line, err := tt.ReadLine(); err != nil || line != "get a" {
+		t.Fatalf("got %q, %v", line, err)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/extra/ssdbcmd"
+)
+
+// formatFunc formats the reply of a processed command, including the errors
+// replied by the server.
+type formatFunc func(cmd *ssdb.Cmd) string
+
+// replyKind returns the shape of the reply of cmd. The shape of unknown
+// commands is guessed from the number of values.
+func replyKind(cmd *ssdb.Cmd) ssdb.ReplyKind {
+	if info := cmd.Info(); info != nil {
+		return info.Reply
+	}
+	switch len(values(cmd)) {
+	case 0:
+		return ssdb.ReplyStatus
+	case 1:
+		return ssdb.ReplyString
+	default:
+		return ssdb.ReplyList
+	}
+}
+
+func values(cmd *ssdb.Cmd) []string {
+	vals, _ := cmd.Val().([]string)
+	return vals
+}
+
+// errorMessage returns the message of the error replied by the server.
+func errorMessage(err error) string {
+	var serverErr *ssdb.ServerError
+	if errors.As(err, &serverErr) && serverErr.Message != "" {
+		return serverErr.Message
+	}
+	var clientErr *ssdb.ClientError
+	if errors.As(err, &clientErr) && clientErr.Message != "" {
+		return clientErr.Message
+	}
+	return strings.TrimPrefix(err.Error(), "ssdb: ")
+}
+
+// formatText formats the reply for humans: strings are quoted, numbers are
+// tagged with their type and lists and pairs are numbered.
+func formatText(cmd *ssdb.Cmd) string {
+	switch err := cmd.Err(); {
+	case errors.Is(err, ssdb.ErrNotFound):
+		return "(not_found)\n"
+	case err != nil:
+		return "(error) " + errorMessage(err) + "\n"
+	}
+
+	vals := values(cmd)
+	kind := replyKind(cmd)
+	if kind != ssdb.ReplyStatus && kind != ssdb.ReplyList && kind != ssdb.ReplyMap && len(vals) != 1 {
+		kind = ssdb.ReplyList
+	}
+
+	var b strings.Builder
+	switch kind {
+	case ssdb.ReplyStatus:
+		b.WriteString("ok\n")
+	case ssdb.ReplyString:
+		b.WriteString(strconv.Quote(vals[0]) + "\n")
+	case ssdb.ReplyInt:
+		b.WriteString("(integer) " + vals[0] + "\n")
+	case ssdb.ReplyFloat:
+		b.WriteString("(float) " + vals[0] + "\n")
+	case ssdb.ReplyBool:
+		b.WriteString("(boolean) " + strconv.FormatBool(vals[0] == "1") + "\n")
+	case ssdb.ReplyMap:
+		if len(vals) == 0 {
+			return "(empty map)\n"
+		}
+		width := len(strconv.Itoa(len(vals) / 2))
+		for i := 0; i+1 < len(vals); i += 2 {
+			writeIndex(&b, i/2+1, width)
+			b.WriteString(strconv.Quote(vals[i]) + " => " + strconv.Quote(vals[i+1]) + "\n")
+		}
+	default:
+		if len(vals) == 0 {
+			return "(empty list)\n"
+		}
+		width := len(strconv.Itoa(len(vals)))
+		for i, v := range vals {
+			writeIndex(&b, i+1, width)
+			b.WriteString(strconv.Quote(v) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func writeIndex(b *strings.Builder, i, width int) {
+	s := strconv.Itoa(i)
+	b.WriteString(strings.Repeat(" ", width-len(s)))
+	b.WriteString(s + ") ")
+}
+
+type jsonReply struct {
+	Command string      `json:"command"`
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+type jsonPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// formatJSON formats the reply as a JSON object on a single line. The value
+// has the JSON type of the reply, pairs are a list of key value objects.
+func formatJSON(cmd *ssdb.Cmd) string {
+	reply := jsonReply{
+		Command: cmd.Name(),
+		Status:  ssdbcmd.CmdStatus(cmd),
+	}
+	if err := cmd.Err(); err != nil {
+		if reply.Status == ssdbcmd.StatusError {
+			reply.Error = errorMessage(err)
+		}
+	} else {
+		reply.Value = jsonValue(replyKind(cmd), values(cmd))
+	}
+
+	b, err := json.Marshal(reply)
+	if err != nil {
+		return "{}\n"
+	}
+	return string(b) + "\n"
+}
+
+func jsonValue(kind ssdb.ReplyKind, vals []string) interface{} {
+	switch kind {
+	case ssdb.ReplyStatus:
+		return nil
+	case ssdb.ReplyList:
+		if vals == nil {
+			vals = []string{}
+		}
+		return vals
+	case ssdb.ReplyMap:
+		pairs := make([]jsonPair, 0, len(vals)/2)
+		for i := 0; i+1 < len(vals); i += 2 {
+			pairs = append(pairs, jsonPair{Key: vals[i], Value: vals[i+1]})
+		}
+		return pairs
+	}
+
+	if len(vals) != 1 {
+		return vals
+	}
+	switch kind {
+	case ssdb.ReplyInt:
+		if n, err := strconv.ParseInt(vals[0], 10, 64); err == nil {
+			return n
+		}
+	case ssdb.ReplyFloat:
+		if f, err := strconv.ParseFloat(vals[0], 64); err == nil {
+			return f
+		}
+	case ssdb.ReplyBool:
+		return vals[0] == "1"
+	}
+	return vals[0]
+}
+
+// formatRaw formats the reply as it was sent by the server: the status and
+// the values as length prefixed blocks, followed by an empty line.
+func formatRaw(cmd *ssdb.Cmd) string {
+	var b strings.Builder
+	blocks := append([]string{replyStatus(cmd.Err())}, values(cmd)...)
+	for _, block := range blocks {
+		b.WriteString(strconv.Itoa(len(block)))
+		b.WriteByte('\n')
+		b.WriteString(block)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// replyStatus returns the status of the reply that resulted in err.
+func replyStatus(err error) string {
+	var serverErr *ssdb.ServerError
+	var authErr *ssdb.AuthError
+	var clientErr *ssdb.ClientError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ssdb.ErrNotFound):
+		return "not_found"
+	case errors.As(err, &serverErr):
+		return serverErr.Status
+	case errors.As(err, &authErr):
+		return authErr.Status
+	case errors.As(err, &clientErr):
+		return "client_error"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/ssdb-go/ssdb/cmd/ssdb-cli
+
+go 1.21
+
+replace github.com/ssdb-go/ssdb => ../..
+
+replace github.com/ssdb-go/ssdb/extra/ssdbcmd => ../../extra/ssdbcmd
+
+require (
+	github.com/ssdb-go/ssdb v1.0.0
+	github.com/ssdb-go/ssdb/extra/ssdbcmd v1.0.0
+	golang.org/x/term v0.29.0
+)
+
+require golang.org/x/sys v0.30.0 // indirect
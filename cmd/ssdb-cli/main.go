@@ -0,0 +1,129 @@
+// Command ssdb-cli is an interactive command line client for SSDB.
+//
+// Usage:
+//
+//	ssdb-cli [-u url] [-json] [-raw] [-pipe] [command [arg...]]
+//
+// With a command, ssdb-cli executes it and exits. Otherwise it reads commands
+// from the terminal, with history and tab completion of the command names,
+// or one command per line from stdin when stdin is not a terminal or -pipe
+// is set. The connection settings are parsed by ssdb.ParseURL, e.g.
+// ssdb://:password@localhost:8888?read_timeout=10s.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+func main() {
+	url := flag.String("u", "ssdb://localhost:8888", "SSDB server URL")
+	jsonOut := flag.Bool("json", false, "print the replies as JSON")
+	raw := flag.Bool("raw", false, "print the replies in the SSDB protocol")
+	pipe := flag.Bool("pipe", false, "read the commands from stdin, one per line")
+	flag.Parse()
+
+	opt, err := ssdb.ParseURL(*url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ssdb-cli:", err)
+		os.Exit(2)
+	}
+	// The errors are printed by the cli, the retries don't need to be.
+	opt.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	sdb := ssdb.NewClient(opt)
+	defer sdb.Close()
+
+	cli := &cli{
+		sdb:    sdb,
+		format: formatText,
+	}
+	switch {
+	case *raw:
+		cli.format = formatRaw
+	case *jsonOut:
+		cli.format = formatJSON
+	}
+
+	ctx := context.Background()
+	switch {
+	case flag.NArg() > 0:
+		cli.out = os.Stdout
+		err = cli.exec(ctx, flag.Args())
+	case *pipe || !term.IsTerminal(int(os.Stdin.Fd())):
+		cli.out = os.Stdout
+		err = cli.pipe(ctx, os.Stdin)
+	default:
+		err = cli.repl(ctx, opt.Addr)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ssdb-cli:", err)
+		sdb.Close()
+		os.Exit(1)
+	}
+}
+
+type cli struct {
+	sdb    *ssdb.Client
+	out    io.Writer
+	format formatFunc
+}
+
+// exec executes the command args and prints the reply. Errors replied by the
+// server are printed as part of the reply; other errors are returned.
+func (c *cli) exec(ctx context.Context, args []string) error {
+	cmdArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		cmdArgs[i] = arg
+	}
+
+	cmd := c.sdb.Do(ctx, cmdArgs...)
+	var ssdbErr ssdb.Error
+	if err := cmd.Err(); err != nil && !errors.As(err, &ssdbErr) {
+		return err
+	}
+	_, err := io.WriteString(c.out, c.format(cmd))
+	return err
+}
+
+// pipe executes the commands read from r, one per line. It stops at the first
+// error that is not replied by the server.
+func (c *cli) pipe(ctx context.Context, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		args, err := splitArgs(sc.Text())
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if err := c.exec(ctx, args); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func isQuit(args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	switch strings.ToLower(args[0]) {
+	case "quit", "exit":
+		return true
+	default:
+		return false
+	}
+}
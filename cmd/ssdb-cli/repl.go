@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+const maxHistory = 1000
+
+// repl reads the commands from the terminal until quit, exit or Ctrl-D.
+func (c *cli) repl(ctx context.Context, addr string) error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state) //nolint:errcheck
+
+	hist := loadHistory(historyPath())
+	defer hist.save()
+
+	// The Terminal of the x/term versions that support Go 1.21 can't be
+	// given a history: the saved one is replayed as input, with the
+	// output discarded, to fill its own.
+	out := &switchWriter{w: io.Discard}
+	input, lines := hist.replay()
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{io.MultiReader(strings.NewReader(input), os.Stdin), out}, addr+"> ")
+	for i := 0; i < lines; i++ {
+		if _, err := t.ReadLine(); err != nil {
+			return err
+		}
+	}
+	out.w = os.Stdout
+	t.AutoCompleteCallback = complete(ssdb.CommandNames())
+
+	c.out = t
+	for {
+		line, err := t.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		hist.Add(line)
+
+		args, err := splitArgs(line)
+		if err != nil {
+			fmt.Fprintf(t, "(error) %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if isQuit(args) {
+			return nil
+		}
+
+		if err := c.exec(ctx, args); err != nil {
+			fmt.Fprintf(t, "(error) %v\n", err)
+		}
+	}
+}
+
+// complete returns an AutoCompleteCallback that completes the command name,
+// the first word of the line, on tab. When several names match, the longest
+// common prefix is completed.
+func complete(names []string) func(line string, pos int, key rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		start := len(line) - len(strings.TrimLeft(line, " "))
+		end := strings.IndexByte(line[start:], ' ')
+		if end < 0 {
+			end = len(line)
+		} else {
+			end += start
+		}
+		if pos != end {
+			return "", 0, false
+		}
+
+		prefix := strings.ToLower(line[start:end])
+		i := sort.SearchStrings(names, prefix)
+		var match string
+		var n int
+		for ; i < len(names) && strings.HasPrefix(names[i], prefix); i++ {
+			if n == 0 {
+				match = names[i]
+			} else {
+				match = commonPrefix(match, names[i])
+			}
+			n++
+		}
+		if n == 0 {
+			return "", 0, false
+		}
+
+		if n == 1 && end == len(line) {
+			match += " "
+		}
+		return line[:start] + match + line[end:], start + len(match), true
+	}
+}
+
+func commonPrefix(a, b string) string {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return a[:i]
+		}
+	}
+	return a[:n]
+}
+
+//------------------------------------------------------------------------------
+
+// switchWriter writes to w, which can be changed.
+type switchWriter struct {
+	w io.Writer
+}
+
+func (w *switchWriter) Write(b []byte) (int, error) {
+	return w.w.Write(b)
+}
+
+// history is the command history, saved to a file.
+type history struct {
+	path    string
+	entries []string // the most recent last
+}
+
+func historyPath() string {
+	if path := os.Getenv("SSDB_CLI_HISTFILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssdb_cli_history")
+}
+
+// loadHistory reads the history from path. The history is kept in memory only
+// if path is empty or can't be read.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			h.Add(line)
+		}
+	}
+	return h
+}
+
+func (h *history) save() {
+	if h.path == "" {
+		return
+	}
+	_ = os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+}
+
+func (h *history) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+}
+
+// termHistory is the number of entries kept by a term.Terminal.
+const termHistory = 100
+
+// replay returns the input that enters the last entries of the history in a
+// term.Terminal, and its number of lines. The entries with control
+// characters, which the terminal would interpret, are skipped.
+func (h *history) replay() (input string, lines int) {
+	entries := h.entries
+	if len(entries) > termHistory {
+		entries = entries[len(entries)-termHistory:]
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		if strings.IndexFunc(entry, unicode.IsControl) >= 0 {
+			continue
+		}
+		b.WriteString(entry)
+		b.WriteByte('\r')
+		lines++
+	}
+	return b.String(), lines
+}
@@ -0,0 +1,12 @@
+module github.com/ssdb-go/ssdb/cmd/ssdb-dump
+
+go 1.21
+
+replace github.com/ssdb-go/ssdb => ../..
+
+replace github.com/ssdb-go/ssdb/extra/ssdbdump => ../../extra/ssdbdump
+
+require (
+	github.com/ssdb-go/ssdb v1.0.0
+	github.com/ssdb-go/ssdb/extra/ssdbdump v1.0.0
+)
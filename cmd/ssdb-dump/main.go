@@ -0,0 +1,222 @@
+// Command ssdb-dump dumps the data of an SSDB server to a file and restores it.
+//
+// Usage:
+//
+//	ssdb-dump dump [-u url] [-o file] [-gzip] [-types kv,hash,zset,queue] [-batch n]
+//	ssdb-dump restore [-u url] [-i file] [-batch n] [-progress file]
+//	ssdb-dump verify [-i file]
+//
+// The connection settings are parsed by ssdb.ParseURL. The file defaults to
+// stdout and stdin. With -progress, restore saves the number of records
+// restored to the file after each batch and resumes from it when it is
+// started again; the file is removed once the restore completes.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/extra/ssdbdump"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "dump":
+		err = dump(os.Args[2:])
+	case "restore":
+		err = restore(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ssdb-dump:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ssdb-dump dump|restore|verify [flags]")
+	os.Exit(2)
+}
+
+func newClient(url string) (*ssdb.Client, error) {
+	opt, err := ssdb.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	opt.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return ssdb.NewClient(opt), nil
+}
+
+func dump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	url := fs.String("u", "ssdb://localhost:8888", "SSDB server URL")
+	out := fs.String("o", "", "output file, stdout by default")
+	compress := fs.Bool("gzip", false, "compress the dump with gzip")
+	types := fs.String("types", "", "comma separated types to dump: kv, hash, zset, queue")
+	batch := fs.Int("batch", 1000, "keys or entries read per command")
+	_ = fs.Parse(args)
+
+	sdb, err := newClient(*url)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	opt := &ssdbdump.DumpOptions{BatchSize: *batch}
+	if *types != "" {
+		for _, typ := range strings.Split(*types, ",") {
+			opt.Types = append(opt.Types, ssdbdump.Type(strings.TrimSpace(typ)))
+		}
+	}
+
+	var f io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		f = file
+	}
+
+	w, err := ssdbdump.NewWriter(f, &ssdbdump.WriterOptions{Compress: *compress})
+	if err != nil {
+		return err
+	}
+	if err := ssdbdump.Dump(context.Background(), sdb, w, opt); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if file, ok := f.(*os.File); ok && file != os.Stdout {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "dumped %d records\n", w.Count())
+	return nil
+}
+
+func restore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	url := fs.String("u", "ssdb://localhost:8888", "SSDB server URL")
+	in := fs.String("i", "", "input file, stdin by default")
+	batch := fs.Int("batch", 1000, "entries written per pipeline")
+	progress := fs.String("progress", "", "file to save the progress to and resume from")
+	_ = fs.Parse(args)
+
+	sdb, err := newClient(*url)
+	if err != nil {
+		return err
+	}
+	defer sdb.Close()
+
+	f, err := openInput(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := ssdbdump.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	opt := &ssdbdump.RestoreOptions{BatchSize: *batch}
+	if *progress != "" {
+		skip, err := readProgress(*progress)
+		if err != nil {
+			return err
+		}
+		if skip > 0 {
+			fmt.Fprintf(os.Stderr, "resuming after %d records\n", skip)
+		}
+		opt.Skip = skip
+		opt.OnProgress = func(restored int64) {
+			if err := writeProgress(*progress, restored); err != nil {
+				fmt.Fprintln(os.Stderr, "ssdb-dump:", err)
+			}
+		}
+	}
+
+	n, err := ssdbdump.Restore(context.Background(), sdb, r, opt)
+	if err != nil {
+		return err
+	}
+	if *progress != "" {
+		if err := os.Remove(*progress); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "restored %d records\n", n)
+	return nil
+}
+
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	in := fs.String("i", "", "input file, stdin by default")
+	_ = fs.Parse(args)
+
+	f, err := openInput(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header, n, err := ssdbdump.Verify(f)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version %d dump created at %s: %d records, checksum ok\n",
+		header.Version, header.Created.Format("2006-01-02 15:04:05 MST"), n)
+	return nil
+}
+
+func openInput(path string) (*os.File, error) {
+	if path == "" {
+		return os.Stdin, nil
+	}
+	return os.Open(path)
+}
+
+func readProgress(path string) (int64, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid progress file %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// writeProgress replaces the progress file atomically, so an interrupted
+// write does not lose the progress.
+func writeProgress(path string, n int64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(n, 10)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress")
+
+	n, err := readProgress(path)
+	if err != nil || n != 0 {
+		t.Fatalf("got %d, %v, wanted 0 without a progress file", n, err)
+	}
+
+	if err := writeProgress(path, 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeProgress(path, 1042); err != nil {
+		t.Fatal(err)
+	}
+	n, err = readProgress(path)
+	if err != nil || n != 1042 {
+		t.Fatalf("got %d, %v, wanted 1042", n, err)
+	}
+}
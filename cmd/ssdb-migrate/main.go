@@ -0,0 +1,124 @@
+// Command ssdb-migrate copies a range of keys from an SSDB server to another.
+//
+// Usage:
+//
+//	ssdb-migrate -from url -to url [-prefix p] [-start key] [-end key]
+//		[-types kv,hash,zset,queue] [-batch n] [-rate ops] [-verify] [-delete]
+//		[-checkpoint file]
+//
+// The connection settings are parsed by ssdb.ParseURL. With -checkpoint, the
+// position of the migration is saved to the file after each batch and the
+// migration resumes from it when it is started again; the file is removed
+// once the migration completes.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/extra/ssdbmigrate"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "ssdb-migrate:", err)
+		os.Exit(1)
+	}
+}
+
+func newClient(url string) (*ssdb.Client, error) {
+	opt, err := ssdb.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	opt.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return ssdb.NewClient(opt), nil
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("ssdb-migrate", flag.ExitOnError)
+	from := fs.String("from", "", "source SSDB server URL")
+	to := fs.String("to", "", "destination SSDB server URL")
+	prefix := fs.String("prefix", "", "migrate the keys with the prefix")
+	start := fs.String("start", "", "migrate the keys after start")
+	end := fs.String("end", "", "migrate the keys up to end, included")
+	types := fs.String("types", "", "comma separated types to migrate: kv, hash, zset, queue")
+	batch := fs.Int("batch", 1000, "keys or entries copied per batch")
+	rate := fs.Float64("rate", 0, "keys or entries copied per second, 0 for no limit")
+	verify := fs.Bool("verify", true, "read each batch back from the destination and compare it")
+	del := fs.Bool("delete", false, "delete the keys from the source once copied")
+	checkpoint := fs.String("checkpoint", "", "file to save the position to and resume from")
+	_ = fs.Parse(args)
+
+	if *from == "" || *to == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	src, err := newClient(*from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := newClient(*to)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	opt := &ssdbmigrate.Options{
+		Start:        *start,
+		End:          *end,
+		Prefix:       *prefix,
+		Types:        parseTypes(*types),
+		BatchSize:    *batch,
+		OpsPerSecond: *rate,
+		Verify:       *verify,
+		DeleteSource: *del,
+	}
+	var store *ssdbmigrate.FileCheckpoint
+	if *checkpoint != "" {
+		store = ssdbmigrate.NewFileCheckpoint(*checkpoint)
+		cp, err := store.Load(context.Background())
+		if err != nil {
+			return err
+		}
+		if cp != nil {
+			fmt.Fprintf(os.Stderr, "resuming %s migration after %q\n", cp.Type, cp.Key)
+		}
+		opt.Checkpoint = store
+	}
+
+	// Cancel the migration on an interrupt. The checkpoint is saved after
+	// each batch, so the migration resumes from the last complete batch.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := ssdbmigrate.Migrate(ctx, src, dst, opt)
+	fmt.Fprintf(os.Stderr, "migrated %d keys, %d entries, deleted %d entries\n",
+		stats.Keys, stats.Entries, stats.Deleted)
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		return store.Remove()
+	}
+	return nil
+}
+
+// parseTypes parses a comma separated list of types.
+func parseTypes(s string) []ssdbmigrate.Type {
+	var types []ssdbmigrate.Type
+	for _, typ := range strings.Split(s, ",") {
+		if typ = strings.TrimSpace(typ); typ != "" {
+			types = append(types, ssdbmigrate.Type(typ))
+		}
+	}
+	return types
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ssdb-go/ssdb/extra/ssdbmigrate"
+)
+
+func TestParseTypes(t *testing.T) {
+	if got := parseTypes(""); got != nil {
+		t.Fatalf("got %q, wanted all the types", got)
+	}
+	got := parseTypes("kv, zset,")
+	if wanted := []ssdbmigrate.Type{ssdbmigrate.TypeKV, ssdbmigrate.TypeZSet}; !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/ssdb-go/ssdb/cmd/ssdb-proxy
+
+go 1.21
+
+replace github.com/ssdb-go/ssdb => ../..
+
+replace github.com/ssdb-go/ssdb/extra/ssdbproxy => ../../extra/ssdbproxy
+
+require github.com/ssdb-go/ssdb/extra/ssdbproxy v1.0.0
+
+require github.com/ssdb-go/ssdb v1.0.0 // indirect
@@ -0,0 +1,93 @@
+// Command ssdb-proxy shards the keys of its SSDB clients on several SSDB
+// servers.
+//
+// Usage:
+//
+//	ssdb-proxy -backends host:port,host:port [-addr host:port] [-stats host:port]
+//		[-password p] [-backend-password p] [-pool-size n] [-eject-timeout d]
+//		[-reroute-ejected]
+//
+// With -stats, the counters of the proxy and of the backends are served as
+// JSON over HTTP. See the ssdbproxy package for the routing of the commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/ssdb-go/ssdb/extra/ssdbproxy"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "ssdb-proxy:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	addr := flag.String("addr", "localhost:8889", "address to serve the SSDB protocol on")
+	backends := flag.String("backends", "", "comma separated addresses of the SSDB servers")
+	stats := flag.String("stats", "", "address to serve the stats on, e.g. localhost:8890")
+	password := flag.String("password", "", "password the clients must send with auth")
+	backendPassword := flag.String("backend-password", "", "password of the SSDB servers")
+	poolSize := flag.Int("pool-size", 10, "maximum number of connections per SSDB server")
+	ejectTimeout := flag.Duration("eject-timeout", 30*time.Second, "time a failed SSDB server is ejected for")
+	rerouteEjected := flag.Bool("reroute-ejected", false, "route the keys of an ejected SSDB server to the others; the keys written meanwhile are lost when it is back")
+	flag.Parse()
+
+	if *backends == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	proxy, err := ssdbproxy.NewProxy(&ssdbproxy.Options{
+		Backends:        strings.Split(*backends, ","),
+		Password:        *password,
+		BackendPassword: *backendPassword,
+		PoolSize:        *poolSize,
+		EjectTimeout:    *ejectTimeout,
+		RerouteEjected:  *rerouteEjected,
+		Logger:          logger,
+	})
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	if *stats != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/stats", proxy.StatsHandler())
+		srv := &http.Server{Addr: *stats, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("stats server failed", "error", err)
+			}
+		}()
+		defer srv.Close()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		l.Close()
+	}()
+
+	logger.Info("serving the SSDB protocol", "addr", l.Addr().String(), "backends", *backends)
+	err = proxy.Serve(l)
+	proxy.Close()
+	return err
+}
@@ -0,0 +1,12 @@
+module github.com/ssdb-go/ssdb/cmd/ssdb-resp-proxy
+
+go 1.21
+
+replace github.com/ssdb-go/ssdb => ../..
+
+replace github.com/ssdb-go/ssdb/extra/ssdbresp => ../../extra/ssdbresp
+
+require (
+	github.com/ssdb-go/ssdb v1.0.0
+	github.com/ssdb-go/ssdb/extra/ssdbresp v1.0.0
+)
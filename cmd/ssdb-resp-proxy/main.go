@@ -0,0 +1,66 @@
+// Command ssdb-resp-proxy serves the Redis protocol in front of an SSDB
+// server, so that Redis clients can use it unchanged.
+//
+// Usage:
+//
+//	ssdb-resp-proxy [-addr host:port] [-u url] [-password p]
+//
+// The connection settings of the SSDB server are parsed by ssdb.ParseURL.
+// See the ssdbresp package for the supported commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/extra/ssdbresp"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "ssdb-resp-proxy:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	addr := flag.String("addr", "localhost:6379", "address to serve the Redis protocol on")
+	url := flag.String("u", "ssdb://localhost:8888", "SSDB server URL")
+	password := flag.String("password", "", "password the Redis clients must send with AUTH")
+	flag.Parse()
+
+	opt, err := ssdb.ParseURL(*url)
+	if err != nil {
+		return err
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	opt.Logger = logger
+	sdb := ssdb.NewClient(opt)
+	defer sdb.Close()
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	proxy := ssdbresp.NewProxy(sdb, &ssdbresp.Options{
+		Password: *password,
+		Logger:   logger,
+	})
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		l.Close()
+	}()
+
+	logger.Info("serving the Redis protocol", "addr", l.Addr().String(), "ssdb", opt.Addr)
+	err = proxy.Serve(l)
+	proxy.Close()
+	return err
+}
@@ -7,10 +7,21 @@ import (
 	"time"
 
 	"github.com/ssdb-go/ssdb/internal"
+	"github.com/ssdb-go/ssdb/internal/hscan"
 	"github.com/ssdb-go/ssdb/internal/proto"
 	"github.com/ssdb-go/ssdb/internal/util"
 )
 
+// Statuses of the replies, sent as the first block of every reply.
+const (
+	statusOK          = "ok"
+	statusNotFound    = "not_found"
+	statusError       = "error"
+	statusFail        = "fail"
+	statusClientError = "client_error"
+	statusNoAuth      = "noauth"
+)
+
 type Cmder interface {
 	Name() string
 	FullName() string
@@ -19,8 +30,19 @@ type Cmder interface {
 	stringArg(int) string
 	firstKeyPos() int8
 	SetFirstKeyPos(int8)
+	// Info returns the registry entry of the command, or nil if the
+	// command is unknown.
+	Info() *CommandInfo
+
+	// SetReadTimeout sets the timeout for reading the reply of the command,
+	// overriding WithCommandTimeout and the Options. 0 means no timeout.
+	SetReadTimeout(time.Duration)
+	// SetWriteTimeout sets the timeout for writing the command, overriding
+	// WithCommandTimeout and the Options. 0 means no timeout.
+	SetWriteTimeout(time.Duration)
 
 	readTimeout() *time.Duration
+	writeTimeout() *time.Duration
 	readReply(rd *proto.Reader) error
 
 	SetErr(error)
@@ -58,12 +80,12 @@ func writeCmd(wr *proto.Writer, cmd Cmder) error {
 	return wr.WriteArgs(cmd.Args())
 }
 
-func cmdFirstKeyPos(cmd Cmder, info *CommandInfo) int {
+func cmdFirstKeyPos(cmd Cmder) int {
 	if pos := cmd.firstKeyPos(); pos != 0 {
 		return int(pos)
 	}
 
-	if info != nil {
+	if info := cmd.Info(); info != nil {
 		return int(info.FirstKeyPos)
 	}
 	return 1
@@ -98,7 +120,8 @@ type baseCmd struct {
 	err    error
 	keyPos int8
 
-	_readTimeout *time.Duration
+	_readTimeout  *time.Duration
+	_writeTimeout *time.Duration
 }
 
 var _ Cmder = (*Cmd)(nil)
@@ -152,6 +175,10 @@ func (cmd *baseCmd) SetFirstKeyPos(keyPos int8) {
 	cmd.keyPos = keyPos
 }
 
+func (cmd *baseCmd) Info() *CommandInfo {
+	return LookupCommand(cmd.Name())
+}
+
 func (cmd *baseCmd) SetErr(e error) {
 	cmd.err = e
 }
@@ -164,10 +191,18 @@ func (cmd *baseCmd) readTimeout() *time.Duration {
 	return cmd._readTimeout
 }
 
-func (cmd *baseCmd) setReadTimeout(d time.Duration) {
+func (cmd *baseCmd) SetReadTimeout(d time.Duration) {
 	cmd._readTimeout = &d
 }
 
+func (cmd *baseCmd) writeTimeout() *time.Duration {
+	return cmd._writeTimeout
+}
+
+func (cmd *baseCmd) SetWriteTimeout(d time.Duration) {
+	cmd._writeTimeout = &d
+}
+
 //------------------------------------------------------------------------------
 
 type Cmd struct {
@@ -446,14 +481,91 @@ func (cmd *Cmd) BoolSlice() ([]bool, error) {
 	return bools, nil
 }
 
-func (cmd *Cmd) readReply(rd *proto.Reader) (err error) {
-	//fmt.Println("readReply")
-	cmd.val, err = rd.ReadReply()
-	return err
+// Scan scans the values of the reply to dst in order, e.g. a *string, an
+// *int64 or an encoding.BinaryUnmarshaler. It is an error to pass more
+// destinations than the reply has values.
+func (cmd *Cmd) Scan(dst ...interface{}) error {
+	vals, err := cmd.values()
+	if err != nil {
+		return err
+	}
+	if len(dst) > len(vals) {
+		return fmt.Errorf("ssdb: Scan got %d destinations for %d values", len(dst), len(vals))
+	}
+	for i, v := range dst {
+		if err := proto.Scan([]byte(vals[i]), v); err != nil {
+			return fmt.Errorf("ssdb: Scan index=%d value=%q failed: %w", i, vals[i], err)
+		}
+	}
+	return nil
+}
+
+// ScanSlice appends the values of the reply to container, a pointer to a
+// slice, e.g. *[]string or *[]int64.
+func (cmd *Cmd) ScanSlice(container interface{}) error {
+	vals, err := cmd.values()
+	if err != nil {
+		return err
+	}
+	return proto.ScanSlice(vals, container)
+}
+
+// ScanStruct scans a reply of (key, value) pairs, e.g. of scan, hgetall or
+// multi_get. If dst is a pointer to a struct, the keys are matched to the
+// struct's fields with the `ssdb` tag. If dst is a pointer to a slice of
+// structs, every pair is appended as one element with the key in the field
+// tagged `ssdb:"key"` and the value in the field tagged `ssdb:"value"`.
+func (cmd *Cmd) ScanStruct(dst interface{}) error {
+	vals, err := cmd.values()
+	if err != nil {
+		return err
+	}
+	return hscan.ScanPairs(dst, vals)
+}
+
+// values returns the value of cmd as a list of strings.
+func (cmd *Cmd) values() ([]string, error) {
+	if cmd.err != nil {
+		return nil, cmd.err
+	}
+	switch val := cmd.val.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return val, nil
+	case string:
+		return []string{val}, nil
+	case []interface{}:
+		ss := make([]string, len(val))
+		for i, iface := range val {
+			s, err := toString(iface)
+			if err != nil {
+				return nil, err
+			}
+			ss[i] = s
+		}
+		return ss, nil
+	default:
+		return nil, fmt.Errorf("ssdb: unexpected type=%T for Scan", val)
+	}
+}
+
+// readReply reads the reply and sets the value of cmd to the blocks that
+// follow the status. A status other than ok is returned as an error.
+func (cmd *Cmd) readReply(rd *proto.Reader) error {
+	v, err := rd.ReadReply()
+	if err != nil {
+		return err
+	}
+	reply := v.([]string)
+	cmd.val = reply[1:]
+	return replyError(cmd.Name(), reply[0], reply[1:])
 }
 
 //------------------------------------------------------------------------------
 
+// CommandInfo describes a command. The SSDB commands are described by a
+// static registry, see LookupCommand.
 type CommandInfo struct {
 	Name        string
 	Arity       int8
@@ -462,7 +574,13 @@ type CommandInfo struct {
 	FirstKeyPos int8
 	LastKeyPos  int8
 	StepCount   int8
-	ReadOnly    bool
+	// ReadOnly commands do not modify the data.
+	ReadOnly bool
+	// Idempotent commands have the same effect when they are executed
+	// more than once, so they can be safely retried.
+	Idempotent bool
+	// Reply is the shape of the command reply.
+	Reply ReplyKind
 }
 
 type CommandsInfoCmd struct {
@@ -504,42 +622,6 @@ func (cmd *CommandsInfoCmd) readReply(rd *proto.Reader) error {
 
 //------------------------------------------------------------------------------
 
-type cmdsInfoCache struct {
-	fn func(ctx context.Context) (map[string]*CommandInfo, error)
-
-	once internal.Once
-	cmds map[string]*CommandInfo
-}
-
-func newCmdsInfoCache(fn func(ctx context.Context) (map[string]*CommandInfo, error)) *cmdsInfoCache {
-	return &cmdsInfoCache{
-		fn: fn,
-	}
-}
-
-func (c *cmdsInfoCache) Get(ctx context.Context) (map[string]*CommandInfo, error) {
-	err := c.once.Do(func() error {
-		cmds, err := c.fn(ctx)
-		if err != nil {
-			return err
-		}
-
-		// Extensions have cmd names in upper case. Convert them to lower case.
-		for k, v := range cmds {
-			lower := internal.ToLower(k)
-			if lower != k {
-				cmds[lower] = v
-			}
-		}
-
-		c.cmds = cmds
-		return nil
-	})
-	return c.cmds, err
-}
-
-//------------------------------------------------------------------------------
-
 type SlowLog struct {
 	ID       int64
 	Time     time.Time
@@ -0,0 +1,248 @@
+package ssdb
+
+import (
+	"sort"
+
+	"github.com/ssdb-go/ssdb/internal"
+)
+
+// ReplyKind describes the shape of the data that follows the status
+// in a command reply.
+type ReplyKind int8
+
+const (
+	// ReplyStatus is a reply made of the status only, e.g. set.
+	ReplyStatus ReplyKind = iota
+	// ReplyString is a single value, e.g. get.
+	ReplyString
+	// ReplyInt is a single integer, e.g. incr.
+	ReplyInt
+	// ReplyFloat is a single floating point number, e.g. zavg.
+	ReplyFloat
+	// ReplyBool is "1" or "0", e.g. exists.
+	ReplyBool
+	// ReplyList is a list of values, e.g. keys or qrange.
+	ReplyList
+	// ReplyMap is a list of key value pairs, e.g. scan or hgetall.
+	ReplyMap
+)
+
+func (k ReplyKind) String() string {
+	switch k {
+	case ReplyStatus:
+		return "status"
+	case ReplyString:
+		return "string"
+	case ReplyInt:
+		return "int"
+	case ReplyFloat:
+		return "float"
+	case ReplyBool:
+		return "bool"
+	case ReplyList:
+		return "list"
+	case ReplyMap:
+		return "map"
+	default:
+		return "unknown"
+	}
+}
+
+type cmdAccess uint8
+
+const (
+	// cmdRead commands do not modify the data and can always be retried.
+	cmdRead cmdAccess = iota
+	// cmdWrite commands modify the data but have the same effect
+	// when they are executed twice.
+	cmdWrite
+	// cmdWriteOnce commands must not be executed twice.
+	cmdWriteOnce
+)
+
+type keyPos struct {
+	first, last, step int8
+}
+
+var (
+	noKey    = keyPos{}
+	oneKey   = keyPos{1, 1, 1}
+	allKeys  = keyPos{1, -1, 1}
+	keyPairs = keyPos{1, -1, 2}
+)
+
+func newCommandInfo(name string, arity int8, access cmdAccess, keys keyPos, reply ReplyKind) *CommandInfo {
+	return &CommandInfo{
+		Name:        name,
+		Arity:       arity,
+		FirstKeyPos: keys.first,
+		LastKeyPos:  keys.last,
+		StepCount:   keys.step,
+		ReadOnly:    access == cmdRead,
+		Idempotent:  access != cmdWriteOnce,
+		Reply:       reply,
+	}
+}
+
+// commands is the registry of the SSDB commands. Arity counts the command
+// name; a negative arity is the minimum number of arguments.
+// Hash, sorted set and queue commands use the name of the collection as key.
+var commands = map[string]*CommandInfo{}
+
+func init() {
+	for _, info := range []*CommandInfo{
+		// Server.
+		newCommandInfo("auth", 2, cmdRead, noKey, ReplyStatus),
+		newCommandInfo("ping", 1, cmdRead, noKey, ReplyStatus),
+		newCommandInfo("version", 1, cmdRead, noKey, ReplyString),
+		newCommandInfo("info", -1, cmdRead, noKey, ReplyList),
+		newCommandInfo("dbsize", 1, cmdRead, noKey, ReplyInt),
+		newCommandInfo("flushdb", -1, cmdWrite, noKey, ReplyStatus),
+		newCommandInfo("compact", 1, cmdWrite, noKey, ReplyStatus),
+
+		// Key value.
+		newCommandInfo("set", 3, cmdWrite, oneKey, ReplyStatus),
+		newCommandInfo("setx", 4, cmdWrite, oneKey, ReplyStatus),
+		newCommandInfo("setnx", 3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("expire", 3, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("ttl", 2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("get", 2, cmdRead, oneKey, ReplyString),
+		newCommandInfo("getset", 3, cmdWriteOnce, oneKey, ReplyString),
+		newCommandInfo("del", 2, cmdWrite, oneKey, ReplyStatus),
+		newCommandInfo("incr", -2, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("decr", -2, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("exists", 2, cmdRead, oneKey, ReplyBool),
+		newCommandInfo("getbit", 3, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("setbit", 4, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("bitcount", -2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("countbit", -2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("substr", -3, cmdRead, oneKey, ReplyString),
+		newCommandInfo("strlen", 2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("keys", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("rkeys", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("scan", 4, cmdRead, noKey, ReplyMap),
+		newCommandInfo("rscan", 4, cmdRead, noKey, ReplyMap),
+		newCommandInfo("multi_set", -3, cmdWrite, keyPairs, ReplyStatus),
+		newCommandInfo("multi_get", -2, cmdRead, allKeys, ReplyMap),
+		newCommandInfo("multi_del", -2, cmdWrite, allKeys, ReplyStatus),
+		newCommandInfo("multi_exists", -2, cmdRead, allKeys, ReplyMap),
+
+		// Hash.
+		newCommandInfo("hset", 4, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("hget", 3, cmdRead, oneKey, ReplyString),
+		newCommandInfo("hdel", 3, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("hincr", -3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("hdecr", -3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("hexists", 3, cmdRead, oneKey, ReplyBool),
+		newCommandInfo("hsize", 2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("hlist", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("hrlist", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("hkeys", 5, cmdRead, oneKey, ReplyList),
+		newCommandInfo("hgetall", 2, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("hscan", 5, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("hrscan", 5, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("hclear", 2, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("multi_hset", -4, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("multi_hget", -3, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("multi_hdel", -3, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("multi_hexists", -3, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("multi_hsize", -2, cmdRead, allKeys, ReplyMap),
+
+		// Sorted set.
+		newCommandInfo("zset", 4, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("zget", 3, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("zdel", 3, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("zincr", 4, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("zdecr", 4, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("zexists", 3, cmdRead, oneKey, ReplyBool),
+		newCommandInfo("zsize", 2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("zlist", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("zrlist", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("zkeys", 6, cmdRead, oneKey, ReplyList),
+		newCommandInfo("zscan", 6, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("zrscan", 6, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("zrank", 3, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("zrrank", 3, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("zrange", 4, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("zrrange", 4, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("zclear", 2, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("zcount", 4, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("zsum", 4, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("zavg", 4, cmdRead, oneKey, ReplyFloat),
+		newCommandInfo("zremrangebyrank", 4, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("zremrangebyscore", 4, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("zpop_front", 3, cmdWriteOnce, oneKey, ReplyMap),
+		newCommandInfo("zpop_back", 3, cmdWriteOnce, oneKey, ReplyMap),
+		newCommandInfo("multi_zset", -4, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("multi_zget", -3, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("multi_zdel", -3, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("multi_zexists", -3, cmdRead, oneKey, ReplyMap),
+		newCommandInfo("multi_zsize", -2, cmdRead, allKeys, ReplyMap),
+
+		// Queue.
+		newCommandInfo("qpush", -3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("qpush_front", -3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("qpush_back", -3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("qpop", -2, cmdWriteOnce, oneKey, ReplyList),
+		newCommandInfo("qpop_front", -2, cmdWriteOnce, oneKey, ReplyList),
+		newCommandInfo("qpop_back", -2, cmdWriteOnce, oneKey, ReplyList),
+		newCommandInfo("qfront", 2, cmdRead, oneKey, ReplyString),
+		newCommandInfo("qback", 2, cmdRead, oneKey, ReplyString),
+		newCommandInfo("qsize", 2, cmdRead, oneKey, ReplyInt),
+		newCommandInfo("qclear", 2, cmdWrite, oneKey, ReplyInt),
+		newCommandInfo("qget", 3, cmdRead, oneKey, ReplyString),
+		newCommandInfo("qset", 4, cmdWrite, oneKey, ReplyStatus),
+		newCommandInfo("qrange", 4, cmdRead, oneKey, ReplyList),
+		newCommandInfo("qslice", 4, cmdRead, oneKey, ReplyList),
+		newCommandInfo("qtrim_front", 3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("qtrim_back", 3, cmdWriteOnce, oneKey, ReplyInt),
+		newCommandInfo("qlist", 4, cmdRead, noKey, ReplyList),
+		newCommandInfo("qrlist", 4, cmdRead, noKey, ReplyList),
+	} {
+		commands[info.Name] = info
+	}
+}
+
+// LookupCommand returns the registry entry of the SSDB command with the given
+// name, or nil if the command is unknown. The returned CommandInfo is shared
+// and must not be modified.
+func LookupCommand(name string) *CommandInfo {
+	return commands[internal.ToLower(name)]
+}
+
+// CommandNames returns the sorted names of the registered SSDB commands.
+func CommandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Keys returns the positions of the keys in args, the arguments of a
+// command including its name, according to the key positions of info.
+func (info *CommandInfo) Keys(args []interface{}) []int {
+	if info == nil || info.FirstKeyPos <= 0 || int(info.FirstKeyPos) >= len(args) {
+		return nil
+	}
+
+	last := int(info.LastKeyPos)
+	if last < 0 {
+		last += len(args)
+	}
+	if last >= len(args) {
+		last = len(args) - 1
+	}
+
+	step := int(info.StepCount)
+	if step <= 0 {
+		step = 1
+	}
+
+	var keys []int
+	for i := int(info.FirstKeyPos); i <= last; i += step {
+		keys = append(keys, i)
+	}
+	return keys
+}
@@ -0,0 +1,98 @@
+package ssdb
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLookupCommand(t *testing.T) {
+	cases := []struct {
+		name       string
+		readOnly   bool
+		idempotent bool
+		reply      ReplyKind
+	}{
+		{"get", true, true, ReplyString},
+		{"SET", false, true, ReplyStatus},
+		{"incr", false, false, ReplyInt},
+		{"qpush_back", false, false, ReplyInt},
+		{"zincr", false, false, ReplyInt},
+		{"zremrangebyrank", false, false, ReplyInt},
+		{"hgetall", true, true, ReplyMap},
+		{"multi_zsize", true, true, ReplyMap},
+	}
+
+	for _, tc := range cases {
+		info := LookupCommand(tc.name)
+		if info == nil {
+			t.Fatalf("%s is not registered", tc.name)
+		}
+		if info.ReadOnly != tc.readOnly || info.Idempotent != tc.idempotent || info.Reply != tc.reply {
+			t.Errorf("%s: got %+v", tc.name, info)
+		}
+	}
+
+	if info := LookupCommand("unknown"); info != nil {
+		t.Fatalf("got %+v, wanted nil", info)
+	}
+
+	names := CommandNames()
+	if !sort.StringsAreSorted(names) || len(names) != len(commands) {
+		t.Fatalf("got %v, wanted the sorted registered names", names)
+	}
+}
+
+func TestCommandInfoKeys(t *testing.T) {
+	cases := []struct {
+		args []interface{}
+		keys []int
+	}{
+		{[]interface{}{"get", "key"}, []int{1}},
+		{[]interface{}{"multi_set", "k1", "v1", "k2", "v2"}, []int{1, 3}},
+		{[]interface{}{"multi_get", "k1", "k2", "k3"}, []int{1, 2, 3}},
+		{[]interface{}{"hset", "hash", "field", "value"}, []int{1}},
+		{[]interface{}{"multi_hsize", "h1", "h2"}, []int{1, 2}},
+		{[]interface{}{"multi_zexists", "zset", "m1", "m2"}, []int{1}},
+		{[]interface{}{"scan", "", "", 10}, nil},
+	}
+
+	for _, tc := range cases {
+		cmd := NewCmd(context.Background(), tc.args...)
+		if keys := cmd.Info().Keys(cmd.Args()); !reflect.DeepEqual(keys, tc.keys) {
+			t.Errorf("%s: got keys %v, wanted %v", cmd.Name(), keys, tc.keys)
+		}
+	}
+}
+
+func TestReadOnlyClient(t *testing.T) {
+	client := NewClient(&Options{
+		ReadOnly: true,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			t.Fatal("a write command must not be sent")
+			return nil, nil
+		},
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, cmd := range []Cmder{
+		NewCmd(ctx, "set", "key", "value"),
+		NewCmd(ctx, "unknown", "key"),
+	} {
+		if err := client.Process(ctx, cmd); err != ErrReadOnlyClient {
+			t.Fatalf("%s: got %v, wanted %v", cmd.Name(), err, ErrReadOnlyClient)
+		}
+	}
+
+	_, err := client.Pipelined(ctx, func(pipe Pipeliner) error {
+		pipe.Process(ctx, NewCmd(ctx, "get", "key"))
+		pipe.Process(ctx, NewCmd(ctx, "qpush", "queue", "value"))
+		return nil
+	})
+	if err != ErrReadOnlyClient {
+		t.Fatalf("got %v, wanted %v", err, ErrReadOnlyClient)
+	}
+}
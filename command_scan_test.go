@@ -0,0 +1,81 @@
+package ssdb_test
+
+import (
+	"testing"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+func TestCmdScan(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: fakeServerDialerFunc(func(args []string) []string {
+			if args[1] == "missing" {
+				return []string{"not_found"}
+			}
+			switch args[0] {
+			case "get":
+				return []string{"ok", "42"}
+			case "qrange":
+				return []string{"ok", "1", "2", "3"}
+			case "hgetall":
+				return []string{"ok", "name", "alice", "age", "30", "unknown", "x"}
+			case "multi_get":
+				return []string{"ok", "a", "1", "b", "2"}
+			default:
+				return []string{"ok"}
+			}
+		}),
+		MaxRetries: -1,
+	})
+	defer sdb.Close()
+
+	var n int64
+	if err := sdb.Do(ctx, "get", "key").Scan(&n); err != nil || n != 42 {
+		t.Fatalf("got %d, %v, wanted 42", n, err)
+	}
+	var s string
+	if err := sdb.Do(ctx, "get", "key").Scan(&s, &n); err == nil {
+		t.Fatal("got nil, wanted an error for too many destinations")
+	}
+	if err := sdb.Do(ctx, "hgetall", "h").Scan(&n); err == nil {
+		t.Fatal("got nil, wanted an error for a value that is not a number")
+	}
+	if err := sdb.Do(ctx, "get", "missing").Scan(&s); err != ssdb.Nil {
+		t.Fatalf("got %v, wanted ssdb.Nil", err)
+	}
+
+	var nums []int
+	if err := sdb.Do(ctx, "qrange", "q", 0, -1).ScanSlice(&nums); err != nil {
+		t.Fatal(err)
+	}
+	if len(nums) != 3 || nums[0] != 1 || nums[2] != 3 {
+		t.Fatalf("got %v, wanted [1 2 3]", nums)
+	}
+
+	var user struct {
+		Name string `ssdb:"name"`
+		Age  int    `ssdb:"age"`
+	}
+	if err := sdb.Do(ctx, "hgetall", "h").ScanStruct(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "alice" || user.Age != 30 {
+		t.Fatalf("got %+v", user)
+	}
+
+	type kv struct {
+		Key   string `ssdb:"key"`
+		Value int64  `ssdb:"value"`
+	}
+	var kvs []kv
+	if err := sdb.Do(ctx, "multi_get", "a", "b").ScanStruct(&kvs); err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 2 || kvs[0] != (kv{"a", 1}) || kvs[1] != (kv{"b", 2}) {
+		t.Fatalf("got %+v", kvs)
+	}
+	if err := sdb.Do(ctx, "qrange", "q", 0, -1).ScanStruct(&kvs); err == nil {
+		t.Fatal("got nil, wanted an error for an odd number of values")
+	}
+}
@@ -2,9 +2,8 @@ package ssdb
 
 import (
 	"context"
+	"log/slog"
 	"time"
-
-	"github.com/ssdb-go/ssdb/internal"
 )
 
 // KeepTTL is a ssdb KEEPTTL option to keep existing TTL, it requires your ssdb-server version >= 6.0,
@@ -18,25 +17,19 @@ func usePrecise(dur time.Duration) bool {
 	return dur < time.Second || dur%time.Second != 0
 }
 
-func formatMs(ctx context.Context, dur time.Duration) int64 {
+func (c cmdable) formatMs(ctx context.Context, dur time.Duration) int64 {
 	if dur > 0 && dur < time.Millisecond {
-		internal.Logger.Printf(
-			ctx,
-			"specified duration is %s, but minimal supported value is %s - truncating to 1ms",
-			dur, time.Millisecond,
-		)
+		c.logger.WarnContext(ctx, "duration is truncated to the minimal supported value",
+			"duration", dur, "min", time.Millisecond)
 		return 1
 	}
 	return int64(dur / time.Millisecond)
 }
 
-func formatSec(ctx context.Context, dur time.Duration) int64 {
+func (c cmdable) formatSec(ctx context.Context, dur time.Duration) int64 {
 	if dur > 0 && dur < time.Second {
-		internal.Logger.Printf(
-			ctx,
-			"specified duration is %s, but minimal supported value is %s - truncating to 1s",
-			dur, time.Second,
-		)
+		c.logger.WarnContext(ctx, "duration is truncated to the minimal supported value",
+			"duration", dur, "min", time.Second)
 		return 1
 	}
 	return int64(dur / time.Second)
@@ -128,7 +121,12 @@ var (
 	_ Cmdable = (*Client)(nil)
 )
 
-type cmdable func(ctx context.Context, cmd Cmder) error
+type cmdable struct {
+	process func(ctx context.Context, cmd Cmder) error
+	// logger is the logger of the client, e.g. for the durations truncated
+	// to the precision of a command.
+	logger *slog.Logger
+}
 
 type statefulCmdable func(ctx context.Context, cmd Cmder) error
 
@@ -140,9 +138,9 @@ func (c statefulCmdable) Auth(ctx context.Context, password string) *Cmd {
 	return cmd
 }
 
-// AuthACL Perform an AUTH command, using the given user and pass.
-// Should be used to authenticate the current connection with one of the connections defined in the ACL list
-// when connecting to a ssdb 6.0 instance, or greater, that is using the ssdb ACL system.
+// AuthACL sends the auth command with the given password.
+//
+// Deprecated: SSDB has no users and username is ignored. Use Auth instead.
 func (c statefulCmdable) AuthACL(ctx context.Context, username, password string) *Cmd {
 	cmd := NewCmd(ctx, "auth", password)
 	_ = c(ctx, cmd)
@@ -159,84 +157,84 @@ func (c statefulCmdable) ClientSetName(ctx context.Context, name string) *Cmd {
 //------------------------------------------------------------------------------
 func (c cmdable) DBSize(ctx context.Context) *Cmd {
 	cmd := NewCmd(ctx, "dbsize")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) DBInfo(ctx context.Context) *Cmd {
 	cmd := NewCmd(ctx, "info")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Set(ctx context.Context, key string, val interface{}, ttl ...int64) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Ping(ctx context.Context) *Cmd {
 	cmd := NewCmd(ctx, "version")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) SetNX(ctx context.Context, key string, val interface{}) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Get(ctx context.Context, key string) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) GetSet(ctx context.Context, key string, val interface{}) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Del(ctx context.Context, key string) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) HSet(ctx context.Context, key string, val ...interface{}) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Scan(ctx context.Context, keyStart, keyEnd string, limit int64) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Expire(ctx context.Context, key string, ttl int64) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Exists(ctx context.Context, key string) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) TTL(ctx context.Context, key string) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
 
 func (c cmdable) Incr(ctx context.Context, key string, num int64) *Cmd {
 	cmd := NewCmd(ctx, "command")
-	_ = c(ctx, cmd)
+	_ = c.process(ctx, cmd)
 	return cmd
 }
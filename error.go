@@ -2,9 +2,9 @@ package ssdb
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net"
-	"strings"
 
 	"github.com/ssdb-go/ssdb/internal/pool"
 	"github.com/ssdb-go/ssdb/internal/proto"
@@ -13,6 +13,131 @@ import (
 // ErrClosed performs any operation on the closed client will return this error.
 var ErrClosed = pool.ErrClosed
 
+// ErrPoolTimeout matches, with errors.Is, the PoolTimeoutError returned
+// when no connection became available within Options.PoolTimeout.
+var ErrPoolTimeout = pool.ErrPoolTimeout
+
+// PoolTimeoutError is returned when no connection of the pool became
+// available within Options.PoolTimeout.
+type PoolTimeoutError = pool.TimeoutError
+
+// NetworkError is returned when the connection to the server can't be
+// dialed, or fails while a command is written or its reply read, e.g. on
+// a timeout or when the server closes it. It wraps the net.Error.
+type NetworkError = pool.NetworkError
+
+// ProtocolError is returned when the reply of the server can't be parsed.
+type ProtocolError = proto.ProtocolError
+
+// ErrPoolOverload is returned, with Options.PoolShedLoad, when the estimated
+// wait for a connection exceeds the deadline of the command context.
+var ErrPoolOverload = pool.ErrPoolOverload
+
+// ErrNotFound is returned when the server replies not_found, e.g. by get
+// when the key does not exist. It is Nil.
+const ErrNotFound = Nil
+
+// ServerError is returned when the server replies that it failed to execute
+// a command.
+type ServerError struct {
+	// Status is the reply status, "error" or "fail".
+	Status  string
+	Message string
+	// Cmd is the name of the command.
+	Cmd string
+}
+
+var _ Error = (*ServerError)(nil)
+
+func (e *ServerError) Error() string {
+	s := "ssdb: " + e.Cmd + ": " + e.Status
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+func (e *ServerError) SsdbError() {}
+
+// ClientError is returned when the server rejects a malformed command, e.g.
+// with a wrong number of arguments, and replies with the client_error status.
+type ClientError struct {
+	Message string
+	// Cmd is the name of the command.
+	Cmd string
+}
+
+var _ Error = (*ClientError)(nil)
+
+func (e *ClientError) Error() string {
+	s := "ssdb: " + e.Cmd + ": client_error"
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+func (e *ClientError) SsdbError() {}
+
+// replyError returns the error described by the status of the reply to the
+// command name, followed by data.
+func replyError(name, status string, data []string) error {
+	var msg string
+	if len(data) > 0 {
+		msg = data[0]
+	}
+	switch status {
+	case statusOK:
+		return nil
+	case statusNotFound:
+		return Nil
+	case statusError, statusFail:
+		return &ServerError{Status: status, Message: msg, Cmd: name}
+	case statusClientError:
+		return &ClientError{Message: msg, Cmd: name}
+	case statusNoAuth:
+		return &AuthError{Status: status, Message: msg}
+	default:
+		return &ProtocolError{Msg: "unknown reply status", Data: []byte(status)}
+	}
+}
+
+// ErrAuth matches, with errors.Is, the errors returned when the server
+// rejects the password or requires authentication.
+var ErrAuth = errors.New("ssdb: authentication failed")
+
+// AuthError is returned when the server rejects the auth command, or replies
+// noauth to a command sent on a connection that is not authenticated.
+type AuthError struct {
+	// Status is the reply status, e.g. "error" or "noauth".
+	Status  string
+	Message string
+}
+
+var _ Error = (*AuthError)(nil)
+
+func (e *AuthError) Error() string {
+	if e.Message == "" {
+		return "ssdb: auth " + e.Status
+	}
+	return "ssdb: auth " + e.Status + ": " + e.Message
+}
+
+func (e *AuthError) SsdbError() {}
+
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuth
+}
+
+func isNoAuthError(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && authErr.Status == statusNoAuth
+}
+
+// ErrReadOnlyClient is returned when a command that may modify the data is
+// processed by a client with Options.ReadOnly set.
+var ErrReadOnlyClient = errors.New("ssdb: write command on a read only client")
+
 type Error interface {
 	error
 
@@ -26,71 +151,47 @@ type Error interface {
 var _ Error = proto.SsdbError("")
 
 func shouldRetry(err error, retryTimeout bool) bool {
-	switch err {
-	case io.EOF, io.ErrUnexpectedEOF:
-		return true
-	case nil, context.Canceled, context.DeadlineExceeded:
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
 		return false
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
 	}
 
-	if v, ok := err.(timeoutError); ok {
-		if v.Timeout() {
+	var timeoutErr timeoutError
+	if errors.As(err, &timeoutErr) {
+		if timeoutErr.Timeout() {
 			return retryTimeout
 		}
 		return true
 	}
 
-	s := err.Error()
-	if s == "ERR max number of clients reached" {
-		return true
-	}
-	if strings.HasPrefix(s, "LOADING ") {
-		return true
-	}
-	if strings.HasPrefix(s, "READONLY ") {
-		return true
-	}
-	if strings.HasPrefix(s, "CLUSTERDOWN ") {
-		return true
-	}
-	if strings.HasPrefix(s, "TRYAGAIN ") {
-		return true
-	}
-
+	// The errors replied by the server are not transient, and the pool
+	// timeout already waited for PoolTimeout.
 	return false
 }
 
 func isSsdbError(err error) bool {
-	_, ok := err.(proto.SsdbError)
-	return ok
+	var ssdbErr Error
+	return errors.As(err, &ssdbErr)
 }
 
-func isBadConn(err error, allowTimeout bool, addr string) bool {
-	switch err {
-	case nil:
+func isBadConn(err error, allowTimeout bool) bool {
+	switch {
+	case err == nil:
 		return false
-	case context.Canceled, context.DeadlineExceeded:
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
 		return true
 	}
 
 	if isSsdbError(err) {
-		switch {
-		case isReadOnlyError(err):
-			// Close connections in read only state in case domain addr is used
-			// and domain resolves to a different Ssdb Server. See #790.
-			return true
-		case isMovedSameConnAddr(err, addr):
-			// Close connections when we are asked to move to the same addr
-			// of the connection. Force a DNS resolution when all connections
-			// of the pool are recycled
-			return true
-		default:
-			return false
-		}
+		// The reply was fully read.
+		return false
 	}
 
 	if allowTimeout {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
 			return false
 		}
 	}
@@ -98,43 +199,13 @@ func isBadConn(err error, allowTimeout bool, addr string) bool {
 	return true
 }
 
-func isMovedError(err error) (moved bool, ask bool, addr string) {
-	if !isSsdbError(err) {
-		return
-	}
-
-	s := err.Error()
-	switch {
-	case strings.HasPrefix(s, "MOVED "):
-		moved = true
-	case strings.HasPrefix(s, "ASK "):
-		ask = true
-	default:
-		return
-	}
-
-	ind := strings.LastIndex(s, " ")
-	if ind == -1 {
-		return false, false, ""
-	}
-	addr = s[ind+1:]
-	return
-}
-
-func isLoadingError(err error) bool {
-	return strings.HasPrefix(err.Error(), "LOADING ")
-}
-
-func isReadOnlyError(err error) bool {
-	return strings.HasPrefix(err.Error(), "READONLY ")
-}
-
-func isMovedSameConnAddr(err error, addr string) bool {
-	ssdbError := err.Error()
-	if !strings.HasPrefix(ssdbError, "MOVED ") {
-		return false
+// checkReadOnly returns ErrReadOnlyClient if cmd is not known to be
+// read only.
+func checkReadOnly(cmd Cmder) error {
+	if info := cmd.Info(); info != nil && info.ReadOnly {
+		return nil
 	}
-	return strings.HasSuffix(ssdbError, " "+addr)
+	return ErrReadOnlyClient
 }
 
 //------------------------------------------------------------------------------
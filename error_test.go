@@ -0,0 +1,127 @@
+package ssdb_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+func TestReplyErrors(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: fakeServerDialerFunc(func(args []string) []string {
+			switch args[1] {
+			case "missing":
+				return []string{"not_found"}
+			case "broken":
+				return []string{"error", "disk failure"}
+			case "malformed":
+				return []string{"client_error", "wrong number of arguments"}
+			case "unknown":
+				return []string{"maybe"}
+			default:
+				return []string{"ok", "value"}
+			}
+		}),
+		MaxRetries: -1,
+	})
+	defer sdb.Close()
+
+	get := sdb.Do(ctx, "get", "key")
+	if err := get.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if val, ok := get.Val().([]string); !ok || len(val) != 1 || val[0] != "value" {
+		t.Fatalf("got %#v, wanted the blocks after the status", get.Val())
+	}
+
+	err := sdb.Do(ctx, "get", "missing").Err()
+	if err != ssdb.Nil || !errors.Is(err, ssdb.ErrNotFound) {
+		t.Fatalf("got %v, wanted ssdb.ErrNotFound", err)
+	}
+
+	var serverErr *ssdb.ServerError
+	err = sdb.Do(ctx, "get", "broken").Err()
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("got %v, wanted a ServerError", err)
+	}
+	if *serverErr != (ssdb.ServerError{Status: "error", Message: "disk failure", Cmd: "get"}) {
+		t.Fatalf("got %+v", serverErr)
+	}
+
+	var clientErr *ssdb.ClientError
+	if err := sdb.Do(ctx, "get", "malformed").Err(); !errors.As(err, &clientErr) {
+		t.Fatalf("got %v, wanted a ClientError", err)
+	}
+	if clientErr.Message != "wrong number of arguments" {
+		t.Fatalf("got %+v", clientErr)
+	}
+
+	var protoErr *ssdb.ProtocolError
+	if err := sdb.Do(ctx, "get", "unknown").Err(); !errors.As(err, &protoErr) {
+		t.Fatalf("got %v, wanted a ProtocolError", err)
+	}
+	if string(protoErr.Data) != "maybe" {
+		t.Fatalf("got %+v", protoErr)
+	}
+
+	// Only the unknown status made the connection unusable.
+	if stats := sdb.PoolStats(); stats.Closed.BadConn != 1 {
+		t.Fatalf("got %d bad conns, wanted 1", stats.Closed.BadConn)
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go func() {
+				// Close the connection instead of replying.
+				buf := make([]byte, 64)
+				_, _ = server.Read(buf)
+				server.Close()
+			}()
+			return client, nil
+		},
+		MaxRetries: -1,
+	})
+	defer sdb.Close()
+
+	var netErr *ssdb.NetworkError
+	if err := sdb.Ping(ctx).Err(); !errors.As(err, &netErr) {
+		t.Fatalf("got %v, wanted a NetworkError", err)
+	}
+	if netErr.Op != "read" || netErr.Timeout() {
+		t.Fatalf("got %+v", netErr)
+	}
+}
+
+func TestPoolTimeoutError(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:        "fake:8888",
+		Dialer:      fakeServerDialer(),
+		PoolSize:    1,
+		PoolTimeout: 10 * time.Millisecond,
+	})
+	defer sdb.Close()
+
+	conn := sdb.Conn()
+	defer conn.Close()
+	if err := conn.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := sdb.Ping(ctx).Err()
+	var timeoutErr *ssdb.PoolTimeoutError
+	if !errors.As(err, &timeoutErr) || !errors.Is(err, ssdb.ErrPoolTimeout) {
+		t.Fatalf("got %v, wanted a PoolTimeoutError", err)
+	}
+	if timeoutErr.Timeout != 10*time.Millisecond {
+		t.Fatalf("got %+v", timeoutErr)
+	}
+}
@@ -1,14 +1,15 @@
 module github.com/ssdb-go/ssdb/extra/ssdbcensus
 
-go 1.15
+go 1.21
 
 replace github.com/ssdb-go/ssdb => ../..
 
-replace github.com/go-ssdb/ssdb/extra/ssdbcmd => ../ssdbcmd
+replace github.com/ssdb-go/ssdb/extra/ssdbcmd => ../ssdbcmd
 
 require (
-	github.com/ssdb-go/ssdb/extra/ssdbcmd
-	github.com/ssdb-go/ssdb
-	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
+	github.com/ssdb-go/ssdb v1.0.0
+	github.com/ssdb-go/ssdb/extra/ssdbcmd v1.0.0
 	go.opencensus.io v0.23.0
 )
+
+require github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
@@ -2,6 +2,7 @@ package ssdbcensus
 
 import (
 	"context"
+	"errors"
 
 	"go.opencensus.io/trace"
 
@@ -34,16 +35,41 @@ func (TracingHook) AfterProcess(ctx context.Context, cmd ssdb.Cmder) error {
 	return nil
 }
 
+// BeforeProcessPipeline starts a single span for the whole pipeline.
+// The span is named after the unique command names in the pipeline.
 func (TracingHook) BeforeProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) (context.Context, error) {
+	summary, cmdsString := ssdbcmd.CmdsString(cmds)
+
+	ctx, span := trace.StartSpan(ctx, "pipeline "+summary)
+	span.AddAttributes(trace.StringAttribute("db.system", "ssdb"),
+		trace.Int64Attribute("ssdb.num_cmd", int64(len(cmds))),
+		trace.StringAttribute("ssdb.cmds", cmdsString))
+
 	return ctx, nil
 }
 
+// AfterProcessPipeline annotates the pipeline span with the status of every
+// command. Commands that failed with an error replied by the server do not
+// fail the span; only an error that failed the pipeline as a whole does.
 func (TracingHook) AfterProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) error {
+	span := trace.FromContext(ctx)
+	for i, cmd := range cmds {
+		attrs := []trace.Attribute{
+			trace.Int64Attribute("ssdb.cmd.index", int64(i)),
+			trace.StringAttribute("ssdb.cmd.name", cmd.FullName()),
+			trace.StringAttribute("ssdb.cmd.status", ssdbcmd.CmdStatus(cmd)),
+		}
+		span.Annotate(attrs, ssdbcmd.CmdString(cmd))
+	}
+	if err := ssdbcmd.PipelineError(cmds); err != nil {
+		recordErrorOnOCSpan(ctx, span, err)
+	}
+	span.End()
 	return nil
 }
 
 func recordErrorOnOCSpan(ctx context.Context, span *trace.Span, err error) {
-	if err != ssdb.Nil {
+	if !errors.Is(err, ssdb.ErrNotFound) {
 		span.AddAttributes(trace.BoolAttribute("error", true))
 		span.Annotate([]trace.Attribute{trace.StringAttribute("Error", "ssdb error")}, err.Error())
 	}
@@ -0,0 +1,84 @@
+package ssdbcensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.opencensus.io/trace"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	r.mu.Unlock()
+}
+
+func TestPipelineSpan(t *testing.T) {
+	rec := new(spanRecorder)
+	trace.RegisterExporter(rec)
+	defer trace.UnregisterExporter(rec)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+	ctx := context.TODO()
+	get := ssdb.NewCmd(ctx, "get", "key")
+	set := ssdb.NewCmd(ctx, "set", "key", "value")
+	cmds := []ssdb.Cmder{get, set}
+
+	hook := NewTracingHook()
+	ctx, err := hook.BeforeProcessPipeline(ctx, cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.SetErr(ssdb.Nil)
+	if err := hook.AfterProcessPipeline(ctx, cmds); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.spans) != 1 {
+		t.Fatalf("got %d spans, wanted 1", len(rec.spans))
+	}
+	span := rec.spans[0]
+	if span.Name != "pipeline get set" {
+		t.Fatalf("span name = %q", span.Name)
+	}
+	if _, ok := span.Attributes["error"]; ok {
+		t.Fatal("a nil reply must not fail the pipeline span")
+	}
+	if len(span.Annotations) != 2 {
+		t.Fatalf("got %d annotations, wanted 2", len(span.Annotations))
+	}
+	if status := span.Annotations[0].Attributes["ssdb.cmd.status"]; status != "nil" {
+		t.Fatalf("ssdb.cmd.status = %v", status)
+	}
+}
+
+func TestPipelineSpanError(t *testing.T) {
+	rec := new(spanRecorder)
+	trace.RegisterExporter(rec)
+	defer trace.UnregisterExporter(rec)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+
+	ctx := context.TODO()
+	cmds := []ssdb.Cmder{ssdb.NewCmd(ctx, "get", "key")}
+
+	hook := NewTracingHook()
+	ctx, _ = hook.BeforeProcessPipeline(ctx, cmds)
+	cmds[0].SetErr(errors.New("connection reset"))
+	_ = hook.AfterProcessPipeline(ctx, cmds)
+
+	if len(rec.spans) != 1 {
+		t.Fatalf("got %d spans, wanted 1", len(rec.spans))
+	}
+	if v := rec.spans[0].Attributes["error"]; v != true {
+		t.Fatalf("error = %v, wanted true", v)
+	}
+}
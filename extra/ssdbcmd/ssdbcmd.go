@@ -2,6 +2,7 @@ package ssdbcmd
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -50,6 +51,44 @@ func CmdsString(cmds []ssdb.Cmder) (string, string) {
 	return summary, String(b)
 }
 
+// Command statuses reported by CmdStatus.
+const (
+	StatusOK    = "ok"
+	StatusNil   = "nil"
+	StatusError = "error"
+)
+
+// CmdStatus returns the status of the processed cmd: StatusNil when the
+// key does not exist, StatusError when the command failed and StatusOK otherwise.
+func CmdStatus(cmd ssdb.Cmder) string {
+	switch err := cmd.Err(); {
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, ssdb.ErrNotFound):
+		return StatusNil
+	default:
+		return StatusError
+	}
+}
+
+// PipelineError returns the error that failed the pipeline as a whole, e.g.
+// a network error. Errors replied by the server, including ssdb.Nil, belong to
+// the individual commands and are not returned.
+func PipelineError(cmds []ssdb.Cmder) error {
+	for _, cmd := range cmds {
+		err := cmd.Err()
+		if err == nil {
+			continue
+		}
+		var ssdbErr ssdb.Error
+		if errors.As(err, &ssdbErr) {
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
 func AppendCmd(b []byte, cmd ssdb.Cmder) []byte {
 	const numArgLimit = 32
 
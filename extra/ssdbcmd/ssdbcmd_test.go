@@ -1,11 +1,15 @@
 package ssdbcmd
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+
+	"github.com/ssdb-go/ssdb"
 )
 
 func TestGinkgo(t *testing.T) {
@@ -30,3 +34,31 @@ var _ = Describe("AppendArg", func() {
 		Entry("", "\000", "00"),
 	)
 })
+
+var _ = Describe("CmdStatus", func() {
+	ctx := context.TODO()
+
+	It("reports command statuses", func() {
+		ok := ssdb.NewCmd(ctx, "get", "key")
+		Expect(CmdStatus(ok)).To(Equal(StatusOK))
+
+		nilCmd := ssdb.NewCmd(ctx, "get", "key")
+		nilCmd.SetErr(ssdb.Nil)
+		Expect(CmdStatus(nilCmd)).To(Equal(StatusNil))
+
+		failed := ssdb.NewCmd(ctx, "get", "key")
+		failed.SetErr(errors.New("boom"))
+		Expect(CmdStatus(failed)).To(Equal(StatusError))
+	})
+
+	It("ignores reply errors in PipelineError", func() {
+		get := ssdb.NewCmd(ctx, "get", "key")
+		get.SetErr(ssdb.Nil)
+		set := ssdb.NewCmd(ctx, "set", "key", "value")
+		Expect(PipelineError([]ssdb.Cmder{get, set})).NotTo(HaveOccurred())
+
+		netErr := errors.New("connection reset")
+		set.SetErr(netErr)
+		Expect(PipelineError([]ssdb.Cmder{get, set})).To(Equal(netErr))
+	})
+})
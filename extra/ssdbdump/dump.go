@@ -0,0 +1,233 @@
+// Package ssdbdump dumps the data of an SSDB server to a portable JSON lines
+// format and restores it.
+//
+// A dump is made of a header line, one JSON record per line and a trailer
+// line that carries the number of records and their CRC-32. The dump is read
+// with the range commands, e.g. scan and hscan, so it is not a consistent
+// snapshot of a server that is being written to.
+package ssdbdump
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// Client is the subset of *ssdb.Client used by Dump and Restore.
+type Client interface {
+	Do(ctx context.Context, args ...interface{}) *ssdb.Cmd
+	Pipeline() ssdb.Pipeliner
+}
+
+var _ Client = (*ssdb.Client)(nil)
+
+const defaultBatchSize = 1000
+
+// DumpOptions configure Dump.
+type DumpOptions struct {
+	// Types are the types of data to dump. All of them are dumped by default.
+	Types []Type
+	// BatchSize is the number of keys or entries read per range command and
+	// written per record. Defaults to 1000.
+	BatchSize int
+}
+
+func (opt *DumpOptions) init() {
+	if len(opt.Types) == 0 {
+		opt.Types = []Type{TypeKV, TypeHash, TypeZSet, TypeQueue}
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = defaultBatchSize
+	}
+}
+
+// Dump writes every kv key, hash, sorted set and queue of c to w. It does
+// not close w.
+func Dump(ctx context.Context, c Client, w *Writer, opt *DumpOptions) error {
+	if opt == nil {
+		opt = &DumpOptions{}
+	}
+	o := *opt
+	o.init()
+
+	d := &dumper{c: c, w: w, batch: o.BatchSize}
+	for _, typ := range o.Types {
+		var err error
+		switch typ {
+		case TypeKV:
+			err = d.dumpKV(ctx)
+		case TypeHash:
+			err = d.dumpNames(ctx, "hlist", d.dumpHash)
+		case TypeZSet:
+			err = d.dumpNames(ctx, "zlist", d.dumpZSet)
+		case TypeQueue:
+			err = d.dumpNames(ctx, "qlist", d.dumpQueue)
+		default:
+			err = fmt.Errorf("ssdbdump: unknown type %q", typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dumper struct {
+	c     Client
+	w     *Writer
+	batch int
+}
+
+func (d *dumper) do(ctx context.Context, args ...interface{}) ([]string, error) {
+	cmd := d.c.Do(ctx, args...)
+	if err := cmd.Err(); err != nil {
+		return nil, err
+	}
+	vals, _ := cmd.Val().([]string)
+	return vals, nil
+}
+
+func (d *dumper) dumpKV(ctx context.Context) error {
+	start := ""
+	for {
+		pairs, err := d.do(ctx, "scan", start, "", d.batch)
+		if err != nil {
+			return err
+		}
+		if len(pairs) < 2 {
+			return nil
+		}
+
+		ttls, err := d.ttls(ctx, pairs)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			rec := &Record{Type: TypeKV, Key: Bytes(pairs[i]), Value: Bytes(pairs[i+1]), TTL: ttls[i/2]}
+			if err := d.w.Write(rec); err != nil {
+				return err
+			}
+		}
+		start = pairs[len(pairs)-2]
+	}
+}
+
+// ttls returns the TTLs of the keys of pairs, 0 for the keys without one.
+func (d *dumper) ttls(ctx context.Context, pairs []string) ([]int64, error) {
+	pipe := d.c.Pipeline()
+	cmds := make([]*ssdb.Cmd, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		cmds = append(cmds, pipe.Do(ctx, "ttl", pairs[i]))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	ttls := make([]int64, len(cmds))
+	for i, cmd := range cmds {
+		vals, _ := cmd.Val().([]string)
+		if len(vals) == 0 {
+			continue
+		}
+		ttl, err := strconv.ParseInt(vals[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ssdbdump: ttl %q: %w", pairs[2*i], err)
+		}
+		if ttl > 0 {
+			ttls[i] = ttl
+		}
+	}
+	return ttls, nil
+}
+
+// dumpNames lists the names of the collections with the list command and
+// dumps each of them with fn.
+func (d *dumper) dumpNames(ctx context.Context, list string, fn func(ctx context.Context, name string) error) error {
+	start := ""
+	for {
+		names, err := d.do(ctx, list, start, "", d.batch)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		for _, name := range names {
+			if err := fn(ctx, name); err != nil {
+				return err
+			}
+		}
+		start = names[len(names)-1]
+	}
+}
+
+func (d *dumper) dumpHash(ctx context.Context, name string) error {
+	start := ""
+	for {
+		pairs, err := d.do(ctx, "hscan", name, start, "", d.batch)
+		if err != nil {
+			return err
+		}
+		if len(pairs) < 2 {
+			return nil
+		}
+
+		rec := &Record{Type: TypeHash, Key: Bytes(name), Fields: make([]Field, 0, len(pairs)/2)}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			rec.Fields = append(rec.Fields, Field{Key: Bytes(pairs[i]), Value: Bytes(pairs[i+1])})
+		}
+		if err := d.w.Write(rec); err != nil {
+			return err
+		}
+		start = pairs[len(pairs)-2]
+	}
+}
+
+func (d *dumper) dumpZSet(ctx context.Context, name string) error {
+	keyStart, scoreStart := "", ""
+	for {
+		pairs, err := d.do(ctx, "zscan", name, keyStart, scoreStart, "", d.batch)
+		if err != nil {
+			return err
+		}
+		if len(pairs) < 2 {
+			return nil
+		}
+
+		rec := &Record{Type: TypeZSet, Key: Bytes(name), Members: make([]Member, 0, len(pairs)/2)}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			score, err := strconv.ParseInt(pairs[i+1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("ssdbdump: zscan %q: score %q: %w", name, pairs[i+1], err)
+			}
+			rec.Members = append(rec.Members, Member{Key: Bytes(pairs[i]), Score: score})
+		}
+		if err := d.w.Write(rec); err != nil {
+			return err
+		}
+		keyStart, scoreStart = pairs[len(pairs)-2], pairs[len(pairs)-1]
+	}
+}
+
+func (d *dumper) dumpQueue(ctx context.Context, name string) error {
+	for offset := 0; ; {
+		items, err := d.do(ctx, "qrange", name, offset, d.batch)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		rec := &Record{Type: TypeQueue, Key: Bytes(name), Items: make([]Bytes, len(items))}
+		for i, item := range items {
+			rec.Items[i] = Bytes(item)
+		}
+		if err := d.w.Write(rec); err != nil {
+			return err
+		}
+		offset += len(items)
+	}
+}
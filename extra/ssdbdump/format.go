@@ -0,0 +1,382 @@
+package ssdbdump
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Version is the version of the dump format written by Writer.
+const Version = 1
+
+const formatName = "ssdb-dump"
+
+var (
+	// ErrChecksum is returned by Reader when the records don't match the
+	// checksum of the dump.
+	ErrChecksum = errors.New("ssdbdump: checksum mismatch")
+	// ErrTruncated is returned by Reader when the dump ends before its trailer.
+	ErrTruncated = errors.New("ssdbdump: dump is truncated")
+)
+
+// Type is the data type of a record.
+type Type string
+
+const (
+	TypeKV    Type = "kv"
+	TypeHash  Type = "hash"
+	TypeZSet  Type = "zset"
+	TypeQueue Type = "queue"
+)
+
+// typeEnd is the type of the trailer that ends a dump.
+const typeEnd Type = "end"
+
+// Bytes is binary data. It is encoded as a JSON string if it is valid UTF-8
+// and as an object holding the base64 encoded data otherwise.
+type Bytes string
+
+// base64Bytes is the encoding of Bytes that are not valid UTF-8; encoding/json
+// encodes []byte in base64.
+type base64Bytes struct {
+	Base64 []byte `json:"base64"`
+}
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(string(b)) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(base64Bytes{Base64: []byte(b)})
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var v base64Bytes
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*b = Bytes(v.Base64)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*b = Bytes(s)
+	return nil
+}
+
+// Field is a field of a hash.
+type Field struct {
+	Key   Bytes `json:"key"`
+	Value Bytes `json:"value"`
+}
+
+// Member is a member of a sorted set.
+type Member struct {
+	Key   Bytes `json:"key"`
+	Score int64 `json:"score"`
+}
+
+// Record is a kv key or a part of a hash, sorted set or queue. Large
+// collections are split into several records with the same key.
+type Record struct {
+	Type Type
+	Key  Bytes
+
+	// Value is the value of a kv key.
+	Value Bytes
+	// TTL is the time to live of a kv key in seconds, 0 if it has none.
+	TTL int64
+	// Fields are the fields of a hash.
+	Fields []Field
+	// Members are the members of a sorted set.
+	Members []Member
+	// Items are the items of a queue, from the front.
+	Items []Bytes
+}
+
+// jsonRecord is the encoding of Record: only the fields of its type are set.
+type jsonRecord struct {
+	Type    Type     `json:"type"`
+	Key     Bytes    `json:"key"`
+	Value   *Bytes   `json:"value,omitempty"`
+	TTL     int64    `json:"ttl,omitempty"`
+	Fields  []Field  `json:"fields,omitempty"`
+	Members []Member `json:"members,omitempty"`
+	Items   []Bytes  `json:"items,omitempty"`
+}
+
+func (r *Record) MarshalJSON() ([]byte, error) {
+	v := jsonRecord{Type: r.Type, Key: r.Key}
+	switch r.Type {
+	case TypeKV:
+		v.Value = &r.Value
+		v.TTL = r.TTL
+	case TypeHash:
+		v.Fields = r.Fields
+	case TypeZSet:
+		v.Members = r.Members
+	case TypeQueue:
+		v.Items = r.Items
+	default:
+		return nil, fmt.Errorf("ssdbdump: unknown record type %q", r.Type)
+	}
+	return json.Marshal(v)
+}
+
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var v jsonRecord
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = Record{
+		Type:    v.Type,
+		Key:     v.Key,
+		TTL:     v.TTL,
+		Fields:  v.Fields,
+		Members: v.Members,
+		Items:   v.Items,
+	}
+	if v.Value != nil {
+		r.Value = *v.Value
+	}
+	return nil
+}
+
+// size returns the number of entries of the record.
+func (r *Record) size() int {
+	switch r.Type {
+	case TypeHash:
+		return len(r.Fields)
+	case TypeZSet:
+		return len(r.Members)
+	case TypeQueue:
+		return len(r.Items)
+	default:
+		return 1
+	}
+}
+
+// Header is the first line of a dump.
+type Header struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// trailer is the last line of a dump.
+type trailer struct {
+	Type    Type   `json:"type"`
+	Records int64  `json:"records"`
+	CRC32   string `json:"crc32"`
+}
+
+//------------------------------------------------------------------------------
+
+// WriterOptions configure a Writer.
+type WriterOptions struct {
+	// Compress compresses the dump with gzip.
+	Compress bool
+}
+
+// Writer writes a dump: a header line, one JSON record per line and a
+// trailer line with the number of records and the CRC-32 of their lines.
+type Writer struct {
+	gz  *gzip.Writer
+	bw  *bufio.Writer
+	crc hash.Hash32
+	n   int64
+	buf bytes.Buffer
+}
+
+// NewWriter writes the header of a dump to w and returns a Writer for its
+// records. Close must be called to complete the dump.
+func NewWriter(w io.Writer, opt *WriterOptions) (*Writer, error) {
+	if opt == nil {
+		opt = &WriterOptions{}
+	}
+
+	dw := &Writer{crc: crc32.NewIEEE()}
+	if opt.Compress {
+		dw.gz = gzip.NewWriter(w)
+		w = dw.gz
+	}
+	dw.bw = bufio.NewWriter(w)
+
+	header := Header{Format: formatName, Version: Version, Created: time.Now().UTC()}
+	if err := dw.writeLine(header); err != nil {
+		return nil, err
+	}
+	return dw, nil
+}
+
+// Write writes rec to the dump.
+func (w *Writer) Write(rec *Record) error {
+	w.buf.Reset()
+	if err := json.NewEncoder(&w.buf).Encode(rec); err != nil {
+		return err
+	}
+	w.crc.Write(w.buf.Bytes()) //nolint:errcheck
+	w.n++
+	_, err := w.bw.Write(w.buf.Bytes())
+	return err
+}
+
+// Count returns the number of records written.
+func (w *Writer) Count() int64 {
+	return w.n
+}
+
+// Close writes the trailer and flushes the dump. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	t := trailer{Type: typeEnd, Records: w.n, CRC32: fmt.Sprintf("%08x", w.crc.Sum32())}
+	if err := w.writeLine(t); err != nil {
+		return err
+	}
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+func (w *Writer) writeLine(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.bw.Write(b); err != nil {
+		return err
+	}
+	return w.bw.WriteByte('\n')
+}
+
+//------------------------------------------------------------------------------
+
+// Reader reads the records of a dump written by Writer, compressed or not.
+type Reader struct {
+	rd     *bufio.Reader
+	header Header
+	crc    hash.Hash32
+	n      int64
+	done   bool
+}
+
+// NewReader reads the header of the dump from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	rd := bufio.NewReader(r)
+	if magic, err := rd.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(rd)
+		if err != nil {
+			return nil, err
+		}
+		rd = bufio.NewReader(gz)
+	}
+
+	dr := &Reader{rd: rd, crc: crc32.NewIEEE()}
+	line, err := dr.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(line, &dr.header); err != nil || dr.header.Format != formatName {
+		return nil, errors.New("ssdbdump: not a dump")
+	}
+	if dr.header.Version != Version {
+		return nil, fmt.Errorf("ssdbdump: unsupported version %d", dr.header.Version)
+	}
+	return dr, nil
+}
+
+// Header returns the header of the dump.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Count returns the number of records read.
+func (r *Reader) Count() int64 {
+	return r.n
+}
+
+// Read returns the next record. It returns io.EOF after the last record once
+// the trailer is verified, ErrChecksum if it does not match the records and
+// ErrTruncated if the dump has no trailer.
+func (r *Reader) Read() (*Record, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	var rec Record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("ssdbdump: record %d: %w", r.n+1, err)
+	}
+
+	switch rec.Type {
+	case TypeKV, TypeHash, TypeZSet, TypeQueue:
+	case typeEnd:
+		return nil, r.readTrailer(line)
+	default:
+		return nil, fmt.Errorf("ssdbdump: record %d: unknown type %q", r.n+1, rec.Type)
+	}
+
+	r.crc.Write(line) //nolint:errcheck
+	r.n++
+	return &rec, nil
+}
+
+func (r *Reader) readTrailer(line []byte) error {
+	var t trailer
+	if err := json.Unmarshal(line, &t); err != nil {
+		return err
+	}
+	if t.Records != r.n || t.CRC32 != fmt.Sprintf("%08x", r.crc.Sum32()) {
+		return ErrChecksum
+	}
+	r.done = true
+	return io.EOF
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.rd.ReadBytes('\n')
+	if err != nil {
+		if err == io.EOF {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	return line, nil
+}
+
+// Verify reads the dump from r and checks its checksum without restoring it.
+// It returns the header and the number of records of the dump.
+func Verify(r io.Reader) (Header, int64, error) {
+	dr, err := NewReader(r)
+	if err != nil {
+		return Header{}, 0, err
+	}
+	for {
+		if _, err := dr.Read(); err != nil {
+			if err == io.EOF {
+				return dr.header, dr.n, nil
+			}
+			return dr.header, dr.n, err
+		}
+	}
+}
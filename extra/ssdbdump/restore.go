@@ -0,0 +1,139 @@
+package ssdbdump
+
+import (
+	"context"
+	"io"
+)
+
+// RestoreOptions configure Restore.
+type RestoreOptions struct {
+	// BatchSize is the number of entries written per pipeline. Defaults to
+	// 1000.
+	BatchSize int
+	// Skip is the number of records to skip, e.g. the number of records
+	// restored before an interrupted restore, as reported by OnProgress.
+	Skip int64
+	// OnProgress is called after each pipeline with the number of records
+	// of the dump that are restored, including the skipped ones.
+	OnProgress func(restored int64)
+}
+
+// Restore writes the records read from r to c with the multi_* commands and
+// returns the number of records restored, including the skipped ones.
+//
+// The checksum of the dump is verified once all the records are read, so a
+// corrupted dump can be partially restored before ErrChecksum is returned;
+// use Verify first to avoid it. Restoring kv keys, hashes and sorted sets is
+// idempotent, but the queue items are pushed: the items of a pipeline that
+// was interrupted can be pushed twice when the restore is resumed.
+func Restore(ctx context.Context, c Client, r *Reader, opt *RestoreOptions) (int64, error) {
+	if opt == nil {
+		opt = &RestoreOptions{}
+	}
+	batchSize := opt.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var restored int64
+	var batch []*Record
+	size := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := restoreBatch(ctx, c, batch); err != nil {
+			return err
+		}
+		restored += int64(len(batch))
+		batch, size = batch[:0], 0
+		if opt.OnProgress != nil {
+			opt.OnProgress(restored)
+		}
+		return nil
+	}
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return restored, flush()
+		}
+		if err != nil {
+			return restored, err
+		}
+
+		if restored < opt.Skip {
+			restored++
+			continue
+		}
+
+		batch = append(batch, rec)
+		size += rec.size()
+		if size >= batchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+}
+
+// restoreBatch writes the records in a pipeline. The kv keys without a TTL
+// are written with a single multi_set.
+func restoreBatch(ctx context.Context, c Client, batch []*Record) error {
+	pipe := c.Pipeline()
+
+	var multiSet []interface{}
+	for _, rec := range batch {
+		switch rec.Type {
+		case TypeKV:
+			if rec.TTL > 0 {
+				pipe.Do(ctx, "setx", string(rec.Key), string(rec.Value), rec.TTL)
+				continue
+			}
+			if multiSet == nil {
+				multiSet = append(multiSet, "multi_set")
+			}
+			multiSet = append(multiSet, string(rec.Key), string(rec.Value))
+		case TypeHash:
+			if len(rec.Fields) == 0 {
+				continue
+			}
+			args := make([]interface{}, 0, 2+2*len(rec.Fields))
+			args = append(args, "multi_hset", string(rec.Key))
+			for _, f := range rec.Fields {
+				args = append(args, string(f.Key), string(f.Value))
+			}
+			pipe.Do(ctx, args...)
+		case TypeZSet:
+			if len(rec.Members) == 0 {
+				continue
+			}
+			args := make([]interface{}, 0, 2+2*len(rec.Members))
+			args = append(args, "multi_zset", string(rec.Key))
+			for _, m := range rec.Members {
+				args = append(args, string(m.Key), m.Score)
+			}
+			pipe.Do(ctx, args...)
+		case TypeQueue:
+			if len(rec.Items) == 0 {
+				continue
+			}
+			args := make([]interface{}, 0, 2+len(rec.Items))
+			args = append(args, "qpush_back", string(rec.Key))
+			for _, item := range rec.Items {
+				args = append(args, string(item))
+			}
+			pipe.Do(ctx, args...)
+		}
+	}
+	if multiSet != nil {
+		pipe.Do(ctx, multiSet...)
+	}
+
+	if pipe.Len() == 0 {
+		return nil
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
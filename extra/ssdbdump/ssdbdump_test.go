@@ -0,0 +1,213 @@
+package ssdbdump
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+var ctx = context.Background()
+
+func newServer(t *testing.T) (*ssdbtest.Server, *ssdb.Client) {
+	srv := ssdbtest.NewServer()
+	now := time.Unix(1000, 0)
+	srv.Now = func() time.Time { return now }
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: srv.Dialer(),
+	})
+	t.Cleanup(func() {
+		sdb.Close()
+		srv.Close()
+	})
+	return srv, sdb
+}
+
+func mustDo(t *testing.T, srv *ssdbtest.Server, args ...string) []string {
+	t.Helper()
+	reply := srv.Do(args...)
+	if reply[0] != "ok" {
+		t.Fatalf("%q: %q", args, reply)
+	}
+	return reply[1:]
+}
+
+func populate(t *testing.T, srv *ssdbtest.Server) {
+	mustDo(t, srv, "multi_set", "a", "1", "b", "2", "bin\xff", "\x00\xfe", "empty", "")
+	mustDo(t, srv, "setx", "session", "token", "60")
+	for i := 0; i < 5; i++ {
+		mustDo(t, srv, "hset", "h", "f"+strconv.Itoa(i), "v"+strconv.Itoa(i))
+	}
+	mustDo(t, srv, "multi_zset", "z", "a", "2", "b", "1", "c", "2", "d", "2", "e", "-5")
+	mustDo(t, srv, "qpush_back", "q", "1", "2", "3", "4", "5")
+}
+
+// contents returns every command reply needed to compare two servers.
+func contents(t *testing.T, srv *ssdbtest.Server) [][]string {
+	return [][]string{
+		mustDo(t, srv, "scan", "", "", "-1"),
+		mustDo(t, srv, "ttl", "session"),
+		mustDo(t, srv, "hgetall", "h"),
+		mustDo(t, srv, "zscan", "z", "", "", "", "-1"),
+		mustDo(t, srv, "qrange", "q", "0", "-1"),
+		mustDo(t, srv, "hlist", "", "", "-1"),
+		mustDo(t, srv, "zlist", "", "", "-1"),
+		mustDo(t, srv, "qlist", "", "", "-1"),
+	}
+}
+
+func dump(t *testing.T, sdb *ssdb.Client, opt *WriterOptions) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Dump(ctx, sdb, w, &DumpOptions{BatchSize: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDumpRestore(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		src, srcClient := newServer(t)
+		populate(t, src)
+
+		b := dump(t, srcClient, &WriterOptions{Compress: compress})
+		if compress != (b[0] == 0x1f) {
+			t.Fatalf("compress=%v: got %q", compress, b[:2])
+		}
+
+		_, n, err := Verify(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 5 kv, 3 hash, 3 zset and 3 queue records of up to 2 entries.
+		if n != 14 {
+			t.Fatalf("got %d records, wanted 14", n)
+		}
+
+		dst, dstClient := newServer(t)
+		r, err := NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var progress []int64
+		restored, err := Restore(ctx, dstClient, r, &RestoreOptions{
+			BatchSize:  4,
+			OnProgress: func(n int64) { progress = append(progress, n) },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored != n || progress[len(progress)-1] != n || len(progress) < 3 {
+			t.Fatalf("got %d restored, progress %v", restored, progress)
+		}
+
+		if got, wanted := contents(t, dst), contents(t, src); !reflect.DeepEqual(got, wanted) {
+			t.Fatalf("got\n%q\nwanted\n%q", got, wanted)
+		}
+	}
+}
+
+func TestRestoreResume(t *testing.T) {
+	src, srcClient := newServer(t)
+	populate(t, src)
+	b := dump(t, srcClient, nil)
+
+	dst, dstClient := newServer(t)
+	r, err := NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errStop := errors.New("stop")
+	dst.Hook = func(args []string) []string {
+		if args[0] == "multi_zset" {
+			return []string{"error", errStop.Error()}
+		}
+		return nil
+	}
+	var saved int64
+	_, err = Restore(ctx, dstClient, r, &RestoreOptions{
+		BatchSize:  1,
+		OnProgress: func(n int64) { saved = n },
+	})
+	if err == nil || !strings.Contains(err.Error(), errStop.Error()) {
+		t.Fatalf("got %v, wanted the error of the server", err)
+	}
+	if saved != 8 { // the kv and hash records
+		t.Fatalf("got %d restored records, wanted 8", saved)
+	}
+
+	dst.Hook = nil
+	r, err = NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(ctx, dstClient, r, &RestoreOptions{Skip: saved}); err != nil {
+		t.Fatal(err)
+	}
+	if got, wanted := contents(t, dst), contents(t, src); !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got\n%q\nwanted\n%q", got, wanted)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	src, srcClient := newServer(t)
+	populate(t, src)
+	b := dump(t, srcClient, nil)
+	lines := strings.SplitAfter(string(b), "\n")
+
+	corrupted := strings.Replace(string(b), `"token"`, `"tokem"`, 1)
+	if _, _, err := Verify(strings.NewReader(corrupted)); err != ErrChecksum {
+		t.Fatalf("got %v, wanted ErrChecksum", err)
+	}
+
+	truncated := strings.Join(lines[:len(lines)-3], "")
+	if _, _, err := Verify(strings.NewReader(truncated)); err != ErrTruncated {
+		t.Fatalf("got %v, wanted ErrTruncated", err)
+	}
+
+	if _, err := NewReader(strings.NewReader("{\"format\":\"ssdb-dump\",\"version\":2}\n")); err == nil {
+		t.Fatal("got nil, wanted an error for an unsupported version")
+	}
+	if _, err := NewReader(strings.NewReader("hello\n")); err == nil {
+		t.Fatal("got nil, wanted an error for a file that is not a dump")
+	}
+
+	r, err := NewReader(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Type != TypeKV || rec.Key != "a" || rec.Value != "1" || rec.TTL != 0 {
+		t.Fatalf("got %+v", rec)
+	}
+	if line := lines[3]; !strings.Contains(line, `"key":{"base64":`) {
+		t.Fatalf("got %s, wanted the binary key in base64", line)
+	}
+	for {
+		if _, err := r.Read(); err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package ssdblimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// CircuitBreakerOptions are the settings of a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// FailureRatio is the ratio of failed operations in the current window
+	// that opens the circuit.
+	// Default is 0.5.
+	FailureRatio float64
+	// MinRequests is the number of results in the current window below
+	// which the circuit does not open.
+	// Default is 10.
+	MinRequests int
+	// Window is the period after which the closed circuit forgets the
+	// results.
+	// Default is 10 seconds.
+	Window time.Duration
+	// OpenTimeout is the time the circuit stays open before it becomes
+	// half-open and lets probes through.
+	// Default is 5 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of probes allowed by the half-open
+	// circuit. The circuit closes when they all succeed and opens again on
+	// the first failure.
+	// Default is 1.
+	HalfOpenRequests int
+	// IsFailure reports whether the result of an operation is a failure.
+	// Default is DefaultIsFailure.
+	IsFailure func(error) bool
+	// OnStateChange is called when the circuit changes state.
+	OnStateChange StateChangeFunc
+}
+
+// DefaultIsFailure reports network errors, timeouts and pool errors as
+// failures. The errors replied by the server, ssdb.Nil included, show that
+// the server is up, and canceled or limited operations say nothing about it,
+// so they are not failures.
+func DefaultIsFailure(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, ssdb.ErrClosed),
+		errors.Is(err, ErrRateLimited),
+		errors.Is(err, ErrConcurrencyLimited),
+		errors.Is(err, ErrCircuitOpen):
+		return false
+	}
+	var ssdbErr ssdb.Error
+	return !errors.As(err, &ssdbErr)
+}
+
+// CircuitBreaker rejects the operations while the ratio of failures is too
+// high. It is closed while the operations succeed, opens when FailureRatio
+// of them failed, and becomes half-open after OpenTimeout to probe whether
+// the server recovered.
+type CircuitBreaker struct {
+	opt CircuitBreakerOptions
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int // probes in flight
+	successes   int // successful probes
+}
+
+var _ ssdb.Limiter = (*CircuitBreaker)(nil)
+
+// NewCircuitBreaker returns a closed CircuitBreaker.
+func NewCircuitBreaker(opt *CircuitBreakerOptions) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		opt: *opt,
+		now: time.Now,
+	}
+	if cb.opt.FailureRatio <= 0 {
+		cb.opt.FailureRatio = 0.5
+	}
+	if cb.opt.MinRequests <= 0 {
+		cb.opt.MinRequests = 10
+	}
+	if cb.opt.Window <= 0 {
+		cb.opt.Window = 10 * time.Second
+	}
+	if cb.opt.OpenTimeout <= 0 {
+		cb.opt.OpenTimeout = 5 * time.Second
+	}
+	if cb.opt.HalfOpenRequests <= 0 {
+		cb.opt.HalfOpenRequests = 1
+	}
+	if cb.opt.IsFailure == nil {
+		cb.opt.IsFailure = DefaultIsFailure
+	}
+	cb.windowStart = cb.now()
+	return cb
+}
+
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	from := cb.state
+	err := cb.allow(cb.now())
+	to := cb.state
+	cb.mu.Unlock()
+
+	notify(cb.opt.OnStateChange, from, to)
+	return err
+}
+
+func (cb *CircuitBreaker) allow(now time.Time) error {
+	switch cb.state {
+	case StateOpen:
+		if now.Sub(cb.openedAt) < cb.opt.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		cb.setState(StateHalfOpen, now)
+		fallthrough
+	case StateHalfOpen:
+		if cb.probes+cb.successes >= cb.opt.HalfOpenRequests {
+			return ErrCircuitOpen
+		}
+		cb.probes++
+	}
+	return nil
+}
+
+func (cb *CircuitBreaker) ReportResult(result error) {
+	cb.mu.Lock()
+	from := cb.state
+	cb.report(result, cb.now())
+	to := cb.state
+	cb.mu.Unlock()
+
+	notify(cb.opt.OnStateChange, from, to)
+}
+
+func (cb *CircuitBreaker) report(result error, now time.Time) {
+	failed := result != errNotExecuted && cb.opt.IsFailure(result)
+
+	switch cb.state {
+	case StateClosed:
+		if result == errNotExecuted {
+			return
+		}
+		if now.Sub(cb.windowStart) >= cb.opt.Window {
+			cb.windowStart = now
+			cb.requests, cb.failures = 0, 0
+		}
+		cb.requests++
+		if failed {
+			cb.failures++
+		}
+		if cb.requests >= cb.opt.MinRequests &&
+			float64(cb.failures) >= cb.opt.FailureRatio*float64(cb.requests) {
+			cb.setState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		if cb.probes > 0 {
+			cb.probes--
+		}
+		switch {
+		case result == errNotExecuted:
+		case failed:
+			cb.setState(StateOpen, now)
+		default:
+			cb.successes++
+			if cb.successes >= cb.opt.HalfOpenRequests {
+				cb.setState(StateClosed, now)
+			}
+		}
+	}
+}
+
+func (cb *CircuitBreaker) setState(state State, now time.Time) {
+	cb.state = state
+	cb.probes, cb.successes = 0, 0
+	switch state {
+	case StateClosed:
+		cb.windowStart = now
+		cb.requests, cb.failures = 0, 0
+	case StateOpen:
+		cb.openedAt = now
+	}
+}
+
+// State returns the state of the circuit. An open circuit whose OpenTimeout
+// expired is reported open until the next call to Allow.
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
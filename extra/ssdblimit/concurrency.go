@@ -0,0 +1,80 @@
+package ssdblimit
+
+import (
+	"sync"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// ConcurrencyLimiterOptions are the settings of a ConcurrencyLimiter.
+type ConcurrencyLimiterOptions struct {
+	// Limit is the maximum number of operations in flight.
+	// Default is 1.
+	Limit int
+	// OnStateChange is called when the limiter starts rejecting the
+	// operations (StateOpen) and when it allows them again (StateClosed).
+	OnStateChange StateChangeFunc
+}
+
+// ConcurrencyLimiter limits the number of operations in flight: an operation
+// is in flight from Allow until its result is reported.
+type ConcurrencyLimiter struct {
+	opt ConcurrencyLimiterOptions
+
+	mu       sync.Mutex
+	inFlight int
+	state    State
+}
+
+var _ ssdb.Limiter = (*ConcurrencyLimiter)(nil)
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter.
+func NewConcurrencyLimiter(opt *ConcurrencyLimiterOptions) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{opt: *opt}
+	if l.opt.Limit <= 0 {
+		l.opt.Limit = 1
+	}
+	return l
+}
+
+func (l *ConcurrencyLimiter) Allow() error {
+	l.mu.Lock()
+
+	var err error
+	to := StateClosed
+	if l.inFlight < l.opt.Limit {
+		l.inFlight++
+	} else {
+		err = ErrConcurrencyLimited
+		to = StateOpen
+	}
+
+	from := l.state
+	l.state = to
+	l.mu.Unlock()
+
+	notify(l.opt.OnStateChange, from, to)
+	return err
+}
+
+func (l *ConcurrencyLimiter) ReportResult(result error) {
+	l.mu.Lock()
+	if l.inFlight > 0 {
+		l.inFlight--
+	}
+	l.mu.Unlock()
+}
+
+// InFlight returns the number of operations in flight.
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// State returns StateOpen if the last operation was rejected.
+func (l *ConcurrencyLimiter) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state
+}
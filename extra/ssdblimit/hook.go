@@ -0,0 +1,109 @@
+package ssdblimit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// Hook applies a limiter per command name. It is added to a client with
+// AddHook.
+type Hook struct {
+	newLimiter func(name string) ssdb.Limiter
+
+	mu       sync.RWMutex
+	limiters map[string]ssdb.Limiter
+}
+
+var _ ssdb.Hook = (*Hook)(nil)
+
+// NewHook returns a Hook that limits the commands with the limiters returned
+// by newLimiter. newLimiter is called once per command name; the commands for
+// which it returns nil are not limited.
+func NewHook(newLimiter func(name string) ssdb.Limiter) *Hook {
+	return &Hook{
+		newLimiter: newLimiter,
+		limiters:   make(map[string]ssdb.Limiter),
+	}
+}
+
+// Limiter returns the limiter of the commands with the given name, or nil.
+func (h *Hook) Limiter(name string) ssdb.Limiter {
+	h.mu.RLock()
+	l, ok := h.limiters[name]
+	h.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l, ok := h.limiters[name]; ok {
+		return l
+	}
+	l = h.newLimiter(name)
+	h.limiters[name] = l
+	return l
+}
+
+type hookKey struct {
+	h *Hook
+}
+
+// allowed is an operation allowed by a limiter.
+type allowed struct {
+	limiter ssdb.Limiter
+	cmd     ssdb.Cmder
+}
+
+func (h *Hook) BeforeProcess(ctx context.Context, cmd ssdb.Cmder) (context.Context, error) {
+	l := h.Limiter(cmd.Name())
+	if l == nil {
+		return ctx, nil
+	}
+	if err := l.Allow(); err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, hookKey{h}, l), nil
+}
+
+func (h *Hook) AfterProcess(ctx context.Context, cmd ssdb.Cmder) error {
+	if l, ok := ctx.Value(hookKey{h}).(ssdb.Limiter); ok {
+		l.ReportResult(cmd.Err())
+	}
+	return nil
+}
+
+// BeforeProcessPipeline allows the commands of the pipeline one by one. When
+// a command is rejected the whole pipeline is, and the commands allowed so far
+// are reported as not executed.
+func (h *Hook) BeforeProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) (context.Context, error) {
+	var ops []allowed
+	for _, cmd := range cmds {
+		l := h.Limiter(cmd.Name())
+		if l == nil {
+			continue
+		}
+		if err := l.Allow(); err != nil {
+			for _, op := range ops {
+				op.limiter.ReportResult(errNotExecuted)
+			}
+			return ctx, err
+		}
+		ops = append(ops, allowed{limiter: l, cmd: cmd})
+	}
+	if len(ops) == 0 {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, hookKey{h}, ops), nil
+}
+
+func (h *Hook) AfterProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) error {
+	if ops, ok := ctx.Value(hookKey{h}).([]allowed); ok {
+		for _, op := range ops {
+			op.limiter.ReportResult(op.cmd.Err())
+		}
+	}
+	return nil
+}
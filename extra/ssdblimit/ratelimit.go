@@ -0,0 +1,94 @@
+package ssdblimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// RateLimiterOptions are the settings of a RateLimiter.
+type RateLimiterOptions struct {
+	// Rate is the number of operations allowed per second.
+	Rate float64
+	// Burst is the number of operations allowed at once, the size of the
+	// bucket. Default is 1.
+	Burst int
+	// OnStateChange is called when the limiter starts rejecting the
+	// operations (StateOpen) and when it allows them again (StateClosed).
+	OnStateChange StateChangeFunc
+}
+
+// RateLimiter is a token bucket rate limiter. The bucket starts full and is
+// refilled with Rate tokens per second; each allowed operation takes one.
+type RateLimiter struct {
+	opt RateLimiterOptions
+	now func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	state  State
+}
+
+var _ ssdb.Limiter = (*RateLimiter)(nil)
+
+// NewRateLimiter returns a RateLimiter.
+func NewRateLimiter(opt *RateLimiterOptions) *RateLimiter {
+	l := &RateLimiter{
+		opt: *opt,
+		now: time.Now,
+	}
+	if l.opt.Burst <= 0 {
+		l.opt.Burst = 1
+	}
+	l.tokens = float64(l.opt.Burst)
+	l.last = l.now()
+	return l
+}
+
+func (l *RateLimiter) Allow() error {
+	l.mu.Lock()
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.opt.Rate
+	if max := float64(l.opt.Burst); l.tokens > max {
+		l.tokens = max
+	}
+	l.last = now
+
+	var err error
+	to := StateClosed
+	if l.tokens >= 1 {
+		l.tokens--
+	} else {
+		err = ErrRateLimited
+		to = StateOpen
+	}
+
+	from := l.state
+	l.state = to
+	l.mu.Unlock()
+
+	notify(l.opt.OnStateChange, from, to)
+	return err
+}
+
+// ReportResult gives the token back if the operation was not executed.
+func (l *RateLimiter) ReportResult(result error) {
+	if result != errNotExecuted {
+		return
+	}
+	l.mu.Lock()
+	if l.tokens++; l.tokens > float64(l.opt.Burst) {
+		l.tokens = float64(l.opt.Burst)
+	}
+	l.mu.Unlock()
+}
+
+// State returns StateOpen if the last operation was rejected.
+func (l *RateLimiter) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state
+}
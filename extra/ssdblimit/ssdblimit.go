@@ -0,0 +1,101 @@
+// Package ssdblimit provides implementations of ssdb.Limiter: a token bucket
+// rate limiter, a concurrency limiter and a circuit breaker.
+//
+// A limiter applies to all the commands of a client when it is set as
+// ssdb.Options.Limiter, or to the commands with a given name when it is
+// returned by the function passed to NewHook:
+//
+//	rdb := ssdb.NewClient(&ssdb.Options{
+//		Limiter: ssdblimit.NewCircuitBreaker(&ssdblimit.CircuitBreakerOptions{}),
+//	})
+//	rdb.AddHook(ssdblimit.NewHook(func(name string) ssdb.Limiter {
+//		if name == "qpush" {
+//			return ssdblimit.NewRateLimiter(&ssdblimit.RateLimiterOptions{Rate: 100})
+//		}
+//		return nil
+//	}))
+package ssdblimit
+
+import (
+	"errors"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+var (
+	// ErrRateLimited is returned by RateLimiter.Allow when the bucket is empty.
+	ErrRateLimited = errors.New("ssdblimit: rate limit exceeded")
+	// ErrConcurrencyLimited is returned by ConcurrencyLimiter.Allow when the
+	// limit of operations in flight is reached.
+	ErrConcurrencyLimited = errors.New("ssdblimit: too many operations in flight")
+	// ErrCircuitOpen is returned by CircuitBreaker.Allow when the circuit is
+	// open, or half-open and all the probes are in flight.
+	ErrCircuitOpen = errors.New("ssdblimit: circuit breaker is open")
+)
+
+// errNotExecuted is reported for an operation that was allowed and then
+// cancelled before it was executed. It is neither a success nor a failure.
+var errNotExecuted = errors.New("ssdblimit: operation was not executed")
+
+// State is the state of a limiter. The rate and concurrency limiters are
+// closed while they allow the operations and open while they reject them.
+// Only the circuit breaker is ever half-open.
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChangeFunc is called, outside of the limiter locks, when a limiter
+// changes state.
+type StateChangeFunc func(from, to State)
+
+func notify(fn StateChangeFunc, from, to State) {
+	if fn != nil && from != to {
+		fn(from, to)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type multiLimiter []ssdb.Limiter
+
+// Multi returns a limiter that allows an operation when all the limiters
+// allow it, in order. The limiters that allowed an operation rejected by a
+// later one are told that it was not executed.
+func Multi(limiters ...ssdb.Limiter) ssdb.Limiter {
+	return multiLimiter(limiters)
+}
+
+func (m multiLimiter) Allow() error {
+	for i, l := range m {
+		if err := l.Allow(); err != nil {
+			for _, allowed := range m[:i] {
+				allowed.ReportResult(errNotExecuted)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiLimiter) ReportResult(result error) {
+	for _, l := range m {
+		l.ReportResult(result)
+	}
+}
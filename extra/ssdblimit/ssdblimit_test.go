@@ -0,0 +1,343 @@
+package ssdblimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+type transitions struct {
+	mu     sync.Mutex
+	states []State
+}
+
+func (t *transitions) record(from, to State) {
+	t.mu.Lock()
+	t.states = append(t.states, to)
+	t.mu.Unlock()
+}
+
+func (t *transitions) get() []State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]State(nil), t.states...)
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := newFakeClock()
+	var tr transitions
+	l := NewRateLimiter(&RateLimiterOptions{
+		Rate:          10,
+		Burst:         2,
+		OnStateChange: tr.record,
+	})
+	l.now = clock.Now
+	l.last = clock.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := l.Allow(); err != nil {
+			t.Fatalf("Allow #%d: %v", i, err)
+		}
+		l.ReportResult(nil)
+	}
+	if err := l.Allow(); err != ErrRateLimited {
+		t.Fatalf("got %v, wanted ErrRateLimited", err)
+	}
+	if l.State() != StateOpen {
+		t.Fatalf("got state %s, wanted open", l.State())
+	}
+
+	clock.Add(100 * time.Millisecond)
+	if err := l.Allow(); err != nil {
+		t.Fatalf("Allow after refill: %v", err)
+	}
+	l.ReportResult(nil)
+	if err := l.Allow(); err != ErrRateLimited {
+		t.Fatalf("got %v, wanted ErrRateLimited", err)
+	}
+
+	if got, want := tr.get(), []State{StateOpen, StateClosed, StateOpen}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got transitions %v, wanted %v", got, want)
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	var tr transitions
+	l := NewConcurrencyLimiter(&ConcurrencyLimiterOptions{
+		Limit:         2,
+		OnStateChange: tr.record,
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := l.Allow(); err != nil {
+			t.Fatalf("Allow #%d: %v", i, err)
+		}
+	}
+	if err := l.Allow(); err != ErrConcurrencyLimited {
+		t.Fatalf("got %v, wanted ErrConcurrencyLimited", err)
+	}
+	if l.InFlight() != 2 {
+		t.Fatalf("got %d in flight, wanted 2", l.InFlight())
+	}
+
+	l.ReportResult(io.EOF)
+	if err := l.Allow(); err != nil {
+		t.Fatalf("Allow after release: %v", err)
+	}
+
+	if got, want := tr.get(), []State{StateOpen, StateClosed}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got transitions %v, wanted %v", got, want)
+	}
+}
+
+func newTestBreaker(clock *fakeClock, tr *transitions) *CircuitBreaker {
+	cb := NewCircuitBreaker(&CircuitBreakerOptions{
+		FailureRatio:     0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		OpenTimeout:      time.Second,
+		HalfOpenRequests: 2,
+		OnStateChange:    tr.record,
+	})
+	cb.now = clock.Now
+	cb.windowStart = clock.Now()
+	return cb
+}
+
+func report(t *testing.T, l ssdb.Limiter, results ...error) {
+	t.Helper()
+	for _, result := range results {
+		if err := l.Allow(); err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		l.ReportResult(result)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	clock := newFakeClock()
+	var tr transitions
+	cb := newTestBreaker(clock, &tr)
+
+	// Server errors and canceled commands are not failures.
+	report(t, cb, io.EOF, ssdb.Nil, context.Canceled, nil, io.EOF)
+	if cb.State() != StateClosed {
+		t.Fatalf("got state %s, wanted closed", cb.State())
+	}
+	report(t, cb, io.EOF)
+	if cb.State() != StateOpen {
+		t.Fatalf("got state %s, wanted open", cb.State())
+	}
+	if err := cb.Allow(); err != ErrCircuitOpen {
+		t.Fatalf("got %v, wanted ErrCircuitOpen", err)
+	}
+
+	// Half-open lets HalfOpenRequests probes through.
+	clock.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if err := cb.Allow(); err != nil {
+			t.Fatalf("probe #%d: %v", i, err)
+		}
+	}
+	if err := cb.Allow(); err != ErrCircuitOpen {
+		t.Fatalf("got %v, wanted ErrCircuitOpen", err)
+	}
+	cb.ReportResult(nil)
+	cb.ReportResult(io.EOF)
+	if cb.State() != StateOpen {
+		t.Fatalf("got state %s, wanted open", cb.State())
+	}
+
+	clock.Add(time.Second)
+	report(t, cb, nil, nil)
+	if cb.State() != StateClosed {
+		t.Fatalf("got state %s, wanted closed", cb.State())
+	}
+
+	want := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+	if got := tr.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got transitions %v, wanted %v", got, want)
+	}
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	clock := newFakeClock()
+	cb := newTestBreaker(clock, &transitions{})
+
+	report(t, cb, io.EOF, io.EOF, io.EOF)
+	clock.Add(time.Minute)
+	report(t, cb, io.EOF, nil, nil)
+	if cb.State() != StateClosed {
+		t.Fatalf("got state %s, wanted closed", cb.State())
+	}
+}
+
+func TestMulti(t *testing.T) {
+	conc := NewConcurrencyLimiter(&ConcurrencyLimiterOptions{Limit: 1})
+	rate := NewRateLimiter(&RateLimiterOptions{Rate: 1, Burst: 1})
+	l := Multi(conc, rate)
+
+	report(t, l, nil)
+	if err := l.Allow(); err != ErrRateLimited {
+		t.Fatalf("got %v, wanted ErrRateLimited", err)
+	}
+	if conc.InFlight() != 0 {
+		t.Fatalf("got %d in flight, wanted 0", conc.InFlight())
+	}
+}
+
+func TestHook(t *testing.T) {
+	ctx := context.Background()
+	var names []string
+	h := NewHook(func(name string) ssdb.Limiter {
+		names = append(names, name)
+		if name == "set" {
+			return NewConcurrencyLimiter(&ConcurrencyLimiterOptions{Limit: 1})
+		}
+		return nil
+	})
+
+	get := ssdb.NewCmd(ctx, "get", "key")
+	if _, err := h.BeforeProcess(ctx, get); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	set1 := ssdb.NewCmd(ctx, "set", "key", "1")
+	ctx1, err := h.BeforeProcess(ctx, set1)
+	if err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	set2 := ssdb.NewCmd(ctx, "set", "key", "2")
+	if _, err := h.BeforeProcess(ctx, set2); err != ErrConcurrencyLimited {
+		t.Fatalf("got %v, wanted ErrConcurrencyLimited", err)
+	}
+	// The client calls AfterProcess on the hook whose BeforeProcess failed.
+	if err := h.AfterProcess(ctx, set2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AfterProcess(ctx1, set1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.BeforeProcess(ctx, set2); err != nil {
+		t.Fatalf("set after release: %v", err)
+	}
+
+	if want := []string{"get", "set"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("got limiters for %v, wanted %v", names, want)
+	}
+}
+
+func TestHookPipeline(t *testing.T) {
+	ctx := context.Background()
+	conc := NewConcurrencyLimiter(&ConcurrencyLimiterOptions{Limit: 2})
+	h := NewHook(func(name string) ssdb.Limiter {
+		if name == "set" {
+			return conc
+		}
+		return nil
+	})
+
+	cmds := []ssdb.Cmder{
+		ssdb.NewCmd(ctx, "set", "a", "1"),
+		ssdb.NewCmd(ctx, "get", "a"),
+		ssdb.NewCmd(ctx, "set", "b", "2"),
+	}
+	pctx, err := h.BeforeProcessPipeline(ctx, cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conc.InFlight() != 2 {
+		t.Fatalf("got %d in flight, wanted 2", conc.InFlight())
+	}
+
+	if err := h.AfterProcessPipeline(pctx, cmds); err != nil {
+		t.Fatal(err)
+	}
+	if conc.InFlight() != 0 {
+		t.Fatalf("got %d in flight, wanted 0", conc.InFlight())
+	}
+
+	// The third set is rejected and the first two are rolled back.
+	cmds = []ssdb.Cmder{
+		ssdb.NewCmd(ctx, "set", "c", "3"),
+		ssdb.NewCmd(ctx, "set", "d", "4"),
+		ssdb.NewCmd(ctx, "set", "e", "5"),
+	}
+	if _, err := h.BeforeProcessPipeline(ctx, cmds); !errors.Is(err, ErrConcurrencyLimited) {
+		t.Fatalf("got %v, wanted ErrConcurrencyLimited", err)
+	}
+	if conc.InFlight() != 0 {
+		t.Fatalf("got %d in flight, wanted 0", conc.InFlight())
+	}
+}
+
+func TestConcurrencyLimiterAuth(t *testing.T) {
+	ctx := context.Background()
+	srv := ssdbtest.NewServer()
+	defer srv.Close()
+
+	newClient := func(minIdleConns int) (*ssdb.Client, *ConcurrencyLimiter) {
+		conc := NewConcurrencyLimiter(&ConcurrencyLimiterOptions{Limit: 1})
+		sdb := ssdb.NewClient(&ssdb.Options{
+			Addr:         "fake:8888",
+			Dialer:       srv.Dialer(),
+			Password:     "secret",
+			MinIdleConns: minIdleConns,
+			Limiter:      conc,
+		})
+		t.Cleanup(func() { sdb.Close() })
+		return sdb, conc
+	}
+
+	// The command takes the only slot; the auth of the connection it
+	// dials must not compete with it.
+	sdb, conc := newClient(0)
+	if err := sdb.Do(ctx, "set", "key", "value").Err(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if val, err := sdb.Do(ctx, "get", "key").Result(); err != nil || !reflect.DeepEqual(val, []string{"value"}) {
+		t.Fatalf("got %v, %v, wanted [value]", val, err)
+	}
+	if err := conc.Allow(); err != nil {
+		t.Fatalf("got %v after the commands, wanted nil", err)
+	}
+	conc.ReportResult(nil)
+
+	// The auth and the ping of WaitReady are not limited either.
+	sdb, conc = newClient(1)
+	if err := conc.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.WaitReady(ctx); err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	conc.ReportResult(nil)
+}
@@ -0,0 +1,82 @@
+package ssdbmigrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Checkpoint is the position of a migration. The types are migrated in the
+// order kv, hash, zset, queue, so the types before Type are done.
+type Checkpoint struct {
+	Type Type `json:"type"`
+	// Key is the last kv key or collection of Type that is migrated.
+	Key string `json:"key,omitempty"`
+
+	// Name is the collection being migrated after Key, if any.
+	Name string `json:"name,omitempty"`
+	// Field and Score are the last hash field or sorted set member of Name
+	// that is migrated.
+	Field string `json:"field,omitempty"`
+	Score string `json:"score,omitempty"`
+	// Offset is the number of queue items of Name that are migrated.
+	Offset int64 `json:"offset,omitempty"`
+}
+
+// CheckpointStore saves the position of a migration.
+type CheckpointStore interface {
+	// Load returns the saved checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*Checkpoint, error)
+	Save(ctx context.Context, cp *Checkpoint) error
+}
+
+// FileCheckpoint is a CheckpointStore that saves the checkpoint as JSON to a
+// file. The file is replaced atomically, so an interrupted save does not lose
+// the previous checkpoint.
+type FileCheckpoint struct {
+	Path string
+}
+
+var _ CheckpointStore = (*FileCheckpoint)(nil)
+
+// NewFileCheckpoint returns a FileCheckpoint that saves to the file at path.
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{Path: path}
+}
+
+func (f *FileCheckpoint) Load(ctx context.Context) (*Checkpoint, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := new(Checkpoint)
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("ssdbmigrate: invalid checkpoint file %s: %w", f.Path, err)
+	}
+	return cp, nil
+}
+
+func (f *FileCheckpoint) Save(ctx context.Context, cp *Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
+
+// Remove removes the checkpoint file, e.g. once the migration is complete.
+func (f *FileCheckpoint) Remove() error {
+	if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,590 @@
+// Package ssdbmigrate copies a range of keys between two SSDB servers.
+//
+// Migrate copies the kv keys, hashes, sorted sets and queues whose key or
+// name is in the range, in batches read with the range commands. Each batch
+// is read back from the destination and compared with the source before the
+// next one, and it is deleted from the source if requested. The position of
+// the migration is saved to a CheckpointStore after every batch, so an
+// interrupted migration resumes where it stopped.
+package ssdbmigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// ErrMismatch is returned when a batch read back from the destination does
+// not match the source.
+var ErrMismatch = errors.New("ssdbmigrate: destination does not match the source")
+
+// Client is the subset of *ssdb.Client used by Migrate.
+type Client interface {
+	Do(ctx context.Context, args ...interface{}) *ssdb.Cmd
+	Pipeline() ssdb.Pipeliner
+}
+
+var _ Client = (*ssdb.Client)(nil)
+
+// Type is a type of data.
+type Type string
+
+const (
+	TypeKV    Type = "kv"
+	TypeHash  Type = "hash"
+	TypeZSet  Type = "zset"
+	TypeQueue Type = "queue"
+)
+
+var allTypes = []Type{TypeKV, TypeHash, TypeZSet, TypeQueue}
+
+// Options configure Migrate.
+type Options struct {
+	// Start and End bound the keys and the names of the collections to
+	// migrate to (Start, End], as the SSDB range commands do. Empty bounds
+	// are unbounded.
+	Start, End string
+	// Prefix restricts the migration to the keys and names with the prefix.
+	Prefix string
+	// Types are the types of data to migrate. All of them by default.
+	Types []Type
+
+	// BatchSize is the number of keys or entries copied per batch.
+	// Defaults to 1000.
+	BatchSize int
+	// OpsPerSecond limits the number of keys or entries copied per second.
+	// 0 means no limit.
+	OpsPerSecond float64
+	// Verify reads each batch back from the destination and compares it
+	// with the source.
+	Verify bool
+	// DeleteSource deletes the keys and entries from the source once they
+	// are copied. The queues are cleared once all their items are copied.
+	DeleteSource bool
+
+	// Checkpoint saves the position of the migration after every batch and
+	// provides the position to resume from.
+	Checkpoint CheckpointStore
+}
+
+func (opt *Options) init() {
+	if len(opt.Types) == 0 {
+		opt.Types = allTypes
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 1000
+	}
+}
+
+// Stats are the counts of a migration.
+type Stats struct {
+	// Keys is the number of kv keys and collections migrated.
+	Keys int64
+	// Entries is the number of kv keys, fields, members and items copied.
+	Entries int64
+	// Deleted is the number of entries deleted from the source.
+	Deleted int64
+}
+
+// Migrate copies the data selected by opt from src to dst.
+func Migrate(ctx context.Context, src, dst Client, opt *Options) (Stats, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	o := *opt
+	o.init()
+	for _, typ := range o.Types {
+		if !hasType(allTypes, typ) {
+			return Stats{}, fmt.Errorf("ssdbmigrate: unknown type %q", typ)
+		}
+	}
+
+	m := &migrator{
+		src:      src,
+		dst:      dst,
+		opt:      &o,
+		throttle: newThrottle(o.OpsPerSecond),
+	}
+	if o.Checkpoint != nil {
+		cp, err := o.Checkpoint.Load(ctx)
+		if err != nil {
+			return m.stats, err
+		}
+		if cp != nil {
+			m.cp = *cp
+		}
+	}
+	if m.cp.Type != "" && !hasType(o.Types, m.cp.Type) {
+		// Resuming would skip every type.
+		return m.stats, fmt.Errorf("ssdbmigrate: the checkpoint is in type %q, which is not migrated", m.cp.Type)
+	}
+
+	resumed := m.cp.Type != ""
+	for _, typ := range allTypes {
+		if !hasType(o.Types, typ) {
+			continue
+		}
+		if resumed && typ != m.cp.Type {
+			continue // done before the checkpoint
+		}
+		if !resumed {
+			m.cp = Checkpoint{Type: typ}
+		}
+		resumed = false
+
+		var err error
+		if typ == TypeKV {
+			err = m.migrateKV(ctx)
+		} else {
+			err = m.migrateCollections(ctx, typ)
+		}
+		if err != nil {
+			return m.stats, err
+		}
+	}
+	return m.stats, nil
+}
+
+func hasType(types []Type, typ Type) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+type migrator struct {
+	src, dst Client
+	opt      *Options
+	throttle *throttle
+
+	cp    Checkpoint
+	stats Stats
+}
+
+// save records the position of the migration after a batch.
+func (m *migrator) save(ctx context.Context, entries int) error {
+	m.stats.Entries += int64(entries)
+	if err := m.throttle.wait(ctx, entries); err != nil {
+		return err
+	}
+	if m.opt.Checkpoint == nil {
+		return nil
+	}
+	cp := m.cp
+	return m.opt.Checkpoint.Save(ctx, &cp)
+}
+
+// inRange reports whether key is in the range of the migration, and whether
+// the keys after it can still be.
+func (m *migrator) inRange(key string) (in, more bool) {
+	if m.opt.End != "" && key > m.opt.End {
+		return false, false
+	}
+	if m.opt.Prefix != "" && !strings.HasPrefix(key, m.opt.Prefix) {
+		return false, key < m.opt.Prefix
+	}
+	return true, true
+}
+
+// start returns the exclusive start of the range and whether the key equal to
+// the prefix must be checked on its own: the range commands can't include it.
+func (m *migrator) start() (string, bool) {
+	if m.opt.Prefix > m.opt.Start {
+		return m.opt.Prefix, true
+	}
+	return m.opt.Start, false
+}
+
+// filter returns the leading keys of the list that are in the range. step is
+// the number of values per key. more is false once a key is past the range.
+func (m *migrator) filter(vals []string, step int) (in []string, more bool) {
+	for i := 0; i+step <= len(vals); i += step {
+		ok, more := m.inRange(vals[i])
+		if !more {
+			return in, false
+		}
+		if ok {
+			in = append(in, vals[i:i+step]...)
+		}
+	}
+	return in, true
+}
+
+func do(ctx context.Context, c Client, args ...interface{}) ([]string, error) {
+	cmd := c.Do(ctx, args...)
+	if err := cmd.Err(); err != nil {
+		return nil, err
+	}
+	vals, _ := cmd.Val().([]string)
+	return vals, nil
+}
+
+func exec(ctx context.Context, pipe ssdb.Pipeliner) error {
+	if pipe.Len() == 0 {
+		return nil
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+func (m *migrator) migrateKV(ctx context.Context) error {
+	start, checkPrefix := m.start()
+	if m.cp.Key != "" {
+		start, checkPrefix = m.cp.Key, false
+	}
+
+	if checkPrefix {
+		pairs, err := do(ctx, m.src, "multi_get", m.opt.Prefix)
+		if err != nil {
+			return err
+		}
+		if err := m.copyKV(ctx, pairs); err != nil {
+			return err
+		}
+	}
+
+	for {
+		vals, err := do(ctx, m.src, "scan", start, m.opt.End, m.opt.BatchSize)
+		if err != nil {
+			return err
+		}
+		pairs, more := m.filter(vals, 2)
+		if err := m.copyKV(ctx, pairs); err != nil {
+			return err
+		}
+		if !more || len(vals) < 2*m.opt.BatchSize {
+			return nil
+		}
+		start = vals[len(vals)-2]
+	}
+}
+
+func (m *migrator) copyKV(ctx context.Context, pairs []string) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	keys := make([]interface{}, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		keys = append(keys, pairs[i])
+	}
+
+	pipe := m.src.Pipeline()
+	ttls := make([]*ssdb.Cmd, len(keys))
+	for i, key := range keys {
+		ttls[i] = pipe.Do(ctx, "ttl", key)
+	}
+	if err := exec(ctx, pipe); err != nil {
+		return err
+	}
+
+	pipe = m.dst.Pipeline()
+	multiSet := []interface{}{"multi_set"}
+	for i := 0; i < len(pairs); i += 2 {
+		ttl := int64(-1)
+		if vals, _ := ttls[i/2].Val().([]string); len(vals) > 0 {
+			ttl, _ = strconv.ParseInt(vals[0], 10, 64)
+		}
+		if ttl > 0 {
+			pipe.Do(ctx, "setx", pairs[i], pairs[i+1], ttl)
+		} else {
+			multiSet = append(multiSet, pairs[i], pairs[i+1])
+		}
+	}
+	if len(multiSet) > 1 {
+		pipe.Do(ctx, multiSet...)
+	}
+	if err := exec(ctx, pipe); err != nil {
+		return err
+	}
+
+	if m.opt.Verify {
+		got, err := do(ctx, m.dst, append([]interface{}{"multi_get"}, keys...)...)
+		if err != nil {
+			return err
+		}
+		if sum(got) != sum(pairs) {
+			return fmt.Errorf("%w: kv keys %q to %q", ErrMismatch, pairs[0], pairs[len(pairs)-2])
+		}
+	}
+
+	if m.opt.DeleteSource {
+		if _, err := do(ctx, m.src, append([]interface{}{"multi_del"}, keys...)...); err != nil {
+			return err
+		}
+		m.stats.Deleted += int64(len(keys))
+	}
+
+	m.stats.Keys += int64(len(keys))
+	m.cp.Key = pairs[len(pairs)-2]
+	return m.save(ctx, len(keys))
+}
+
+//------------------------------------------------------------------------------
+
+// collection are the commands of a type of collection.
+type collection struct {
+	list, size string
+	copy       func(m *migrator, ctx context.Context, name string) error
+}
+
+var collections = map[Type]collection{
+	TypeHash:  {list: "hlist", size: "hsize", copy: (*migrator).copyHash},
+	TypeZSet:  {list: "zlist", size: "zsize", copy: (*migrator).copyZSet},
+	TypeQueue: {list: "qlist", size: "qsize", copy: (*migrator).copyQueue},
+}
+
+func (m *migrator) migrateCollections(ctx context.Context, typ Type) error {
+	coll := collections[typ]
+
+	// Finish the collection that was being copied when the migration
+	// was interrupted.
+	if m.cp.Name != "" {
+		name := m.cp.Name
+		if err := coll.copy(m, ctx, name); err != nil {
+			return err
+		}
+		if err := m.collectionDone(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	start, checkPrefix := m.start()
+	if m.cp.Key != "" {
+		start, checkPrefix = m.cp.Key, false
+	}
+
+	if checkPrefix {
+		vals, err := do(ctx, m.src, coll.size, m.opt.Prefix)
+		if err != nil {
+			return err
+		}
+		if len(vals) > 0 && vals[0] != "0" {
+			if err := m.copyCollection(ctx, coll, m.opt.Prefix); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		vals, err := do(ctx, m.src, coll.list, start, m.opt.End, m.opt.BatchSize)
+		if err != nil {
+			return err
+		}
+		names, more := m.filter(vals, 1)
+		for _, name := range names {
+			if err := m.copyCollection(ctx, coll, name); err != nil {
+				return err
+			}
+		}
+		if !more || len(vals) < m.opt.BatchSize {
+			return nil
+		}
+		start = vals[len(vals)-1]
+	}
+}
+
+func (m *migrator) copyCollection(ctx context.Context, coll collection, name string) error {
+	m.cp.Name, m.cp.Field, m.cp.Score, m.cp.Offset = name, "", "", 0
+	if err := coll.copy(m, ctx, name); err != nil {
+		return err
+	}
+	return m.collectionDone(ctx, name)
+}
+
+func (m *migrator) collectionDone(ctx context.Context, name string) error {
+	m.stats.Keys++
+	m.cp = Checkpoint{Type: m.cp.Type, Key: name}
+	return m.save(ctx, 0)
+}
+
+func (m *migrator) copyHash(ctx context.Context, name string) error {
+	for {
+		pairs, err := do(ctx, m.src, "hscan", name, m.cp.Field, "", m.opt.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		args := append([]interface{}{"multi_hset", name}, toArgs(pairs)...)
+		if _, err := do(ctx, m.dst, args...); err != nil {
+			return err
+		}
+
+		fields := make([]interface{}, 0, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			fields = append(fields, pairs[i])
+		}
+		if m.opt.Verify {
+			got, err := do(ctx, m.dst, append([]interface{}{"multi_hget", name}, fields...)...)
+			if err != nil {
+				return err
+			}
+			if sum(got) != sum(pairs) {
+				return fmt.Errorf("%w: hash %q", ErrMismatch, name)
+			}
+		}
+		if m.opt.DeleteSource {
+			if _, err := do(ctx, m.src, append([]interface{}{"multi_hdel", name}, fields...)...); err != nil {
+				return err
+			}
+			m.stats.Deleted += int64(len(fields))
+		}
+
+		m.cp.Field = pairs[len(pairs)-2]
+		if err := m.save(ctx, len(fields)); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *migrator) copyZSet(ctx context.Context, name string) error {
+	for {
+		pairs, err := do(ctx, m.src, "zscan", name, m.cp.Field, m.cp.Score, "", m.opt.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		args := append([]interface{}{"multi_zset", name}, toArgs(pairs)...)
+		if _, err := do(ctx, m.dst, args...); err != nil {
+			return err
+		}
+
+		keys := make([]interface{}, 0, len(pairs)/2)
+		for i := 0; i < len(pairs); i += 2 {
+			keys = append(keys, pairs[i])
+		}
+		if m.opt.Verify {
+			got, err := do(ctx, m.dst, append([]interface{}{"multi_zget", name}, keys...)...)
+			if err != nil {
+				return err
+			}
+			if sum(got) != sum(pairs) {
+				return fmt.Errorf("%w: sorted set %q", ErrMismatch, name)
+			}
+		}
+		if m.opt.DeleteSource {
+			if _, err := do(ctx, m.src, append([]interface{}{"multi_zdel", name}, keys...)...); err != nil {
+				return err
+			}
+			m.stats.Deleted += int64(len(keys))
+		}
+
+		m.cp.Field, m.cp.Score = pairs[len(pairs)-2], pairs[len(pairs)-1]
+		if err := m.save(ctx, len(keys)); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *migrator) copyQueue(ctx context.Context, name string) error {
+	for {
+		items, err := do(ctx, m.src, "qrange", name, m.cp.Offset, m.opt.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		args := append([]interface{}{"qpush_back", name}, toArgs(items)...)
+		if _, err := do(ctx, m.dst, args...); err != nil {
+			return err
+		}
+		if m.opt.Verify {
+			got, err := do(ctx, m.dst, "qrange", name, -len(items), len(items))
+			if err != nil {
+				return err
+			}
+			if sum(got) != sum(items) {
+				return fmt.Errorf("%w: queue %q", ErrMismatch, name)
+			}
+		}
+
+		m.cp.Offset += int64(len(items))
+		if err := m.save(ctx, len(items)); err != nil {
+			return err
+		}
+	}
+
+	if m.opt.DeleteSource && m.cp.Offset > 0 {
+		if _, err := do(ctx, m.src, "qclear", name); err != nil {
+			return err
+		}
+		m.stats.Deleted += m.cp.Offset
+	}
+	return nil
+}
+
+func toArgs(vals []string) []interface{} {
+	args := make([]interface{}, len(vals))
+	for i, v := range vals {
+		args[i] = v
+	}
+	return args
+}
+
+// sum hashes the values, each prefixed by its length.
+func sum(vals []string) uint64 {
+	h := fnv.New64a()
+	var b []byte
+	for _, v := range vals {
+		b = strconv.AppendInt(b[:0], int64(len(v)), 10)
+		b = append(b, ':')
+		h.Write(b)         //nolint:errcheck
+		h.Write([]byte(v)) //nolint:errcheck
+	}
+	return h.Sum64()
+}
+
+//------------------------------------------------------------------------------
+
+// throttle limits the rate of the operations by sleeping once the operations
+// are ahead of schedule.
+type throttle struct {
+	rate  float64
+	start time.Time
+	ops   float64
+}
+
+func newThrottle(rate float64) *throttle {
+	return &throttle{rate: rate}
+}
+
+func (t *throttle) wait(ctx context.Context, n int) error {
+	if t.rate <= 0 || n == 0 {
+		return nil
+	}
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	t.ops += float64(n)
+
+	due := t.start.Add(time.Duration(t.ops / t.rate * float64(time.Second)))
+	d := time.Until(due)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
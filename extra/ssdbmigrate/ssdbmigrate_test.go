@@ -0,0 +1,265 @@
+package ssdbmigrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+var ctx = context.Background()
+
+func newServer(t *testing.T) (*ssdbtest.Server, *ssdb.Client) {
+	srv := ssdbtest.NewServer()
+	now := time.Unix(1000, 0)
+	srv.Now = func() time.Time { return now }
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: srv.Dialer(),
+	})
+	t.Cleanup(func() {
+		sdb.Close()
+		srv.Close()
+	})
+	return srv, sdb
+}
+
+func mustDo(t *testing.T, srv *ssdbtest.Server, args ...string) []string {
+	t.Helper()
+	reply := srv.Do(args...)
+	if reply[0] != "ok" {
+		t.Fatalf("%q: %q", args, reply)
+	}
+	return reply[1:]
+}
+
+// populate writes the keys of prefix and a few keys around them.
+func populate(t *testing.T, srv *ssdbtest.Server, prefix string) {
+	mustDo(t, srv, "multi_set", "a", "1", "zz", "2", prefix, "exact", prefix+"1", "1", prefix+"2", "2", prefix+"3", "")
+	mustDo(t, srv, "setx", prefix+"session", "token", "60")
+	for _, name := range []string{"a", prefix, prefix + "h"} {
+		for i := 0; i < 5; i++ {
+			mustDo(t, srv, "hset", name, "f"+strconv.Itoa(i), "v"+strconv.Itoa(i))
+		}
+	}
+	mustDo(t, srv, "multi_zset", prefix+"z", "a", "2", "b", "1", "c", "2", "d", "2", "e", "-5")
+	mustDo(t, srv, "multi_zset", "zz", "a", "1")
+	mustDo(t, srv, "qpush_back", prefix+"q", "1", "2", "3", "4", "5")
+	mustDo(t, srv, "qpush_back", "q", "1")
+}
+
+// contents returns every command reply needed to compare two servers.
+func contents(t *testing.T, srv *ssdbtest.Server, prefix string) [][]string {
+	var got [][]string
+	got = append(got,
+		mustDo(t, srv, "scan", "", "", "-1"),
+		mustDo(t, srv, "ttl", prefix+"session"),
+		mustDo(t, srv, "hlist", "", "", "-1"),
+		mustDo(t, srv, "zlist", "", "", "-1"),
+		mustDo(t, srv, "qlist", "", "", "-1"),
+	)
+	for _, name := range mustDo(t, srv, "hlist", "", "", "-1") {
+		got = append(got, mustDo(t, srv, "hgetall", name))
+	}
+	for _, name := range mustDo(t, srv, "zlist", "", "", "-1") {
+		got = append(got, mustDo(t, srv, "zscan", name, "", "", "", "-1"))
+	}
+	for _, name := range mustDo(t, srv, "qlist", "", "", "-1") {
+		got = append(got, mustDo(t, srv, "qrange", name, "0", "-1"))
+	}
+	return got
+}
+
+func TestMigrate(t *testing.T) {
+	src, srcClient := newServer(t)
+	dst, dstClient := newServer(t)
+	populate(t, src, "user:")
+	want, _ := newServer(t)
+	populate(t, want, "user:")
+
+	stats, err := Migrate(ctx, srcClient, dstClient, &Options{
+		Prefix:       "user:",
+		BatchSize:    2,
+		Verify:       true,
+		DeleteSource: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 kv keys, 2 hashes, a sorted set and a queue.
+	if stats.Keys != 9 || stats.Entries != 25 || stats.Deleted != 25 {
+		t.Fatalf("got %+v", stats)
+	}
+
+	// The destination has the keys of the prefix, the source the others.
+	for _, args := range [][]string{
+		{"del", "a"}, {"del", "zz"}, {"hclear", "a"}, {"zclear", "zz"}, {"qclear", "q"},
+	} {
+		mustDo(t, want, args...)
+	}
+	if got, wanted := contents(t, dst, "user:"), contents(t, want, "user:"); !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got\n%q\nwanted\n%q", got, wanted)
+	}
+	if got := mustDo(t, src, "scan", "", "", "-1"); !reflect.DeepEqual(got, []string{"a", "1", "zz", "2"}) {
+		t.Fatalf("got %q left in the source", got)
+	}
+	for _, list := range []string{"hlist", "zlist", "qlist"} {
+		if got := mustDo(t, src, list, "", "", "-1"); len(got) != 1 {
+			t.Fatalf("%s: got %q left in the source", list, got)
+		}
+	}
+}
+
+func TestMigrateRange(t *testing.T) {
+	src, srcClient := newServer(t)
+	dst, dstClient := newServer(t)
+	populate(t, src, "user:")
+
+	_, err := Migrate(ctx, srcClient, dstClient, &Options{
+		Start: "user:1",
+		End:   "user:3",
+		Types: []Type{TypeKV},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustDo(t, dst, "scan", "", "", "-1"); !reflect.DeepEqual(got, []string{"user:2", "2", "user:3", ""}) {
+		t.Fatalf("got %q", got)
+	}
+	if got := mustDo(t, dst, "hlist", "", "", "-1"); len(got) != 0 {
+		t.Fatalf("got %q, wanted no hashes", got)
+	}
+	if got := mustDo(t, src, "scan", "", "", "-1"); len(got) != 14 {
+		t.Fatalf("got %q, wanted the source untouched", got)
+	}
+}
+
+func TestMigrateResume(t *testing.T) {
+	src, srcClient := newServer(t)
+	dst, dstClient := newServer(t)
+	populate(t, src, "user:")
+	want, _ := newServer(t)
+	populate(t, want, "user:")
+
+	errStop := errors.New("stop")
+	pushes := 0
+	dst.Hook = func(args []string) []string {
+		if args[0] == "qpush_back" && args[1] == "user:q" {
+			if pushes++; pushes == 2 {
+				return []string{"error", errStop.Error()}
+			}
+		}
+		return nil
+	}
+
+	store := NewFileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+	opt := &Options{
+		BatchSize:    2,
+		Verify:       true,
+		DeleteSource: true,
+		Checkpoint:   store,
+	}
+	if _, err := Migrate(ctx, srcClient, dstClient, opt); err == nil || !strings.Contains(err.Error(), errStop.Error()) {
+		t.Fatalf("got %v, wanted the error of the server", err)
+	}
+	cp, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted := &Checkpoint{Type: TypeQueue, Key: "q", Name: "user:q", Offset: 2}
+	if !reflect.DeepEqual(cp, wanted) {
+		t.Fatalf("got %+v, wanted %+v", cp, wanted)
+	}
+
+	dst.Hook = nil
+	stats, err := Migrate(ctx, srcClient, dstClient, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Keys != 1 || stats.Entries != 3 || stats.Deleted != 5 {
+		t.Fatalf("got %+v", stats)
+	}
+	if got, wanted := contents(t, dst, "user:"), contents(t, want, "user:"); !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got\n%q\nwanted\n%q", got, wanted)
+	}
+	if err := store.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if cp, err := store.Load(ctx); cp != nil || err != nil {
+		t.Fatalf("got %+v, %v, wanted no checkpoint", cp, err)
+	}
+}
+
+func TestMigrateMismatch(t *testing.T) {
+	src, srcClient := newServer(t)
+	dst, dstClient := newServer(t)
+	populate(t, src, "user:")
+
+	dst.Hook = func(args []string) []string {
+		if args[0] == "multi_hget" && args[1] == "user:h" {
+			return []string{"ok", "f0", "changed"}
+		}
+		return nil
+	}
+	_, err := Migrate(ctx, srcClient, dstClient, &Options{
+		Prefix:       "user:",
+		Verify:       true,
+		DeleteSource: true,
+	})
+	if !errors.Is(err, ErrMismatch) || !strings.Contains(err.Error(), `"user:h"`) {
+		t.Fatalf("got %v, wanted ErrMismatch", err)
+	}
+	if got := mustDo(t, src, "hsize", "user:h"); got[0] != "5" {
+		t.Fatalf("got %q, wanted the hash kept in the source", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	th := newThrottle(1000)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := th.wait(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 90*time.Millisecond {
+		t.Fatalf("100 ops at 1000 ops/s took %s", d)
+	}
+
+	th = newThrottle(1)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := th.wait(cctx, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, wanted the context error", err)
+	}
+}
+
+func TestMigrateUnknownType(t *testing.T) {
+	_, srcClient := newServer(t)
+	_, dstClient := newServer(t)
+	if _, err := Migrate(ctx, srcClient, dstClient, &Options{Types: []Type{"list"}}); err == nil {
+		t.Fatal("got nil, wanted an error for an unknown type")
+	}
+}
+
+func TestMigrateCheckpointType(t *testing.T) {
+	src, srcClient := newServer(t)
+	_, dstClient := newServer(t)
+	populate(t, src, "user:")
+
+	store := NewFileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+	if err := store.Save(ctx, &Checkpoint{Type: TypeQueue, Key: "q"}); err != nil {
+		t.Fatal(err)
+	}
+	opt := &Options{Types: []Type{TypeKV, TypeHash}, Checkpoint: store}
+	if _, err := Migrate(ctx, srcClient, dstClient, opt); err == nil {
+		t.Fatal("got nil, wanted an error for a checkpoint of a type that is not migrated")
+	}
+}
@@ -0,0 +1,367 @@
+package ssdbmock
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+var (
+	_ ssdb.StatefulCmdable = (*Client)(nil)
+	_ ssdb.Pipeliner       = (*Pipeline)(nil)
+)
+
+// Client is a mock client whose commands get the results of the
+// expectations of its Mock instead of being sent to a server.
+type Client struct {
+	cmdable
+	mock *Mock
+}
+
+// Do creates a Cmd from the args and processes the cmd.
+func (c *Client) Do(ctx context.Context, args ...interface{}) *ssdb.Cmd {
+	cmd := ssdb.NewCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
+// Process sets the result of the expectation matched by cmd.
+func (c *Client) Process(ctx context.Context, cmd ssdb.Cmder) error {
+	return c.mock.process(cmd)
+}
+
+func (c *Client) Pipeline() ssdb.Pipeliner {
+	pipe := &Pipeline{mock: c.mock}
+	pipe.cmdable = pipe.Process
+	return pipe
+}
+
+func (c *Client) Pipelined(ctx context.Context, fn func(ssdb.Pipeliner) error) ([]ssdb.Cmder, error) {
+	return c.Pipeline().Pipelined(ctx, fn)
+}
+
+// TxPipeline acts like Pipeline: the commands are matched as if they were
+// not wrapped in a transaction.
+func (c *Client) TxPipeline() ssdb.Pipeliner {
+	return c.Pipeline()
+}
+
+func (c *Client) TxPipelined(ctx context.Context, fn func(ssdb.Pipeliner) error) ([]ssdb.Cmder, error) {
+	return c.TxPipeline().Pipelined(ctx, fn)
+}
+
+// Close does nothing, the mock client has no connections.
+func (c *Client) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Pipeline queues the commands of a Client, which are matched in order
+// when it is executed.
+type Pipeline struct {
+	cmdable
+	mock *Mock
+
+	mu   sync.Mutex
+	cmds []ssdb.Cmder
+}
+
+// Len returns the number of queued commands.
+func (c *Pipeline) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cmds)
+}
+
+// Do queues the custom command for later execution.
+func (c *Pipeline) Do(ctx context.Context, args ...interface{}) *ssdb.Cmd {
+	cmd := ssdb.NewCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	return cmd
+}
+
+// Process queues the cmd for later execution.
+func (c *Pipeline) Process(ctx context.Context, cmd ssdb.Cmder) error {
+	c.mu.Lock()
+	c.cmds = append(c.cmds, cmd)
+	c.mu.Unlock()
+	return nil
+}
+
+// Discard discards the queued commands.
+func (c *Pipeline) Discard() {
+	c.mu.Lock()
+	c.cmds = c.cmds[:0]
+	c.mu.Unlock()
+}
+
+// Exec sets the results of the queued commands from the expectations, in
+// order, and returns the commands and the error of the first failed one.
+func (c *Pipeline) Exec(ctx context.Context) ([]ssdb.Cmder, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cmds) == 0 {
+		return nil, nil
+	}
+	cmds := c.cmds
+	c.cmds = nil
+
+	var firstErr error
+	for _, cmd := range cmds {
+		if err := c.mock.process(cmd); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return cmds, firstErr
+}
+
+func (c *Pipeline) Pipelined(ctx context.Context, fn func(ssdb.Pipeliner) error) ([]ssdb.Cmder, error) {
+	if err := fn(c); err != nil {
+		return nil, err
+	}
+	return c.Exec(ctx)
+}
+
+func (c *Pipeline) Pipeline() ssdb.Pipeliner {
+	return c
+}
+
+func (c *Pipeline) TxPipelined(ctx context.Context, fn func(ssdb.Pipeliner) error) ([]ssdb.Cmder, error) {
+	return c.Pipelined(ctx, fn)
+}
+
+func (c *Pipeline) TxPipeline() ssdb.Pipeliner {
+	return c
+}
+
+//------------------------------------------------------------------------------
+
+// cmdable implements the commands of Client and Pipeline. The arguments of
+// the commands are the ones of their Expect methods.
+type cmdable func(ctx context.Context, cmd ssdb.Cmder) error
+
+func (c cmdable) do(ctx context.Context, args ...interface{}) *ssdb.Cmd {
+	cmd := ssdb.NewCmd(ctx, args...)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+func (c cmdable) Auth(ctx context.Context, password string) *ssdb.Cmd {
+	return c.do(ctx, "auth", password)
+}
+
+// AuthACL sends the auth command with the given password.
+//
+// Deprecated: SSDB has no users and username is ignored. Use Auth instead.
+func (c cmdable) AuthACL(ctx context.Context, username, password string) *ssdb.Cmd {
+	return c.do(ctx, "auth", password)
+}
+
+func (c cmdable) ClientSetName(ctx context.Context, name string) *ssdb.Cmd {
+	return c.do(ctx, "client", "setname", name)
+}
+
+func (c cmdable) DBSize(ctx context.Context) *ssdb.Cmd {
+	return c.do(ctx, "dbsize")
+}
+
+func (c cmdable) DBInfo(ctx context.Context) *ssdb.Cmd {
+	return c.do(ctx, "info")
+}
+
+func (c cmdable) Ping(ctx context.Context) *ssdb.Cmd {
+	return c.do(ctx, "ping")
+}
+
+func (c cmdable) Set(ctx context.Context, key string, val interface{}, ttl ...int64) *ssdb.Cmd {
+	return c.do(ctx, setArgs(key, val, ttl)...)
+}
+
+func (c cmdable) SetNX(ctx context.Context, key string, val interface{}) *ssdb.Cmd {
+	return c.do(ctx, "setnx", key, val)
+}
+
+func (c cmdable) Get(ctx context.Context, key string) *ssdb.Cmd {
+	return c.do(ctx, "get", key)
+}
+
+func (c cmdable) GetSet(ctx context.Context, key string, val interface{}) *ssdb.Cmd {
+	return c.do(ctx, "getset", key, val)
+}
+
+func (c cmdable) Del(ctx context.Context, key string) *ssdb.Cmd {
+	return c.do(ctx, "del", key)
+}
+
+// HSet sets the fields of the hash key, val being field value pairs.
+func (c cmdable) HSet(ctx context.Context, key string, val ...interface{}) *ssdb.Cmd {
+	return c.do(ctx, hsetArgs(key, val)...)
+}
+
+func (c cmdable) Expire(ctx context.Context, key string, ttl int64) *ssdb.Cmd {
+	return c.do(ctx, "expire", key, ttl)
+}
+
+func (c cmdable) Exists(ctx context.Context, key string) *ssdb.Cmd {
+	return c.do(ctx, "exists", key)
+}
+
+func (c cmdable) TTL(ctx context.Context, key string) *ssdb.Cmd {
+	return c.do(ctx, "ttl", key)
+}
+
+func (c cmdable) Incr(ctx context.Context, key string, num int64) *ssdb.Cmd {
+	return c.do(ctx, "incr", key, num)
+}
+
+// MultiSet sets the keys of kvs, sent in the order of the keys.
+func (c cmdable) MultiSet(ctx context.Context, kvs map[string]interface{}) *ssdb.Cmd {
+	return c.do(ctx, multiSetArgs(kvs)...)
+}
+
+func (c cmdable) MultiGet(ctx context.Context, keys ...string) *ssdb.Cmd {
+	return c.do(ctx, keysArgs("multi_get", keys)...)
+}
+
+func (c cmdable) MultiDel(ctx context.Context, keys ...string) *ssdb.Cmd {
+	return c.do(ctx, keysArgs("multi_del", keys)...)
+}
+
+func (c cmdable) HGet(ctx context.Context, key, field string) *ssdb.Cmd {
+	return c.do(ctx, "hget", key, field)
+}
+
+func (c cmdable) HGetAll(ctx context.Context, key string) *ssdb.Cmd {
+	return c.do(ctx, "hgetall", key)
+}
+
+//------------------------------------------------------------------------------
+
+// ExpectDo expects the custom command args.
+func (m *Mock) ExpectDo(args ...interface{}) *ExpectedCmd {
+	return m.expect(args...)
+}
+
+func (m *Mock) ExpectAuth(password string) *ExpectedCmd {
+	return m.expect("auth", password)
+}
+
+func (m *Mock) ExpectClientSetName(name string) *ExpectedCmd {
+	return m.expect("client", "setname", name)
+}
+
+func (m *Mock) ExpectDBSize() *ExpectedCmd {
+	return m.expect("dbsize")
+}
+
+func (m *Mock) ExpectDBInfo() *ExpectedCmd {
+	return m.expect("info")
+}
+
+func (m *Mock) ExpectPing() *ExpectedCmd {
+	return m.expect("ping")
+}
+
+func (m *Mock) ExpectSet(key string, val interface{}, ttl ...int64) *ExpectedCmd {
+	return m.expect(setArgs(key, val, ttl)...)
+}
+
+func (m *Mock) ExpectSetNX(key string, val interface{}) *ExpectedCmd {
+	return m.expect("setnx", key, val)
+}
+
+func (m *Mock) ExpectGet(key string) *ExpectedCmd {
+	return m.expect("get", key)
+}
+
+func (m *Mock) ExpectGetSet(key string, val interface{}) *ExpectedCmd {
+	return m.expect("getset", key, val)
+}
+
+func (m *Mock) ExpectDel(key string) *ExpectedCmd {
+	return m.expect("del", key)
+}
+
+func (m *Mock) ExpectHSet(key string, val ...interface{}) *ExpectedCmd {
+	return m.expect(hsetArgs(key, val)...)
+}
+
+func (m *Mock) ExpectExpire(key string, ttl int64) *ExpectedCmd {
+	return m.expect("expire", key, ttl)
+}
+
+func (m *Mock) ExpectExists(key string) *ExpectedCmd {
+	return m.expect("exists", key)
+}
+
+func (m *Mock) ExpectTTL(key string) *ExpectedCmd {
+	return m.expect("ttl", key)
+}
+
+func (m *Mock) ExpectIncr(key string, num int64) *ExpectedCmd {
+	return m.expect("incr", key, num)
+}
+
+func (m *Mock) ExpectMultiSet(kvs map[string]interface{}) *ExpectedCmd {
+	return m.expect(multiSetArgs(kvs)...)
+}
+
+func (m *Mock) ExpectMultiGet(keys ...string) *ExpectedCmd {
+	return m.expect(keysArgs("multi_get", keys)...)
+}
+
+func (m *Mock) ExpectMultiDel(keys ...string) *ExpectedCmd {
+	return m.expect(keysArgs("multi_del", keys)...)
+}
+
+func (m *Mock) ExpectHGet(key, field string) *ExpectedCmd {
+	return m.expect("hget", key, field)
+}
+
+func (m *Mock) ExpectHGetAll(key string) *ExpectedCmd {
+	return m.expect("hgetall", key)
+}
+
+//------------------------------------------------------------------------------
+
+func setArgs(key string, val interface{}, ttl []int64) []interface{} {
+	if len(ttl) > 0 && ttl[0] > 0 {
+		return []interface{}{"setx", key, val, ttl[0]}
+	}
+	return []interface{}{"set", key, val}
+}
+
+func hsetArgs(key string, val []interface{}) []interface{} {
+	if len(val) == 2 {
+		return []interface{}{"hset", key, val[0], val[1]}
+	}
+	return append([]interface{}{"multi_hset", key}, val...)
+}
+
+func multiSetArgs(kvs map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]interface{}, 1, 1+2*len(keys))
+	args[0] = "multi_set"
+	for _, key := range keys {
+		args = append(args, key, kvs[key])
+	}
+	return args
+}
+
+func keysArgs(name string, keys []string) []interface{} {
+	args := make([]interface{}, 1, 1+len(keys))
+	args[0] = name
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	return args
+}
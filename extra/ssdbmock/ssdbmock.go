@@ -0,0 +1,251 @@
+// Package ssdbmock stubs an SSDB client in tests.
+//
+// NewClientMock returns a Client, which implements ssdb.Cmdable, and a Mock
+// that records the commands the Client is expected to receive and their
+// results:
+//
+//	db, mock := ssdbmock.NewClientMock(t)
+//	mock.ExpectGet("k").SetVal("v")
+//	mock.ExpectMultiGet("a", "b").SetErr(errors.New("boom"))
+//
+// The commands are matched in order by default, with their arguments
+// compared exactly. MatchRegexp and Match relax the comparison of an
+// expectation. The commands queued on a pipeline are matched in order when
+// the pipeline is executed. An unexpected command fails the test and the
+// expectations that were not met fail it when the test ends.
+package ssdbmock
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal"
+)
+
+// TestingT is the subset of testing.TB used by the mock.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// NewClientMock returns a mock client and the recorder of its expectations.
+// The test t fails when the client receives an unexpected command, or when
+// it ends with expectations that were not met.
+func NewClientMock(t TestingT) (*Client, *Mock) {
+	m := &Mock{t: t, inOrder: true}
+	t.Cleanup(func() {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("%v", err)
+		}
+	})
+	c := &Client{mock: m}
+	c.cmdable = c.Process
+	return c, m
+}
+
+// Mock records the commands expected by a Client.
+type Mock struct {
+	t TestingT
+
+	mu       sync.Mutex
+	expected []*ExpectedCmd
+	inOrder  bool
+}
+
+// MatchExpectationsInOrder sets whether the commands must be received in
+// the order of their expectations, which is the default. Otherwise a command
+// matches the first expectation not met yet that it matches.
+func (m *Mock) MatchExpectationsInOrder(b bool) {
+	m.mu.Lock()
+	m.inOrder = b
+	m.mu.Unlock()
+}
+
+// ExpectationsWereMet returns an error listing the expectations that were
+// not met.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var unmet []string
+	for _, e := range m.expected {
+		if !e.triggered {
+			unmet = append(unmet, "\n\t"+e.String())
+		}
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	return fmt.Errorf("ssdbmock: %d expectations were not met:%s", len(unmet), strings.Join(unmet, ""))
+}
+
+// ClearExpect removes the expectations.
+func (m *Mock) ClearExpect() {
+	m.mu.Lock()
+	m.expected = nil
+	m.mu.Unlock()
+}
+
+func (m *Mock) expect(args ...interface{}) *ExpectedCmd {
+	e := &ExpectedCmd{mock: m, args: args}
+	m.mu.Lock()
+	m.expected = append(m.expected, e)
+	m.mu.Unlock()
+	return e
+}
+
+// process sets the result of the expectation matched by cmd.
+func (m *Mock) process(cmd ssdb.Cmder) error {
+	e, err := m.match(cmd.Args())
+	if err != nil {
+		m.t.Helper()
+		m.t.Errorf("%v", err)
+		cmd.SetErr(err)
+		return err
+	}
+	return e.apply(cmd)
+}
+
+func (m *Mock) match(args []interface{}) (*ExpectedCmd, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.expected {
+		if e.triggered {
+			continue
+		}
+		err := e.matches(args)
+		if err == nil {
+			e.triggered = true
+			return e, nil
+		}
+		if m.inOrder {
+			return nil, fmt.Errorf("ssdbmock: call to %s does not match the next expectation %s: %w",
+				formatArgs(args), e, err)
+		}
+	}
+	return nil, fmt.Errorf("ssdbmock: unexpected call to %s", formatArgs(args))
+}
+
+//------------------------------------------------------------------------------
+
+// ExpectedCmd is a command expected by the mock and its result.
+type ExpectedCmd struct {
+	mock *Mock
+	args []interface{}
+
+	regexps []*regexp.Regexp
+	matchFn func(expected, actual []interface{}) error
+
+	val       interface{}
+	err       error
+	triggered bool
+}
+
+// SetVal sets the value of the command, returned by its Val method.
+func (e *ExpectedCmd) SetVal(val interface{}) {
+	e.mock.mu.Lock()
+	e.val = val
+	e.mock.mu.Unlock()
+}
+
+// SetErr sets the error of the command, e.g. ssdb.Nil for a missing key.
+func (e *ExpectedCmd) SetErr(err error) {
+	e.mock.mu.Lock()
+	e.err = err
+	e.mock.mu.Unlock()
+}
+
+// MatchRegexp makes the expected arguments, but the command name, regular
+// expressions that must match the whole arguments received.
+func (e *ExpectedCmd) MatchRegexp() *ExpectedCmd {
+	regexps := make([]*regexp.Regexp, len(e.args))
+	for i := 1; i < len(e.args); i++ {
+		// An invalid pattern matches nothing, the mismatch reports it.
+		regexps[i], _ = regexp.Compile("^(?:" + argString(e.args[i]) + ")$")
+	}
+
+	e.mock.mu.Lock()
+	e.regexps = regexps
+	e.mock.mu.Unlock()
+	return e
+}
+
+// Match makes fn compare the expected arguments with the ones received,
+// the command name first. fn returns nil if they match.
+func (e *ExpectedCmd) Match(fn func(expected, actual []interface{}) error) *ExpectedCmd {
+	e.mock.mu.Lock()
+	e.matchFn = fn
+	e.mock.mu.Unlock()
+	return e
+}
+
+func (e *ExpectedCmd) String() string {
+	return formatArgs(e.args)
+}
+
+func (e *ExpectedCmd) matches(args []interface{}) error {
+	if e.matchFn != nil {
+		return e.matchFn(e.args, args)
+	}
+	if len(args) != len(e.args) {
+		return fmt.Errorf("got %d arguments, wanted %d", len(args), len(e.args))
+	}
+	for i, arg := range args {
+		got, wanted := argString(arg), argString(e.args[i])
+		switch {
+		case i == 0:
+			if !strings.EqualFold(got, wanted) {
+				return fmt.Errorf("got command %q, wanted %q", got, wanted)
+			}
+		case e.regexps != nil:
+			if e.regexps[i] == nil {
+				return fmt.Errorf("invalid pattern %q of argument %d", wanted, i)
+			}
+			if !e.regexps[i].MatchString(got) {
+				return fmt.Errorf("argument %d %q does not match %q", i, got, wanted)
+			}
+		case got != wanted:
+			return fmt.Errorf("got argument %d %q, wanted %q", i, got, wanted)
+		}
+	}
+	return nil
+}
+
+func (e *ExpectedCmd) apply(cmd ssdb.Cmder) error {
+	e.mock.mu.Lock()
+	val, err := e.val, e.err
+	e.mock.mu.Unlock()
+
+	if val != nil {
+		c, ok := cmd.(*ssdb.Cmd)
+		if !ok {
+			err = fmt.Errorf("ssdbmock: can't set the value of %T", cmd)
+			cmd.SetErr(err)
+			return err
+		}
+		c.SetVal(val)
+	}
+	cmd.SetErr(err)
+	return err
+}
+
+// argString formats arg as it is sent to the server.
+func argString(arg interface{}) string {
+	return string(internal.AppendArg(nil, arg))
+}
+
+func formatArgs(args []interface{}) string {
+	b := make([]byte, 0, 64)
+	for i, arg := range args {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = internal.AppendArg(b, arg)
+	}
+	return string(b)
+}
@@ -0,0 +1,182 @@
+package ssdbmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+var ctx = context.Background()
+
+// fakeT records the failures of a test.
+type fakeT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *fakeT) end() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestMock(t *testing.T) {
+	db, mock := NewClientMock(t)
+
+	boom := errors.New("boom")
+	mock.ExpectGet("k").SetVal("v")
+	mock.ExpectSet("k", "v", 10).SetVal("1")
+	mock.ExpectMultiGet("a", "b").SetErr(boom)
+	mock.ExpectGet("missing").SetErr(ssdb.Nil)
+	mock.ExpectIncr("n", 2).SetVal(int64(3))
+	mock.ExpectMultiSet(map[string]interface{}{"b": 2, "a": 1})
+	mock.ExpectDo("qpush", "q", "x").SetVal([]string{"1"})
+
+	var s ssdb.Cmdable = db
+	if got, err := s.Get(ctx, "k").Text(); err != nil || got != "v" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if err := s.Set(ctx, "k", "v", 10).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.MultiGet(ctx, "a", "b").Err(); err != boom {
+		t.Fatalf("got %v, wanted %v", err, boom)
+	}
+	if err := s.Get(ctx, "missing").Err(); err != ssdb.Nil {
+		t.Fatalf("got %v, wanted ssdb.Nil", err)
+	}
+	// The arguments are compared as they are sent, int64(2) is "2".
+	if got, err := db.Incr(ctx, "n", 2).Int64(); err != nil || got != 3 {
+		t.Fatalf("got %d, %v", got, err)
+	}
+	if err := db.MultiSet(ctx, map[string]interface{}{"a": 1, "b": 2}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Do(ctx, "QPUSH", "q", "x").Val(); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Fatalf("got %v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMockMatch(t *testing.T) {
+	db, mock := NewClientMock(t)
+
+	mock.ExpectGet(`user:\d+`).MatchRegexp().SetVal("u")
+	mock.ExpectSet("k", nil).Match(func(expected, actual []interface{}) error {
+		if len(actual) != 3 || actual[1] != "k" {
+			return errors.New("wrong key")
+		}
+		return nil
+	})
+
+	if got := db.Get(ctx, "user:42").Val(); got != "u" {
+		t.Fatalf("got %v", got)
+	}
+	if err := db.Set(ctx, "k", "any value").Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMockPipeline(t *testing.T) {
+	db, mock := NewClientMock(t)
+
+	mock.ExpectSet("a", 1).SetVal("1")
+	mock.ExpectGet("b").SetErr(ssdb.Nil)
+	mock.ExpectDel("c").SetVal("1")
+
+	cmds, err := db.Pipelined(ctx, func(pipe ssdb.Pipeliner) error {
+		pipe.Set(ctx, "a", 1)
+		pipe.Get(ctx, "b")
+		pipe.Del(ctx, "c")
+		if n := pipe.Len(); n != 3 {
+			t.Fatalf("got %d queued commands, wanted 3", n)
+		}
+		return nil
+	})
+	if err != ssdb.Nil {
+		t.Fatalf("got %v, wanted the error of the first failed command", err)
+	}
+	if len(cmds) != 3 || cmds[0].Err() != nil || cmds[1].Err() != ssdb.Nil || cmds[2].Err() != nil {
+		t.Fatalf("got %v", cmds)
+	}
+
+	pipe := db.Pipeline()
+	pipe.Get(ctx, "d")
+	pipe.Discard()
+	if cmds, err := pipe.Exec(ctx); cmds != nil || err != nil {
+		t.Fatalf("got %v, %v after Discard", cmds, err)
+	}
+}
+
+func TestMockFailures(t *testing.T) {
+	ft := new(fakeT)
+	db, mock := NewClientMock(ft)
+
+	mock.ExpectGet("a").SetVal("1")
+	mock.ExpectGet("b").SetVal("2")
+	mock.ExpectPing()
+
+	// Out of order.
+	if err := db.Get(ctx, "b").Err(); err == nil {
+		t.Fatal("got nil, wanted an error for the call out of order")
+	}
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "does not match the next expectation get a") {
+		t.Fatalf("got %q", ft.errors)
+	}
+
+	mock.MatchExpectationsInOrder(false)
+	if got := db.Get(ctx, "b").Val(); got != "2" {
+		t.Fatalf("got %v", got)
+	}
+
+	// Unexpected.
+	if err := db.Del(ctx, "a").Err(); err == nil {
+		t.Fatal("got nil, wanted an error for the unexpected call")
+	}
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[1], "unexpected call to del a") {
+		t.Fatalf("got %q", ft.errors)
+	}
+
+	// Unmet.
+	ft.end()
+	if len(ft.errors) != 3 || !strings.Contains(ft.errors[2], "2 expectations were not met") ||
+		!strings.Contains(ft.errors[2], "get a") || !strings.Contains(ft.errors[2], "ping") {
+		t.Fatalf("got %q", ft.errors)
+	}
+}
+
+func TestMockPipelineOrder(t *testing.T) {
+	ft := new(fakeT)
+	db, mock := NewClientMock(ft)
+
+	mock.ExpectGet("a")
+	mock.ExpectGet("b")
+
+	pipe := db.Pipeline()
+	pipe.Get(ctx, "b")
+	pipe.Get(ctx, "a")
+	if _, err := pipe.Exec(ctx); err == nil {
+		t.Fatal("got nil, wanted an error for the commands out of order")
+	}
+	if len(ft.errors) != 1 {
+		t.Fatalf("got %q", ft.errors)
+	}
+}
@@ -0,0 +1,222 @@
+package ssdbotel
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// InstrumentMetrics starts reporting OpenTelemetry Metrics for the client
+// connection pool and its connections.
+func InstrumentMetrics(sdb *ssdb.Client, opts ...MetricsOption) error {
+	baseOpts := make([]baseOption, len(opts))
+	for i, opt := range opts {
+		baseOpts[i] = opt
+	}
+	conf := newConfig(baseOpts...)
+
+	if conf.meter == nil {
+		conf.meter = conf.mp.Meter(
+			instrumName,
+			metric.WithInstrumentationVersion("semver:"+ssdb.Version()),
+		)
+	}
+
+	opt := sdb.Options()
+	conf.attrs = append(conf.attrs, attribute.String("pool.name", opt.Addr))
+
+	if err := reportPoolStats(sdb, conf); err != nil {
+		return err
+	}
+	return reportConnStats(sdb, conf)
+}
+
+func reportPoolStats(sdb *ssdb.Client, conf *config) error {
+	labels := conf.attrs
+	idleAttrs := appendAttrs(labels, attribute.String("state", "idle"))
+	usedAttrs := appendAttrs(labels, attribute.String("state", "used"))
+
+	opt := sdb.Options()
+
+	idleMax, err := conf.meter.Int64ObservableGauge(
+		"db.client.connections.idle.max",
+		metric.WithDescription("The maximum number of idle open connections allowed"),
+	)
+	if err != nil {
+		return err
+	}
+
+	idleMin, err := conf.meter.Int64ObservableGauge(
+		"db.client.connections.idle.min",
+		metric.WithDescription("The minimum number of idle open connections allowed"),
+	)
+	if err != nil {
+		return err
+	}
+
+	connsMax, err := conf.meter.Int64ObservableGauge(
+		"db.client.connections.max",
+		metric.WithDescription("The maximum number of open connections allowed"),
+	)
+	if err != nil {
+		return err
+	}
+
+	usage, err := conf.meter.Int64ObservableUpDownCounter(
+		"db.client.connections.usage",
+		metric.WithDescription("The number of connections that are currently in state described by the state attribute"),
+	)
+	if err != nil {
+		return err
+	}
+
+	timeouts, err := conf.meter.Int64ObservableCounter(
+		"db.client.connections.timeouts",
+		metric.WithDescription("The number of connection timeouts that have occurred trying to obtain a connection from the pool"),
+	)
+	if err != nil {
+		return err
+	}
+
+	hits, err := conf.meter.Int64ObservableCounter(
+		"db.client.connections.hits",
+		metric.WithDescription("The number of times a free connection was found in the pool"),
+	)
+	if err != nil {
+		return err
+	}
+
+	misses, err := conf.meter.Int64ObservableCounter(
+		"db.client.connections.misses",
+		metric.WithDescription("The number of times a free connection was not found in the pool"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = conf.meter.RegisterCallback(
+		func(ctx context.Context, o metric.Observer) error {
+			stats := sdb.PoolStats()
+
+			o.ObserveInt64(idleMax, int64(opt.MaxIdleConns), metric.WithAttributes(labels...))
+			o.ObserveInt64(idleMin, int64(opt.MinIdleConns), metric.WithAttributes(labels...))
+			o.ObserveInt64(connsMax, int64(opt.PoolSize), metric.WithAttributes(labels...))
+
+			o.ObserveInt64(usage, int64(stats.IdleConns), metric.WithAttributes(idleAttrs...))
+			o.ObserveInt64(usage, int64(stats.TotalConns-stats.IdleConns), metric.WithAttributes(usedAttrs...))
+
+			o.ObserveInt64(timeouts, int64(stats.Timeouts), metric.WithAttributes(labels...))
+			o.ObserveInt64(hits, int64(stats.Hits), metric.WithAttributes(labels...))
+			o.ObserveInt64(misses, int64(stats.Misses), metric.WithAttributes(labels...))
+			return nil
+		},
+		idleMax,
+		idleMin,
+		connsMax,
+		usage,
+		timeouts,
+		hits,
+		misses,
+	)
+
+	return err
+}
+
+func reportConnStats(sdb *ssdb.Client, conf *config) error {
+	createTime, err := conf.meter.Float64Histogram(
+		"db.client.connections.create_time",
+		metric.WithDescription("The time it took to create a new connection."),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return err
+	}
+
+	dialErrors, err := conf.meter.Int64Counter(
+		"db.client.connections.dial_errors",
+		metric.WithDescription("The number of failed attempts to establish a new connection."),
+	)
+	if err != nil {
+		return err
+	}
+
+	closed, err := conf.meter.Int64Counter(
+		"db.client.connections.closed",
+		metric.WithDescription("The number of connections closed by the pool, by close reason."),
+	)
+	if err != nil {
+		return err
+	}
+
+	sdb.AddConnHook(&metricsConnHook{
+		attrs:      conf.attrs,
+		createTime: createTime,
+		dialErrors: dialErrors,
+		closed:     closed,
+	})
+	return nil
+}
+
+type dialStartKey struct{}
+
+// metricsConnHook is a ssdb.ConnHook that records dial latencies, dial
+// errors and closed connections.
+type metricsConnHook struct {
+	attrs []attribute.KeyValue
+
+	createTime metric.Float64Histogram
+	dialErrors metric.Int64Counter
+	closed     metric.Int64Counter
+}
+
+var _ ssdb.ConnHook = (*metricsConnHook)(nil)
+
+func (h *metricsConnHook) DialStart(ctx context.Context, network, addr string) context.Context {
+	return context.WithValue(ctx, dialStartKey{}, time.Now())
+}
+
+func (h *metricsConnHook) DialFinish(ctx context.Context, network, addr string, remoteAddr net.Addr, err error) {
+	start, ok := ctx.Value(dialStartKey{}).(time.Time)
+	if ok {
+		dur := milliseconds(time.Since(start))
+		h.createTime.Record(ctx, dur, metric.WithAttributes(appendAttrs(h.attrs, statusAttr(err))...))
+	}
+	if err != nil {
+		h.dialErrors.Add(ctx, 1, metric.WithAttributes(h.attrs...))
+	}
+}
+
+func (h *metricsConnHook) ConnCheckout(ctx context.Context, cn ssdb.ConnInfo) {}
+
+func (h *metricsConnHook) ConnReturn(ctx context.Context, cn ssdb.ConnInfo) {}
+
+func (h *metricsConnHook) ConnClose(cn ssdb.ConnInfo, reason ssdb.CloseReason, err error) {
+	attrs := appendAttrs(h.attrs, attribute.String("reason", string(reason)))
+	h.closed.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+}
+
+func (h *metricsConnHook) AuthResult(ctx context.Context, cn ssdb.ConnInfo, err error) {}
+
+// appendAttrs returns a copy of attrs with more appended, so the shared
+// labels slice is never modified.
+func appendAttrs(attrs []attribute.KeyValue, more ...attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs)+len(more))
+	out = append(out, attrs...)
+	return append(out, more...)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func statusAttr(err error) attribute.KeyValue {
+	if err != nil {
+		return attribute.String("status", "error")
+	}
+	return attribute.String("status", "ok")
+}
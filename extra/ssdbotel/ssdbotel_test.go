@@ -0,0 +1,209 @@
+package ssdbotel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+func newTracingHook(t *testing.T, opts ...TracingOption) (*TracingHook, *tracetest.SpanRecorder, context.Context) {
+	t.Helper()
+
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	opts = append(opts, WithTracerProvider(provider))
+	hook := NewTracingHook(opts...)
+
+	ctx, span := provider.Tracer("ssdb-test").Start(context.TODO(), "ssdb-test")
+	t.Cleanup(func() { span.End() })
+
+	return hook, sr, ctx
+}
+
+func attrValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingHookProcess(t *testing.T) {
+	hook, sr, ctx := newTracingHook(t)
+
+	cmd := ssdb.NewCmd(ctx, "get", "key")
+	ctx, err := hook.BeforeProcess(ctx, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.SetErr(errors.New("ssdb: boom"))
+	if err := hook.AfterProcess(ctx, cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, wanted 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "get" {
+		t.Fatalf("span name = %q, wanted %q", span.Name(), "get")
+	}
+	if v, _ := attrValue(span.Attributes(), semconv.DBSystemKey); v.AsString() != "ssdb" {
+		t.Fatalf("db.system = %q", v.AsString())
+	}
+	if v, _ := attrValue(span.Attributes(), semconv.DBStatementKey); v.AsString() != "get key" {
+		t.Fatalf("db.statement = %q", v.AsString())
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("status = %v, wanted error", span.Status().Code)
+	}
+}
+
+func TestTracingHookNil(t *testing.T) {
+	hook, sr, ctx := newTracingHook(t)
+
+	cmd := ssdb.NewCmd(ctx, "get", "key")
+	ctx, _ = hook.BeforeProcess(ctx, cmd)
+	cmd.SetErr(ssdb.Nil)
+	_ = hook.AfterProcess(ctx, cmd)
+
+	span := sr.Ended()[0]
+	if span.Status().Code == codes.Error {
+		t.Fatal("ssdb.Nil must not be recorded as an error")
+	}
+}
+
+func TestTracingHookRedactor(t *testing.T) {
+	hook, sr, ctx := newTracingHook(t, WithStatementRedactor(func(cmd ssdb.Cmder, stmt string) string {
+		return cmd.Name() + " ?"
+	}))
+
+	cmd := ssdb.NewCmd(ctx, "set", "key", "secret")
+	ctx, _ = hook.BeforeProcess(ctx, cmd)
+	_ = hook.AfterProcess(ctx, cmd)
+
+	span := sr.Ended()[0]
+	if v, _ := attrValue(span.Attributes(), semconv.DBStatementKey); v.AsString() != "set ?" {
+		t.Fatalf("db.statement = %q", v.AsString())
+	}
+}
+
+func TestTracingHookPipeline(t *testing.T) {
+	hook, sr, ctx := newTracingHook(t, WithDBStatement(false))
+
+	cmds := []ssdb.Cmder{
+		ssdb.NewCmd(ctx, "set", "key", "value"),
+		ssdb.NewCmd(ctx, "get", "key"),
+	}
+	ctx, _ = hook.BeforeProcessPipeline(ctx, cmds)
+	_ = hook.AfterProcessPipeline(ctx, cmds)
+
+	span := sr.Ended()[0]
+	if span.Name() != "pipeline set get" {
+		t.Fatalf("span name = %q", span.Name())
+	}
+	if v, _ := attrValue(span.Attributes(), "db.ssdb.num_cmd"); v.AsInt64() != 2 {
+		t.Fatalf("db.ssdb.num_cmd = %d", v.AsInt64())
+	}
+	if _, ok := attrValue(span.Attributes(), semconv.DBStatementKey); ok {
+		t.Fatal("db.statement must not be recorded")
+	}
+	if len(span.Events()) != 2 {
+		t.Fatalf("got %d events, wanted 2", len(span.Events()))
+	}
+}
+
+func TestTracingHookPipelineCmdError(t *testing.T) {
+	hook, sr, ctx := newTracingHook(t)
+
+	get := ssdb.NewCmd(ctx, "get", "key")
+	cmds := []ssdb.Cmder{get}
+	ctx, _ = hook.BeforeProcessPipeline(ctx, cmds)
+	get.SetErr(ssdb.Nil)
+	_ = hook.AfterProcessPipeline(ctx, cmds)
+
+	span := sr.Ended()[0]
+	if span.Status().Code == codes.Error {
+		t.Fatal("a command reply must not fail the pipeline span")
+	}
+	event := span.Events()[0]
+	if v, _ := attrValue(event.Attributes, "db.ssdb.cmd.status"); v.AsString() != "nil" {
+		t.Fatalf("db.ssdb.cmd.status = %q", v.AsString())
+	}
+}
+
+func TestPeerAttrs(t *testing.T) {
+	attrs := peerAttrs(&ssdb.Options{Network: "tcp", Addr: "db.local:8888"})
+	if v, _ := attrValue(attrs, semconv.NetPeerNameKey); v.AsString() != "db.local" {
+		t.Fatalf("net.peer.name = %q", v.AsString())
+	}
+	if v, _ := attrValue(attrs, semconv.NetPeerPortKey); v.AsInt64() != 8888 {
+		t.Fatalf("net.peer.port = %d", v.AsInt64())
+	}
+}
+
+func TestInstrumentMetrics(t *testing.T) {
+	dialErr := errors.New("dial failed")
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:       "db.local:8888",
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, dialErr
+		},
+	})
+	defer sdb.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	if err := InstrumentMetrics(sdb, WithMeterProvider(provider)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sdb.Ping(context.TODO()).Err(); !errors.Is(err, dialErr) {
+		t.Fatalf("got %v, wanted %v", err, dialErr)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.TODO(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	found := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = m.Data
+		}
+	}
+
+	for _, name := range []string{
+		"db.client.connections.usage",
+		"db.client.connections.max",
+		"db.client.connections.create_time",
+	} {
+		if _, ok := found[name]; !ok {
+			t.Fatalf("metric %s is not reported", name)
+		}
+	}
+
+	sum, ok := found["db.client.connections.dial_errors"].(metricdata.Sum[int64])
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+		t.Fatalf("got dial_errors %+v, wanted 1", found["db.client.connections.dial_errors"])
+	}
+	if v, _ := sum.DataPoints[0].Attributes.Value("pool.name"); !strings.HasPrefix(v.AsString(), "db.local") {
+		t.Fatalf("pool.name = %q", v.AsString())
+	}
+}
@@ -0,0 +1,191 @@
+package ssdbotel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/extra/ssdbcmd"
+)
+
+const (
+	instrumName = "github.com/ssdb-go/ssdb/extra/ssdbotel"
+)
+
+// InstrumentTracing adds a TracingHook to the client. The address of the
+// client is recorded as net.peer.name and net.peer.port.
+func InstrumentTracing(sdb *ssdb.Client, opts ...TracingOption) error {
+	opts = append(opts, WithAttributes(peerAttrs(sdb.Options())...))
+	sdb.AddHook(NewTracingHook(opts...))
+	return nil
+}
+
+// TracingHook is a ssdb.Hook that starts a client span for every command
+// and for every pipeline.
+type TracingHook struct {
+	conf *config
+
+	spanOpts []trace.SpanStartOption
+}
+
+var _ ssdb.Hook = (*TracingHook)(nil)
+
+func NewTracingHook(opts ...TracingOption) *TracingHook {
+	baseOpts := make([]baseOption, len(opts))
+	for i, opt := range opts {
+		baseOpts[i] = opt
+	}
+	conf := newConfig(baseOpts...)
+
+	if conf.tracer == nil {
+		conf.tracer = conf.tp.Tracer(
+			instrumName,
+			trace.WithInstrumentationVersion("semver:"+ssdb.Version()),
+		)
+	}
+
+	return &TracingHook{
+		conf: conf,
+
+		spanOpts: []trace.SpanStartOption{
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(conf.attrs...),
+		},
+	}
+}
+
+func (th *TracingHook) BeforeProcess(ctx context.Context, cmd ssdb.Cmder) (context.Context, error) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+
+	attrs := make([]attribute.KeyValue, 0, 2)
+	attrs = append(attrs, semconv.DBOperation(cmd.FullName()))
+	if th.conf.dbStmtEnabled {
+		attrs = append(attrs, semconv.DBStatement(th.statement(cmd)))
+	}
+
+	opts := th.spanOpts
+	opts = append(opts, trace.WithAttributes(attrs...))
+
+	ctx, _ = th.conf.tracer.Start(ctx, cmd.FullName(), opts...)
+	return ctx, nil
+}
+
+func (th *TracingHook) AfterProcess(ctx context.Context, cmd ssdb.Cmder) error {
+	span := trace.SpanFromContext(ctx)
+	if err := cmd.Err(); err != nil {
+		recordError(span, err)
+	}
+	span.End()
+	return nil
+}
+
+func (th *TracingHook) BeforeProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) (context.Context, error) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+
+	summary, cmdsString := ssdbcmd.CmdsString(cmds)
+
+	attrs := make([]attribute.KeyValue, 0, 3)
+	attrs = append(attrs,
+		semconv.DBOperation("pipeline"),
+		attribute.Int("db.ssdb.num_cmd", len(cmds)),
+	)
+	if th.conf.dbStmtEnabled {
+		if th.conf.redact != nil {
+			cmdsString = th.pipelineStatement(cmds)
+		}
+		attrs = append(attrs, semconv.DBStatement(cmdsString))
+	}
+
+	opts := th.spanOpts
+	opts = append(opts, trace.WithAttributes(attrs...))
+
+	ctx, _ = th.conf.tracer.Start(ctx, "pipeline "+summary, opts...)
+	return ctx, nil
+}
+
+// AfterProcessPipeline adds an event with the status of every command to the
+// pipeline span. Commands that failed with an error replied by the server do
+// not fail the span; only an error that failed the pipeline as a whole does.
+func (th *TracingHook) AfterProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) error {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+
+	for i, cmd := range cmds {
+		attrs := []attribute.KeyValue{
+			attribute.Int("db.ssdb.cmd.index", i),
+			semconv.DBOperation(cmd.FullName()),
+			attribute.String("db.ssdb.cmd.status", ssdbcmd.CmdStatus(cmd)),
+		}
+		if err := cmd.Err(); err != nil && !errors.Is(err, ssdb.ErrNotFound) {
+			attrs = append(attrs, attribute.String("exception.message", err.Error()))
+		}
+		span.AddEvent("ssdb.cmd", trace.WithAttributes(attrs...))
+	}
+	if err := ssdbcmd.PipelineError(cmds); err != nil {
+		recordError(span, err)
+	}
+	span.End()
+	return nil
+}
+
+func (th *TracingHook) statement(cmd ssdb.Cmder) string {
+	stmt := ssdbcmd.CmdString(cmd)
+	if th.conf.redact != nil {
+		stmt = th.conf.redact(cmd, stmt)
+	}
+	return stmt
+}
+
+func (th *TracingHook) pipelineStatement(cmds []ssdb.Cmder) string {
+	b := make([]byte, 0, 32*len(cmds))
+	for i, cmd := range cmds {
+		if i > 0 {
+			b = append(b, '\n')
+		}
+		b = append(b, th.statement(cmd)...)
+	}
+	return string(b)
+}
+
+func recordError(span trace.Span, err error) {
+	if errors.Is(err, ssdb.ErrNotFound) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// peerAttrs returns the net.* attributes describing the server the client
+// connects to.
+func peerAttrs(opt *ssdb.Options) []attribute.KeyValue {
+	if opt.Network == "unix" {
+		return []attribute.KeyValue{
+			semconv.NetSockFamilyUnix,
+			semconv.NetSockPeerAddr(opt.Addr),
+		}
+	}
+
+	host, portString, err := net.SplitHostPort(opt.Addr)
+	if err != nil {
+		return []attribute.KeyValue{semconv.NetPeerName(opt.Addr)}
+	}
+
+	attrs := []attribute.KeyValue{semconv.NetPeerName(host)}
+	if port, err := strconv.Atoi(portString); err == nil {
+		attrs = append(attrs, semconv.NetPeerPort(port))
+	}
+	return attrs
+}
@@ -0,0 +1,170 @@
+package ssdbproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ssdb-go/ssdb/internal/pool"
+	"github.com/ssdb-go/ssdb/internal/proto"
+)
+
+// backend is an SSDB server the keys are sharded on.
+type backend struct {
+	// 64-bit atomics first, to be aligned on 32-bit platforms.
+	requests uint64 // atomic
+	errors   uint64 // atomic
+
+	addr string
+	opt  *Options
+	pool *pool.ConnPool
+
+	mu           sync.Mutex
+	failures     int // consecutive network failures
+	ejections    int
+	ejectedUntil time.Time
+}
+
+func newBackend(addr string, opt *Options) *backend {
+	b := &backend{addr: addr, opt: opt}
+	b.pool = pool.NewConnPool(&pool.Options{
+		Dialer: func(ctx context.Context) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, opt.DialTimeout)
+			defer cancel()
+			conn, err := opt.Dialer(ctx, "tcp", addr)
+			return conn, pool.NewNetworkError("dial", addr, err)
+		},
+		Logger: opt.Logger,
+
+		PoolSize:        opt.PoolSize,
+		PoolTimeout:     opt.PoolTimeout,
+		MinIdleConns:    opt.MinIdleConns,
+		ConnMaxIdleTime: opt.ConnMaxIdleTime,
+	})
+	return b
+}
+
+// up reports whether the backend is not ejected.
+func (b *backend) up(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.ejectedUntil)
+}
+
+// Do sends the request args to the backend and returns the blocks of the
+// reply, the status first.
+func (b *backend) Do(ctx context.Context, args []string) ([]string, error) {
+	atomic.AddUint64(&b.requests, 1)
+
+	reply, err := b.do(ctx, args)
+	if err != nil {
+		atomic.AddUint64(&b.errors, 1)
+		if isFailure(err) {
+			b.fail()
+		}
+		var netErr *pool.NetworkError
+		if !errors.As(err, &netErr) {
+			err = fmt.Errorf("ssdbproxy: backend %s: %w", b.addr, err)
+		}
+		return nil, err
+	}
+	b.succeed()
+	return reply, nil
+}
+
+// isFailure reports whether err means that the backend is unreachable, as
+// opposed to a busy pool or a canceled request.
+func isFailure(err error) bool {
+	return !errors.Is(err, pool.ErrPoolTimeout) && !errors.Is(err, pool.ErrPoolOverload) &&
+		!errors.Is(err, pool.ErrClosed) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func (b *backend) do(ctx context.Context, args []string) ([]string, error) {
+	cn, err := b.pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cn.Inited {
+		cn.Inited = true
+		if b.opt.BackendPassword != "" {
+			reply, err := b.roundTrip(ctx, cn, []string{"auth", b.opt.BackendPassword})
+			if err == nil && reply[0] != "ok" {
+				err = errors.New("ssdbproxy: auth " + b.addr + ": " + reply[0])
+			}
+			if err != nil {
+				b.pool.Remove(ctx, cn, err)
+				return nil, err
+			}
+		}
+	}
+
+	reply, err := b.roundTrip(ctx, cn, args)
+	if err != nil {
+		b.pool.Remove(ctx, cn, err)
+		return nil, err
+	}
+	b.pool.Put(ctx, cn)
+	return reply, nil
+}
+
+func (b *backend) roundTrip(ctx context.Context, cn *pool.Conn, args []string) ([]string, error) {
+	err := cn.WithWriter(ctx, b.opt.WriteTimeout, func(wr *proto.Writer) error {
+		return wr.WriteArgs(toArgs(args))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply []string
+	err = cn.WithReader(ctx, b.opt.ReadTimeout, func(rd *proto.Reader) error {
+		v, err := rd.ReadReply()
+		if err != nil {
+			return err
+		}
+		reply = v.([]string)
+		return nil
+	})
+	return reply, err
+}
+
+func (b *backend) fail() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures < b.opt.FailureLimit {
+		return
+	}
+	now := time.Now()
+	if now.Before(b.ejectedUntil) {
+		return
+	}
+	b.ejections++
+	b.ejectedUntil = now.Add(b.opt.EjectTimeout)
+	b.opt.Logger.Warn("ssdbproxy: backend ejected",
+		"addr", b.addr, "failures", b.failures, "until", b.ejectedUntil)
+}
+
+func (b *backend) succeed() {
+	b.mu.Lock()
+	b.failures = 0
+	b.mu.Unlock()
+}
+
+func (b *backend) Close() error {
+	return b.pool.Close()
+}
+
+func toArgs(args []string) []interface{} {
+	vals := make([]interface{}, len(args))
+	for i, arg := range args {
+		vals[i] = arg
+	}
+	return vals
+}
@@ -0,0 +1,250 @@
+package ssdbproxy
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+type command struct {
+	// minArgs counts the command name.
+	minArgs int
+	fn      func(ctx context.Context, p *Proxy, args []string) []string
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"ping":    {1, func(context.Context, *Proxy, []string) []string { return []string{"ok"} }},
+		"version": {1, first},
+		"dbsize":  {1, broadcastSum},
+		"flushdb": {1, broadcastSum},
+
+		"multi_get":    {2, multiKeys(1, mergePairs)},
+		"multi_exists": {2, multiKeys(1, mergePairs)},
+		"multi_del":    {2, multiKeys(1, mergeSum)},
+		"multi_set":    {3, multiKeys(2, mergeSum)},
+
+		"scan":   {4, rangeMerge(2, false)},
+		"rscan":  {4, rangeMerge(2, true)},
+		"keys":   {4, rangeMerge(1, false)},
+		"rkeys":  {4, rangeMerge(1, true)},
+		"hlist":  {4, rangeMerge(1, false)},
+		"hrlist": {4, rangeMerge(1, true)},
+		"zlist":  {4, rangeMerge(1, false)},
+		"zrlist": {4, rangeMerge(1, true)},
+		"qlist":  {4, rangeMerge(1, false)},
+		"qrlist": {4, rangeMerge(1, true)},
+	}
+
+	// The commands of a single key, hash, sorted set or queue are routed by
+	// their first argument.
+	for _, name := range []string{
+		"set", "setx", "setnx", "expire", "ttl", "get", "getset", "del", "incr",
+		"decr", "exists", "getbit", "setbit", "bitcount", "countbit", "substr",
+		"strlen",
+
+		"hset", "hget", "hdel", "hincr", "hexists", "hsize", "hkeys", "hgetall",
+		"hscan", "hrscan", "hclear", "multi_hset", "multi_hget", "multi_hdel",
+
+		"zset", "zget", "zdel", "zincr", "zexists", "zsize", "zrank", "zrrank",
+		"zrange", "zrrange", "zclear", "zcount", "zsum", "zavg", "zkeys",
+		"zscan", "zrscan", "zremrangebyrank", "zremrangebyscore", "zpop_front",
+		"zpop_back", "multi_zset", "multi_zget", "multi_zdel",
+
+		"qpush", "qpush_front", "qpush_back", "qpop", "qpop_front", "qpop_back",
+		"qfront", "qback", "qsize", "qclear", "qget", "qset", "qrange",
+		"qslice", "qtrim_front", "qtrim_back",
+	} {
+		commands[name] = command{2, keyed}
+	}
+}
+
+func errorReply(err error) []string {
+	return []string{"error", err.Error()}
+}
+
+func keyed(ctx context.Context, p *Proxy, args []string) []string {
+	live := p.live()
+	if len(live) == 0 {
+		return errorReply(ErrNoBackend)
+	}
+	b, err := p.route(live, args[1])
+	if err != nil {
+		return errorReply(err)
+	}
+	reply, err := b.Do(ctx, args)
+	if err != nil {
+		return errorReply(err)
+	}
+	return reply
+}
+
+func first(ctx context.Context, p *Proxy, args []string) []string {
+	live := p.live()
+	if len(live) == 0 {
+		return errorReply(ErrNoBackend)
+	}
+	reply, err := live[0].Do(ctx, args)
+	if err != nil {
+		return errorReply(err)
+	}
+	return reply
+}
+
+// fanout sends the requests to their backends concurrently and returns the
+// replies in the order of the requests. It returns the first reply that is
+// not ok instead, if any.
+func fanout(ctx context.Context, backends []*backend, reqs [][]string) ([][]string, []string) {
+	replies := make([][]string, len(reqs))
+	errs := make([]error, len(reqs))
+	var wg sync.WaitGroup
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			replies[i], errs[i] = backends[i].Do(ctx, reqs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, errorReply(err)
+		}
+		if replies[i][0] != "ok" {
+			return nil, replies[i]
+		}
+	}
+	return replies, nil
+}
+
+func broadcastSum(ctx context.Context, p *Proxy, args []string) []string {
+	live := p.live()
+	if len(live) == 0 {
+		return errorReply(ErrNoBackend)
+	}
+	reqs := make([][]string, len(live))
+	for i := range reqs {
+		reqs[i] = args
+	}
+	replies, errReply := fanout(ctx, live, reqs)
+	if errReply != nil {
+		return errReply
+	}
+	return mergeSum(nil, replies)
+}
+
+// multiKeys splits a kv multi_* command by backend, step being the number of
+// arguments per key, and merges the replies with merge.
+func multiKeys(step int, merge func(keys []string, replies [][]string) []string) func(ctx context.Context, p *Proxy, args []string) []string {
+	return func(ctx context.Context, p *Proxy, args []string) []string {
+		if (len(args)-1)%step != 0 {
+			return clientError("wrong number of arguments")
+		}
+		live := p.live()
+		if len(live) == 0 {
+			return errorReply(ErrNoBackend)
+		}
+
+		var backends []*backend
+		var reqs [][]string
+		index := make(map[*backend]int)
+		keys := make([]string, 0, (len(args)-1)/step)
+		for i := 1; i < len(args); i += step {
+			key := args[i]
+			keys = append(keys, key)
+			b, err := p.route(live, key)
+			if err != nil {
+				return errorReply(err)
+			}
+			j, ok := index[b]
+			if !ok {
+				j = len(reqs)
+				index[b] = j
+				backends = append(backends, b)
+				reqs = append(reqs, []string{args[0]})
+			}
+			reqs[j] = append(reqs[j], args[i:i+step]...)
+		}
+
+		replies, errReply := fanout(ctx, backends, reqs)
+		if errReply != nil {
+			return errReply
+		}
+		return merge(keys, replies)
+	}
+}
+
+// mergePairs merges the key value pairs of the replies in the order of keys.
+func mergePairs(keys []string, replies [][]string) []string {
+	vals := make(map[string]string)
+	for _, reply := range replies {
+		for i := 1; i+1 < len(reply); i += 2 {
+			vals[reply[i]] = reply[i+1]
+		}
+	}
+	merged := []string{"ok"}
+	for _, key := range keys {
+		if val, ok := vals[key]; ok {
+			merged = append(merged, key, val)
+		}
+	}
+	return merged
+}
+
+// mergeSum sums the integers replied.
+func mergeSum(_ []string, replies [][]string) []string {
+	var sum int64
+	for _, reply := range replies {
+		if len(reply) > 1 {
+			n, _ := strconv.ParseInt(reply[1], 10, 64)
+			sum += n
+		}
+	}
+	return []string{"ok", strconv.FormatInt(sum, 10)}
+}
+
+// rangeMerge sends a range command, which ends with a limit, to every backend
+// and merges the sorted replies. step is the number of blocks per key.
+func rangeMerge(step int, reverse bool) func(ctx context.Context, p *Proxy, args []string) []string {
+	return func(ctx context.Context, p *Proxy, args []string) []string {
+		live := p.live()
+		if len(live) == 0 {
+			return errorReply(ErrNoBackend)
+		}
+		reqs := make([][]string, len(live))
+		for i := range reqs {
+			reqs[i] = args
+		}
+		replies, errReply := fanout(ctx, live, reqs)
+		if errReply != nil {
+			return errReply
+		}
+
+		var groups [][]string
+		for _, reply := range replies {
+			for i := 1; i+step <= len(reply); i += step {
+				groups = append(groups, reply[i:i+step])
+			}
+		}
+		sort.SliceStable(groups, func(i, j int) bool {
+			if reverse {
+				return groups[i][0] > groups[j][0]
+			}
+			return groups[i][0] < groups[j][0]
+		})
+		if limit, err := strconv.Atoi(args[len(args)-1]); err == nil && limit >= 0 && limit < len(groups) {
+			groups = groups[:limit]
+		}
+
+		merged := make([]string, 1, 1+step*len(groups))
+		merged[0] = "ok"
+		for _, g := range groups {
+			merged = append(merged, g...)
+		}
+		return merged
+	}
+}
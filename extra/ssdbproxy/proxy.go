@@ -0,0 +1,337 @@
+// Package ssdbproxy implements a proxy that speaks the SSDB protocol to the
+// clients and shards their keys on several SSDB servers, the backends.
+//
+// A key is routed to a backend by the hash slot of internal/hashtag, so the
+// keys that share a {hash tag} are on the same backend. The hashes, sorted
+// sets and queues are routed by name. The kv multi_* commands are split by
+// backend and their replies merged, and the range commands, e.g. scan and
+// hlist, are sent to every backend and their replies merged in order.
+//
+// The connections to each backend are pooled. A backend that fails
+// Options.FailureLimit times in a row is ejected for Options.EjectTimeout:
+// the commands on its keys fail with ErrBackendEjected meanwhile, and the
+// keys of the other backends stay where they are. With
+// Options.RerouteEjected its keys are routed to the other backends instead,
+// as twemproxy does with auto_eject_hosts.
+package ssdbproxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ssdb-go/ssdb/internal"
+	"github.com/ssdb-go/ssdb/internal/hashtag"
+	"github.com/ssdb-go/ssdb/internal/proto"
+)
+
+// Options configure a Proxy.
+type Options struct {
+	// Backends are the addresses of the SSDB servers.
+	Backends []string
+	// Dialer dials the backends. Defaults to a net.Dialer.
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+	// BackendPassword is sent with auth on the new backend connections.
+	BackendPassword string
+	// Password, if set, must be sent with auth by the clients before any
+	// other command.
+	Password string
+
+	// PoolSize is the maximum number of connections per backend.
+	// Defaults to 10.
+	PoolSize int
+	// PoolTimeout is the time to wait for a backend connection.
+	// Defaults to ReadTimeout + 1 second.
+	PoolTimeout time.Duration
+	// MinIdleConns is the number of idle connections kept per backend.
+	MinIdleConns int
+	// ConnMaxIdleTime is the time after which an idle backend connection is
+	// closed. Defaults to 30 minutes.
+	ConnMaxIdleTime time.Duration
+
+	// DialTimeout defaults to 5 seconds.
+	DialTimeout time.Duration
+	// ReadTimeout and WriteTimeout of the backend requests default to 3
+	// seconds.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// FailureLimit is the number of network failures in a row after which
+	// a backend is ejected. Defaults to 3.
+	FailureLimit int
+	// EjectTimeout is the time a backend is ejected for. Defaults to 30
+	// seconds.
+	EjectTimeout time.Duration
+	// RerouteEjected routes the keys of an ejected backend to the other
+	// backends instead of failing the commands on them. The proxy then acts
+	// as a cache: the keys written meanwhile are lost when the backend comes
+	// back, and the keys it had are missing while it is ejected.
+	RerouteEjected bool
+
+	// Logger defaults to the logger of the ssdb package.
+	Logger *slog.Logger
+}
+
+func (opt *Options) init() {
+	if opt.Dialer == nil {
+		opt.Dialer = (&net.Dialer{KeepAlive: 5 * time.Minute}).DialContext
+	}
+	if opt.PoolSize <= 0 {
+		opt.PoolSize = 10
+	}
+	if opt.DialTimeout <= 0 {
+		opt.DialTimeout = 5 * time.Second
+	}
+	if opt.ReadTimeout <= 0 {
+		opt.ReadTimeout = 3 * time.Second
+	}
+	if opt.WriteTimeout <= 0 {
+		opt.WriteTimeout = opt.ReadTimeout
+	}
+	if opt.PoolTimeout <= 0 {
+		opt.PoolTimeout = opt.ReadTimeout + time.Second
+	}
+	if opt.ConnMaxIdleTime <= 0 {
+		opt.ConnMaxIdleTime = 30 * time.Minute
+	}
+	if opt.FailureLimit <= 0 {
+		opt.FailureLimit = 3
+	}
+	if opt.EjectTimeout <= 0 {
+		opt.EjectTimeout = 30 * time.Second
+	}
+	if opt.Logger == nil {
+		opt.Logger = internal.DefaultLogger
+	}
+}
+
+// ErrNoBackend is replied when every backend is ejected.
+var ErrNoBackend = errors.New("ssdbproxy: no backend available")
+
+// ErrBackendEjected is replied to the commands on the keys of an ejected
+// backend, unless Options.RerouteEjected is set.
+var ErrBackendEjected = errors.New("ssdbproxy: backend of the key ejected")
+
+// Proxy serves SSDB protocol connections.
+type Proxy struct {
+	// 64-bit atomics first, to be aligned on 32-bit platforms.
+	commands uint64 // atomic
+	accepted uint64 // atomic
+
+	opt      *Options
+	backends []*backend
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewProxy returns a proxy in front of opt.Backends.
+func NewProxy(opt *Options) (*Proxy, error) {
+	if len(opt.Backends) == 0 {
+		return nil, errors.New("ssdbproxy: no backends")
+	}
+	o := *opt
+	o.init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Proxy{
+		opt:    &o,
+		ctx:    ctx,
+		cancel: cancel,
+		conns:  make(map[net.Conn]struct{}),
+	}
+	for _, addr := range o.Backends {
+		p.backends = append(p.backends, newBackend(addr, &o))
+	}
+	return p, nil
+}
+
+// Serve accepts the connections of l and serves them until l or the proxy
+// is closed.
+func (p *Proxy) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go p.ServeConn(conn)
+	}
+}
+
+// ServeConn executes the commands read from conn until it is closed.
+func (p *Proxy) ServeConn(conn net.Conn) {
+	if !p.track(conn) {
+		conn.Close()
+		return
+	}
+	defer p.untrack(conn)
+	defer conn.Close()
+	atomic.AddUint64(&p.accepted, 1)
+
+	rd := newRequestReader(conn)
+	bw := bufio.NewWriter(conn)
+	wr := proto.NewWriter(bw)
+	authed := p.opt.Password == ""
+	for {
+		args, err := rd.ReadRequest()
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				// The rest of the stream can't be parsed.
+				_ = wr.WriteArgs(toArgs(clientError(err.Error())))
+				_ = bw.Flush()
+			}
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				p.opt.Logger.Debug("ssdbproxy: read failed", "remote", conn.RemoteAddr().String(), "error", err)
+			}
+			return
+		}
+		atomic.AddUint64(&p.commands, 1)
+
+		var reply []string
+		switch {
+		case toLower(args[0]) == "auth":
+			reply = p.auth(args)
+			authed = authed || reply[0] == "ok"
+		case !authed:
+			reply = []string{"noauth", "authentication required"}
+		default:
+			reply = p.Do(p.ctx, args)
+		}
+
+		if err := wr.WriteArgs(toArgs(reply)); err != nil {
+			return
+		}
+		if rd.Buffered() > 0 {
+			continue // pipelined request
+		}
+		if err := bw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (p *Proxy) auth(args []string) []string {
+	switch {
+	case len(args) != 2:
+		return clientError("wrong number of arguments")
+	case p.opt.Password == "":
+		return []string{"ok", "1"}
+	case args[1] != p.opt.Password:
+		return []string{"error", "invalid password"}
+	}
+	return []string{"ok", "1"}
+}
+
+// Do executes the request args on the backends and returns the blocks of the
+// reply, the status first.
+func (p *Proxy) Do(ctx context.Context, args []string) []string {
+	if len(args) == 0 {
+		return clientError("empty command")
+	}
+	cmd, ok := commands[toLower(args[0])]
+	if !ok {
+		return clientError("command not supported by the proxy: " + args[0])
+	}
+	if len(args) < cmd.minArgs {
+		return clientError("wrong number of arguments")
+	}
+	return cmd.fn(ctx, p, args)
+}
+
+// live returns the backends that are not ejected.
+func (p *Proxy) live() []*backend {
+	now := time.Now()
+	live := make([]*backend, 0, len(p.backends))
+	for _, b := range p.backends {
+		if b.up(now) {
+			live = append(live, b)
+		}
+	}
+	return live
+}
+
+// route returns the backend of key: its home backend, picked by slot among
+// all the backends, if it is live. Otherwise it returns ErrBackendEjected,
+// or one of the live backends with Options.RerouteEjected. Ejecting a
+// backend only moves its own keys.
+func (p *Proxy) route(live []*backend, key string) (*backend, error) {
+	slot := hashtag.Slot(key)
+	home := p.backends[slot%len(p.backends)]
+	for _, b := range live {
+		if b == home {
+			return home, nil
+		}
+	}
+	if !p.opt.RerouteEjected {
+		return nil, ErrBackendEjected
+	}
+	return live[slot%len(live)], nil
+}
+
+// Close closes the connections being served and the backend connections.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	p.cancel()
+	p.wg.Wait()
+
+	var firstErr error
+	for _, b := range p.backends {
+		if err := b.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (p *Proxy) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	p.wg.Add(1)
+	return true
+}
+
+func (p *Proxy) untrack(conn net.Conn) {
+	p.mu.Lock()
+	delete(p.conns, conn)
+	p.mu.Unlock()
+	p.wg.Done()
+}
+
+func clientError(msg string) []string {
+	return []string{"client_error", msg}
+}
+
+func toLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,396 @@
+package ssdbproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/hashtag"
+	"github.com/ssdb-go/ssdb/internal/proto"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+var ctx = context.Background()
+
+var addrs = []string{"backend0:8888", "backend1:8888", "backend2:8888"}
+
+type cluster struct {
+	servers map[string]*ssdbtest.Server
+	proxy   *Proxy
+	client  *ssdb.Client
+
+	mu   sync.Mutex
+	down map[string]bool
+}
+
+func newCluster(t *testing.T, opt *Options) *cluster {
+	c := &cluster{
+		servers: make(map[string]*ssdbtest.Server),
+		down:    make(map[string]bool),
+	}
+	for _, addr := range addrs {
+		c.servers[addr] = ssdbtest.NewServer()
+	}
+
+	if opt == nil {
+		opt = &Options{}
+	}
+	opt.Backends = addrs
+	opt.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	opt.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		c.mu.Lock()
+		down := c.down[addr]
+		c.mu.Unlock()
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return c.servers[addr].Dialer()(ctx, network, addr)
+	}
+	p, err := NewProxy(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.proxy = p
+
+	c.client = ssdb.NewClient(&ssdb.Options{
+		Addr: "proxy:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go p.ServeConn(server)
+			return client, nil
+		},
+		Password: opt.Password,
+	})
+
+	t.Cleanup(func() {
+		c.client.Close()
+		p.Close()
+		for _, srv := range c.servers {
+			srv.Close()
+		}
+	})
+	return c
+}
+
+// kill makes the dials to the backend fail and closes its connections.
+func (c *cluster) kill(addr string) {
+	c.mu.Lock()
+	c.down[addr] = true
+	c.mu.Unlock()
+	c.servers[addr].Close()
+	c.servers[addr] = ssdbtest.NewServer()
+}
+
+func (c *cluster) revive(addr string) {
+	c.mu.Lock()
+	c.down[addr] = false
+	c.mu.Unlock()
+}
+
+func (c *cluster) do(t *testing.T, args ...interface{}) []string {
+	t.Helper()
+	cmd := c.client.Do(ctx, args...)
+	if err := cmd.Err(); err != nil && err != ssdb.Nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	vals, _ := cmd.Val().([]string)
+	return vals
+}
+
+func TestProxyRouting(t *testing.T) {
+	c := newCluster(t, nil)
+
+	var pairs []interface{}
+	for i := 0; i < 20; i++ {
+		key := "key" + strconv.Itoa(i)
+		pairs = append(pairs, key, strconv.Itoa(i))
+	}
+	if got := c.do(t, append([]interface{}{"multi_set"}, pairs...)...); got[0] != "20" {
+		t.Fatalf("got %q, wanted 20 keys set", got)
+	}
+
+	// Each key is on the backend of its slot only.
+	used := make(map[string]bool)
+	for i := 0; i < len(pairs); i += 2 {
+		key := pairs[i].(string)
+		addr := addrs[hashtag.Slot(key)%len(addrs)]
+		used[addr] = true
+		for _, a := range addrs {
+			reply := c.servers[a].Do("get", key)
+			if found := reply[0] == "ok"; found != (a == addr) {
+				t.Fatalf("%s on %s: got %q", key, a, reply)
+			}
+		}
+	}
+	if len(used) != len(addrs) {
+		t.Fatalf("got the keys on %d backends, wanted %d", len(used), len(addrs))
+	}
+
+	got := c.do(t, "multi_get", "key3", "missing", "key1", "key15")
+	if wanted := []string{"key3", "3", "key1", "1", "key15", "15"}; !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+	if got := c.do(t, "get", "key7"); !reflect.DeepEqual(got, []string{"7"}) {
+		t.Fatalf("got %q", got)
+	}
+	var size int
+	for _, a := range addrs {
+		n, _ := strconv.Atoi(c.servers[a].Do("dbsize")[1])
+		size += n
+	}
+	if got := c.do(t, "dbsize"); got[0] != strconv.Itoa(size) {
+		t.Fatalf("got %q, wanted %d", got, size)
+	}
+
+	// The range commands are merged in order across the backends.
+	got = c.do(t, "keys", "key1", "", 4)
+	if wanted := []string{"key10", "key11", "key12", "key13"}; !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+	got = c.do(t, "rscan", "", "key17", 3)
+	if wanted := []string{"key9", "9", "key8", "8", "key7", "7"}; !reflect.DeepEqual(got, wanted) {
+		t.Fatalf("got %q, wanted %q", got, wanted)
+	}
+
+	// The keys of a hash tag share a backend.
+	c.do(t, "multi_set", "{user1}.name", "a", "{user1}.mail", "b")
+	for _, srv := range c.servers {
+		if reply := srv.Do("multi_get", "{user1}.name", "{user1}.mail"); len(reply) != 1 && len(reply) != 5 {
+			t.Fatalf("got %q, wanted both keys or none", reply)
+		}
+	}
+
+	// Collections are routed by name.
+	c.do(t, "multi_hset", "h", "a", "1", "b", "2")
+	if got := c.do(t, "hgetall", "h"); !reflect.DeepEqual(got, []string{"a", "1", "b", "2"}) {
+		t.Fatalf("got %q", got)
+	}
+	if got := c.do(t, "hlist", "", "", -1); !reflect.DeepEqual(got, []string{"h"}) {
+		t.Fatalf("got %q", got)
+	}
+
+	if got := c.do(t, "multi_del", "key1", "key2", "key3"); got[0] != "3" {
+		t.Fatalf("got %q, wanted 3 keys deleted", got)
+	}
+	if err := c.client.Do(ctx, "info").Err(); err == nil {
+		t.Fatal("got nil, wanted an error for an unsupported command")
+	}
+
+	stats := c.proxy.Stats()
+	if stats.Commands == 0 || len(stats.Backends) != 3 || stats.Backends[0].Requests == 0 {
+		t.Fatalf("got %+v", stats)
+	}
+}
+
+// ejectBackend writes a key on each backend, kills backends[1] and fails
+// the commands until it is ejected. It returns the address of the ejected
+// backend and the key written on each backend.
+func ejectBackend(t *testing.T, c *cluster) (string, map[string]string) {
+	t.Helper()
+	keys := make(map[string]string)
+	for i := 0; len(keys) < len(addrs); i++ {
+		key := "key" + strconv.Itoa(i)
+		addr := addrs[hashtag.Slot(key)%len(addrs)]
+		if _, ok := keys[addr]; !ok {
+			keys[addr] = key
+		}
+	}
+	for _, key := range keys {
+		c.do(t, "set", key, "v")
+	}
+
+	dead := addrs[1]
+	c.kill(dead)
+	for i := 0; i < 2; i++ {
+		if err := c.client.Do(ctx, "get", keys[dead]).Err(); err == nil {
+			t.Fatal("got nil, wanted the error of the dead backend")
+		}
+	}
+
+	stats := c.proxy.Stats().Backends[1]
+	if stats.Up || stats.Ejections != 1 || stats.Failures != 2 {
+		t.Fatalf("got %+v, wanted the backend ejected", stats)
+	}
+	return dead, keys
+}
+
+func TestProxyEject(t *testing.T) {
+	c := newCluster(t, &Options{
+		FailureLimit: 2,
+		EjectTimeout: 100 * time.Millisecond,
+	})
+	dead, keys := ejectBackend(t, c)
+
+	// The keys of the live backends stay on them.
+	for i := 0; i < 30; i++ {
+		key := "key" + strconv.Itoa(i)
+		home := addrs[hashtag.Slot(key)%len(addrs)]
+		if home == dead {
+			continue
+		}
+		if got := c.do(t, "set", key, "v"+strconv.Itoa(i)); got[0] != "1" {
+			t.Fatalf("got %q", got)
+		}
+		for _, a := range addrs {
+			if a == dead {
+				continue
+			}
+			reply := c.servers[a].Do("get", key)
+			if found := reply[0] == "ok"; found != (a == home) {
+				t.Fatalf("%s on %s: got %q, wanted it on %s only", key, a, reply, home)
+			}
+		}
+	}
+	for addr, key := range keys {
+		if addr == dead {
+			continue
+		}
+		if got := c.do(t, "exists", key); got[0] != "1" {
+			t.Fatalf("%s: got %q, wanted the key of %s still found", key, got, addr)
+		}
+	}
+
+	// The commands on the keys of the ejected backend fail.
+	before := c.do(t, "get", keys[addrs[0]])
+	for _, args := range [][]interface{}{
+		{"set", keys[dead], "v2"},
+		{"get", keys[dead]},
+		{"multi_set", keys[addrs[0]], "v2", keys[dead], "v2"},
+	} {
+		err := c.client.Do(ctx, args...).Err()
+		var serverErr *ssdb.ServerError
+		if !errors.As(err, &serverErr) || serverErr.Message != ErrBackendEjected.Error() {
+			t.Fatalf("%v: got %v, wanted %v", args, err, ErrBackendEjected)
+		}
+	}
+	if got := c.do(t, "get", keys[addrs[0]]); got[0] != before[0] {
+		t.Fatalf("got %q, wanted %q: the failed multi_set not applied", got, before)
+	}
+
+	// The backend is used again once it is back and the ejection is over.
+	c.revive(dead)
+	time.Sleep(150 * time.Millisecond)
+	if got := c.do(t, "get", keys[dead]); len(got) != 0 {
+		t.Fatalf("got %q, wanted the key of the restarted backend missing", got)
+	}
+	if stats := c.proxy.Stats().Backends[1]; !stats.Up || stats.Failures != 0 {
+		t.Fatalf("got %+v, wanted the backend up", stats)
+	}
+}
+
+func TestProxyRerouteEjected(t *testing.T) {
+	c := newCluster(t, &Options{
+		FailureLimit:   2,
+		EjectTimeout:   100 * time.Millisecond,
+		RerouteEjected: true,
+	})
+	dead, keys := ejectBackend(t, c)
+
+	// The keys of the ejected backend are routed to the others.
+	if got := c.do(t, "set", keys[dead], "v2"); got[0] != "1" {
+		t.Fatalf("got %q", got)
+	}
+	if got := c.do(t, "get", keys[dead]); got[0] != "v2" {
+		t.Fatalf("got %q", got)
+	}
+	if got := c.do(t, "dbsize"); got[0] == "" {
+		t.Fatalf("got %q", got)
+	}
+
+	// The keys written meanwhile are lost when the backend is back.
+	c.revive(dead)
+	time.Sleep(150 * time.Millisecond)
+	if got := c.do(t, "get", keys[dead]); len(got) != 0 {
+		t.Fatalf("got %q, wanted the key of the restarted backend missing", got)
+	}
+}
+
+func TestProxyAuth(t *testing.T) {
+	c := newCluster(t, &Options{Password: "secret"})
+	if got := c.do(t, "ping"); len(got) != 0 {
+		t.Fatalf("got %q", got)
+	}
+
+	wrong := ssdb.NewClient(&ssdb.Options{
+		Addr: "proxy:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go c.proxy.ServeConn(server)
+			return client, nil
+		},
+		Password: "wrong",
+	})
+	defer wrong.Close()
+	if err := wrong.Do(ctx, "get", "a").Err(); err == nil {
+		t.Fatal("got nil, wanted an authentication error")
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	c := newCluster(t, nil)
+	c.do(t, "set", "a", "1")
+
+	w := httptest.NewRecorder()
+	c.proxy.StatsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/stats", nil))
+	var stats Stats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Conns != 1 || stats.Commands != 1 || len(stats.Backends) != 3 {
+		t.Fatalf("got %+v", stats)
+	}
+	var requests uint64
+	for _, b := range stats.Backends {
+		requests += b.Requests
+		if !b.Up || b.Addr == "" {
+			t.Fatalf("got %+v", b)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("got %d backend requests, wanted 1", requests)
+	}
+}
+
+func TestProxyOversizedRequest(t *testing.T) {
+	c := newCluster(t, nil)
+
+	for _, req := range []string{
+		"9223372036854775807\n",
+		strconv.Itoa(maxBlockLen+1) + "\n",
+		"-1\n",
+	} {
+		client, server := net.Pipe()
+		go c.proxy.ServeConn(server)
+
+		go func() {
+			_, _ = client.Write([]byte(req))
+		}()
+		reply, err := proto.NewReader(client).ReadReply()
+		if err != nil {
+			t.Fatalf("%q: %v", req, err)
+		}
+		if got := reply.([]string); got[0] != "client_error" {
+			t.Fatalf("%q: got %q, wanted a client_error", req, got)
+		}
+		// The connection is closed.
+		if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+			t.Fatalf("%q: got %v, wanted io.EOF", req, err)
+		}
+		client.Close()
+	}
+
+	// The proxy still serves the other clients.
+	if got := c.do(t, "ping"); len(got) != 0 {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package ssdbproxy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The limits of the requests read from the clients. proto.Reader trusts the
+// lengths it reads, which only the replies of a server can be trusted for.
+const (
+	maxBlockLen   = 512 << 20
+	maxBlockCount = 1 << 20
+)
+
+var errProtocol = errors.New("ssdbproxy: protocol error")
+
+type requestReader struct {
+	rd *bufio.Reader
+}
+
+func newRequestReader(rd io.Reader) *requestReader {
+	return &requestReader{rd: bufio.NewReader(rd)}
+}
+
+func (r *requestReader) Buffered() int {
+	return r.rd.Buffered()
+}
+
+// ReadRequest reads the blocks of a request up to the empty line that ends
+// it. The blocks are read as their bytes arrive, so a client has to send the
+// bytes of the lengths it claims.
+func (r *requestReader) ReadRequest() ([]string, error) {
+	var args []string
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%w: empty request", errProtocol)
+			}
+			return args, nil
+		}
+		if len(args) == maxBlockCount {
+			return nil, fmt.Errorf("%w: more than %d blocks", errProtocol, maxBlockCount)
+		}
+
+		n, err := strconv.Atoi(string(line))
+		if err != nil || n < 0 || n > maxBlockLen {
+			return nil, fmt.Errorf("%w: invalid block length %.32q", errProtocol, line)
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r.rd, int64(n)+1); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		block := buf.Bytes()
+		if block[n] != '\n' {
+			return nil, fmt.Errorf("%w: block is not followed by a newline", errProtocol)
+		}
+		args = append(args, string(block[:n]))
+	}
+}
+
+func (r *requestReader) readLine() ([]byte, error) {
+	line, err := r.rd.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return nil, fmt.Errorf("%w: line too long", errProtocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line[:len(line)-1], []byte{'\r'}), nil
+}
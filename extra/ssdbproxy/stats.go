@@ -0,0 +1,88 @@
+package ssdbproxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the counters of a proxy.
+type Stats struct {
+	// Conns is the number of client connections being served.
+	Conns int `json:"conns"`
+	// Accepted is the number of client connections served since the start.
+	Accepted uint64 `json:"accepted"`
+	// Commands is the number of commands read from the clients.
+	Commands uint64         `json:"commands"`
+	Backends []BackendStats `json:"backends"`
+}
+
+// BackendStats are the counters of a backend.
+type BackendStats struct {
+	Addr string `json:"addr"`
+	// Up is false while the backend is ejected.
+	Up bool `json:"up"`
+	// EjectedUntil is the end of the last ejection.
+	EjectedUntil time.Time `json:"ejected_until"`
+	// Failures is the number of network failures in a row.
+	Failures  int `json:"failures"`
+	Ejections int `json:"ejections"`
+
+	// Requests is the number of requests sent, Errors the number of them
+	// that failed without a reply.
+	Requests uint64 `json:"requests"`
+	Errors   uint64 `json:"errors"`
+
+	// The stats of the connection pool.
+	TotalConns uint32 `json:"total_conns"`
+	IdleConns  uint32 `json:"idle_conns"`
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+}
+
+// Stats returns the counters of the proxy.
+func (p *Proxy) Stats() *Stats {
+	p.mu.Lock()
+	conns := len(p.conns)
+	p.mu.Unlock()
+
+	stats := &Stats{
+		Conns:    conns,
+		Accepted: atomic.LoadUint64(&p.accepted),
+		Commands: atomic.LoadUint64(&p.commands),
+		Backends: make([]BackendStats, len(p.backends)),
+	}
+	now := time.Now()
+	for i, b := range p.backends {
+		ps := b.pool.Stats()
+		b.mu.Lock()
+		stats.Backends[i] = BackendStats{
+			Addr:         b.addr,
+			Up:           !now.Before(b.ejectedUntil),
+			EjectedUntil: b.ejectedUntil,
+			Failures:     b.failures,
+			Ejections:    b.ejections,
+			Requests:     atomic.LoadUint64(&b.requests),
+			Errors:       atomic.LoadUint64(&b.errors),
+			TotalConns:   ps.TotalConns,
+			IdleConns:    ps.IdleConns,
+			Hits:         ps.Hits,
+			Misses:       ps.Misses,
+			Timeouts:     ps.Timeouts,
+		}
+		b.mu.Unlock()
+	}
+	return stats
+}
+
+// StatsHandler returns an HTTP handler that serves the Stats as JSON.
+func (p *Proxy) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(p.Stats())
+	})
+}
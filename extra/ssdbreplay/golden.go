@@ -0,0 +1,97 @@
+package ssdbreplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// Golden is the traffic of the connections of a Recorder.
+type Golden struct {
+	Conns []Conn `json:"conns"`
+}
+
+// Conn is the traffic of a connection, in the order it was dialed.
+type Conn struct {
+	Network   string     `json:"network"`
+	Addr      string     `json:"addr"`
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// Exchange is the bytes written by the client until it read a reply and the
+// bytes it read until it wrote again. Pipelined requests and their replies
+// are a single exchange.
+type Exchange struct {
+	Request Bytes `json:"request"`
+	Reply   Bytes `json:"reply"`
+}
+
+// Bytes is binary data. It is encoded as a JSON string if it is valid UTF-8
+// and as an object holding the base64 encoded data otherwise.
+type Bytes string
+
+// base64Bytes is the encoding of Bytes that are not valid UTF-8; encoding/json
+// encodes []byte in base64.
+type base64Bytes struct {
+	Base64 []byte `json:"base64"`
+}
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if utf8.ValidString(string(b)) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(base64Bytes{Base64: []byte(b)})
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		var v base64Bytes
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*b = Bytes(v.Base64)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*b = Bytes(s)
+	return nil
+}
+
+// LoadGolden reads a golden file written by Save.
+func LoadGolden(path string) (*Golden, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	g := new(Golden)
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Save writes g to the file path, creating its directory if needed. The file
+// is replaced atomically.
+func (g *Golden) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(g); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
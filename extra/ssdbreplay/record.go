@@ -0,0 +1,104 @@
+package ssdbreplay
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// Dialer dials a connection, as ssdb.Options.Dialer.
+type Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Recorder records the traffic of the connections of a Dialer.
+type Recorder struct {
+	dial Dialer
+
+	mu    sync.Mutex
+	conns []*recordConn
+}
+
+// NewRecorder returns a Recorder of the connections dialed with dial, which
+// defaults to a net.Dialer.
+func NewRecorder(dial Dialer) *Recorder {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return &Recorder{dial: dial}
+}
+
+// Dialer returns the Dialer that records its connections, to be set as
+// ssdb.Options.Dialer.
+func (r *Recorder) Dialer() Dialer {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := r.dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		rc := &recordConn{
+			Conn: conn,
+			mu:   &r.mu,
+			conn: Conn{Network: network, Addr: addr},
+		}
+		r.mu.Lock()
+		r.conns = append(r.conns, rc)
+		r.mu.Unlock()
+		return rc, nil
+	}
+}
+
+// Golden returns the traffic recorded so far.
+func (r *Recorder) Golden() *Golden {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g := &Golden{Conns: make([]Conn, len(r.conns))}
+	for i, rc := range r.conns {
+		g.Conns[i] = rc.conn
+		g.Conns[i].Exchanges = append([]Exchange(nil), rc.conn.Exchanges...)
+	}
+	return g
+}
+
+// Save writes the traffic recorded so far to the golden file path.
+func (r *Recorder) Save(path string) error {
+	return r.Golden().Save(path)
+}
+
+// recordConn appends the bytes written and read to its exchanges.
+type recordConn struct {
+	net.Conn
+
+	mu      *sync.Mutex // of the Recorder
+	conn    Conn
+	reading bool // the last exchange has reply bytes
+}
+
+func (c *recordConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.mu.Lock()
+		if len(c.conn.Exchanges) == 0 || c.reading {
+			c.conn.Exchanges = append(c.conn.Exchanges, Exchange{})
+			c.reading = false
+		}
+		ex := &c.conn.Exchanges[len(c.conn.Exchanges)-1]
+		ex.Request += Bytes(b[:n])
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *recordConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.mu.Lock()
+		if len(c.conn.Exchanges) == 0 {
+			c.conn.Exchanges = append(c.conn.Exchanges, Exchange{})
+		}
+		ex := &c.conn.Exchanges[len(c.conn.Exchanges)-1]
+		ex.Reply += Bytes(b[:n])
+		c.reading = true
+		c.mu.Unlock()
+	}
+	return n, err
+}
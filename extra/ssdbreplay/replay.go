@@ -0,0 +1,228 @@
+// Package ssdbreplay records the traffic of SSDB connections to a golden file
+// and replays it without a server.
+//
+// A Recorder wraps the Dialer of a client talking to a real server and
+// records the request and reply bytes of each connection. A Replayer serves
+// the connections of a golden file over net.Pipe, in the order they were
+// dialed: it reads the requests of each exchange, checks that they match the
+// recorded ones and writes the recorded reply bytes as is. A change in the
+// way the client writes requests or reads replies is then caught by tests
+// that run without a server:
+//
+//	rec := ssdbreplay.NewRecorder(nil)
+//	rdb := ssdb.NewClient(&ssdb.Options{Addr: addr, Dialer: rec.Dialer()})
+//	// ...
+//	rec.Save("testdata/session.json")
+//
+//	g, _ := ssdbreplay.LoadGolden("testdata/session.json")
+//	rep := ssdbreplay.NewReplayer(g, ssdbreplay.Strict)
+//	rdb := ssdb.NewClient(&ssdb.Options{Addr: addr, Dialer: rep.Dialer()})
+//
+// The client must dial the connections in the order they were recorded, so
+// the replayed clients should not have idle connections dialed in the
+// background, e.g. with MinIdleConns.
+package ssdbreplay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/ssdb-go/ssdb/internal/proto"
+)
+
+// Mode is how a Replayer matches the requests with the recorded ones.
+type Mode int
+
+const (
+	// Strict requires the requests to be the recorded ones.
+	Strict Mode = iota
+	// Lenient only requires the requests to have the command names of the
+	// recorded ones, e.g. for arguments that hold the time.
+	Lenient
+)
+
+// Replayer serves the recorded connections of a golden file.
+type Replayer struct {
+	golden *Golden
+	mode   Mode
+
+	mu       sync.Mutex
+	next     int // the next recorded connection to dial
+	replayed int // the exchanges replayed
+	errs     []error
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewReplayer returns a Replayer of the connections of g.
+func NewReplayer(g *Golden, mode Mode) *Replayer {
+	return &Replayer{
+		golden: g,
+		mode:   mode,
+		conns:  make(map[net.Conn]struct{}),
+	}
+}
+
+// Dialer returns the Dialer that serves the recorded connections, to be set
+// as ssdb.Options.Dialer. It fails once they have all been dialed.
+func (r *Replayer) Dialer() Dialer {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.next == len(r.golden.Conns) {
+			err := fmt.Errorf("ssdbreplay: dial %s: the %d recorded connections were dialed",
+				addr, len(r.golden.Conns))
+			r.errs = append(r.errs, err)
+			return nil, err
+		}
+		i := r.next
+		r.next++
+
+		client, server := net.Pipe()
+		r.conns[server] = struct{}{}
+		r.wg.Add(1)
+		go r.serve(server, i)
+		return client, nil
+	}
+}
+
+func (r *Replayer) serve(conn net.Conn, i int) {
+	defer r.wg.Done()
+	defer func() {
+		r.mu.Lock()
+		delete(r.conns, conn)
+		r.mu.Unlock()
+	}()
+	defer conn.Close()
+
+	if err := r.replay(conn, &r.golden.Conns[i]); err != nil {
+		r.mu.Lock()
+		r.errs = append(r.errs, fmt.Errorf("ssdbreplay: conn %d: %w", i, err))
+		r.mu.Unlock()
+	}
+}
+
+func (r *Replayer) replay(conn net.Conn, rc *Conn) error {
+	rd := proto.NewReader(conn)
+	for j, ex := range rc.Exchanges {
+		recorded, err := parseRequests(ex.Request)
+		if err != nil {
+			return fmt.Errorf("exchange %d: invalid recorded request: %w", j, err)
+		}
+		for _, want := range recorded {
+			v, err := rd.ReadReply()
+			if err != nil {
+				if isClosed(err) {
+					return nil // the client is gone before the end of the recording
+				}
+				return fmt.Errorf("exchange %d: %w", j, err)
+			}
+			if err := r.match(want, v.([]string)); err != nil {
+				return fmt.Errorf("exchange %d: %w", j, err)
+			}
+		}
+
+		if _, err := io.WriteString(conn, string(ex.Reply)); err != nil {
+			if isClosed(err) {
+				return nil
+			}
+			return fmt.Errorf("exchange %d: %w", j, err)
+		}
+		r.mu.Lock()
+		r.replayed++
+		r.mu.Unlock()
+	}
+
+	v, err := rd.ReadReply()
+	if err != nil {
+		if isClosed(err) {
+			return nil
+		}
+		return err
+	}
+	return fmt.Errorf("unexpected request %s after the recorded ones", formatArgs(v.([]string)))
+}
+
+func (r *Replayer) match(want, got []string) error {
+	switch r.mode {
+	case Lenient:
+		if len(got) == 0 || !strings.EqualFold(got[0], want[0]) {
+			return fmt.Errorf("got request %s, wanted %s", formatArgs(got), want[0])
+		}
+	default:
+		if formatArgs(got) != formatArgs(want) {
+			return fmt.Errorf("got request %s, wanted %s", formatArgs(got), formatArgs(want))
+		}
+	}
+	return nil
+}
+
+// Err returns the mismatches and the unexpected requests and dials seen so
+// far, nil if there were none.
+func (r *Replayer) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.errs...)
+}
+
+// Done reports whether every recorded exchange was replayed.
+func (r *Replayer) Done() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int
+	for _, c := range r.golden.Conns {
+		n += len(c.Exchanges)
+	}
+	return r.replayed == n
+}
+
+// Close closes the served connections and waits for them to end.
+func (r *Replayer) Close() error {
+	r.mu.Lock()
+	for conn := range r.conns {
+		conn.Close()
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return nil
+}
+
+// parseRequests returns the requests of a recorded exchange.
+func parseRequests(b Bytes) ([][]string, error) {
+	rd := proto.NewReader(strings.NewReader(string(b)))
+	var reqs [][]string
+	for {
+		v, err := rd.ReadReply()
+		if err == io.EOF {
+			return reqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, v.([]string))
+	}
+}
+
+func isClosed(err error) bool {
+	return err == io.EOF || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed)
+}
+
+func formatArgs(args []string) string {
+	var buf bytes.Buffer
+	for i, arg := range args {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(&buf, "%q", arg)
+	}
+	return buf.String()
+}
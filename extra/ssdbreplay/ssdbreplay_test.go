@@ -0,0 +1,211 @@
+package ssdbreplay
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+var update = flag.Bool("update", false, "record the golden files against ssdbtest")
+
+var ctx = context.Background()
+
+func newClient(dial Dialer) *ssdb.Client {
+	return ssdb.NewClient(&ssdb.Options{
+		Addr:     "ssdb:8888",
+		Dialer:   dial,
+		PoolSize: 1,
+	})
+}
+
+// session runs the commands of the golden files and returns their results.
+func session(t *testing.T, rdb *ssdb.Client) []string {
+	t.Helper()
+
+	var results []string
+	do := func(args ...interface{}) {
+		cmd := rdb.Do(ctx, args...)
+		results = append(results, cmd.String())
+		if err := cmd.Err(); err != nil && err != ssdb.Nil {
+			results = append(results, err.Error())
+		}
+	}
+
+	do("set", "a", "1")
+	do("setx", "b", "binary\x00\xff", 60)
+	do("get", "b")
+	do("get", "missing")
+	do("incr", "n", 3)
+	do("multi_get", "a", "b", "missing")
+	do("hset", "h", "f", "v")
+	do("hgetall", "h")
+	do("zset", "z", "m", 5)
+	do("zrange", "z", 0, -1)
+	do("qpush", "q", "x", "y")
+	do("qrange", "q", 0, -1)
+	do("nosuchcommand")
+
+	cmds, err := rdb.Pipelined(ctx, func(pipe ssdb.Pipeliner) error {
+		pipe.Do(ctx, "get", "a")
+		pipe.Do(ctx, "exists", "missing")
+		pipe.Do(ctx, "dbsize")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range cmds {
+		results = append(results, cmd.String())
+	}
+	return results
+}
+
+func record(t *testing.T) (*Golden, []string) {
+	srv := ssdbtest.NewServer()
+	defer srv.Close()
+
+	rec := NewRecorder(srv.Dialer())
+	rdb := newClient(rec.Dialer())
+	defer rdb.Close()
+	results := session(t, rdb)
+	return rec.Golden(), results
+}
+
+func TestRecordReplay(t *testing.T) {
+	golden, want := record(t)
+	if len(golden.Conns) != 1 || len(golden.Conns[0].Exchanges) != 14 {
+		t.Fatalf("got %+v, wanted a conn of 14 exchanges", golden)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := golden.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGolden(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, golden) {
+		t.Fatalf("got %+v, wanted %+v", g, golden)
+	}
+
+	rep := NewReplayer(g, Strict)
+	rdb := newClient(rep.Dialer())
+	got := session(t, rdb)
+	rdb.Close()
+	rep.Close()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	if err := rep.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !rep.Done() {
+		t.Fatal("got exchanges left")
+	}
+}
+
+// TestGolden replays testdata/session.json, recorded with -update.
+func TestGolden(t *testing.T) {
+	path := filepath.Join("testdata", "session.json")
+	if *update {
+		golden, _ := record(t)
+		if err := golden.Save(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := LoadGolden(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := NewReplayer(g, Strict)
+	rdb := newClient(rep.Dialer())
+	got := session(t, rdb)
+	rdb.Close()
+	rep.Close()
+
+	if err := rep.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !rep.Done() {
+		t.Fatal("got exchanges left")
+	}
+	for _, s := range []string{"get b: [binary\x00\xff]", "incr n 3: [3]", "dbsize: [29]"} {
+		if !contains(got, s) {
+			t.Fatalf("got %q, wanted %q", got, s)
+		}
+	}
+}
+
+func contains(results []string, s string) bool {
+	for _, r := range results {
+		if strings.HasPrefix(r, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReplayMismatch(t *testing.T) {
+	golden := &Golden{Conns: []Conn{{
+		Network: "tcp",
+		Addr:    "ssdb:8888",
+		Exchanges: []Exchange{
+			{Request: "3\nget\n1\na\n\n", Reply: "2\nok\n1\n1\n\n"},
+			{Request: "3\nget\n1\nb\n\n", Reply: "2\nok\n1\n2\n\n"},
+		},
+	}}}
+
+	// Lenient matching only compares the command names.
+	rep := NewReplayer(golden, Lenient)
+	rdb := newClient(rep.Dialer())
+	if got := rdb.Do(ctx, "get", "x").Val(); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := rdb.Do(ctx, "get", "y").Val(); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Fatalf("got %v", got)
+	}
+	rdb.Close()
+	rep.Close()
+	if err := rep.Err(); err != nil || !rep.Done() {
+		t.Fatalf("got %v, done %t", err, rep.Done())
+	}
+
+	rep = NewReplayer(golden, Strict)
+	rdb = newClient(rep.Dialer())
+	if err := rdb.Do(ctx, "get", "a").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Do(ctx, "get", "x").Err(); err == nil {
+		t.Fatal("got nil, wanted an error for the mismatched request")
+	}
+	rdb.Close()
+	rep.Close()
+	if err := rep.Err(); err == nil || !strings.Contains(err.Error(), `got request "get" "x", wanted "get" "b"`) {
+		t.Fatalf("got %v", err)
+	}
+	if rep.Done() {
+		t.Fatal("got done, wanted an exchange left")
+	}
+
+	// A request or a dial past the recording is an error.
+	rep = NewReplayer(&Golden{Conns: golden.Conns[:1]}, Strict)
+	rdb = newClient(rep.Dialer())
+	rdb.Do(ctx, "get", "a")
+	rdb.Do(ctx, "get", "b")
+	rdb.Do(ctx, "get", "c")
+	rdb.Close()
+	rep.Close()
+	if err := rep.Err(); err == nil || !strings.Contains(err.Error(), "after the recorded ones") ||
+		!strings.Contains(err.Error(), "recorded connections were dialed") {
+		t.Fatalf("got %v", err)
+	}
+}
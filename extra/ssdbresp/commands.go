@@ -0,0 +1,689 @@
+package ssdbresp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+type command struct {
+	// minArgs and maxArgs don't count the command name; maxArgs -1 is
+	// unlimited.
+	minArgs, maxArgs int
+	fn               func(ctx context.Context, c Client, args []string) interface{}
+}
+
+var commands map[string]command
+
+func init() {
+	// DBSIZE is not translated: the dbsize of SSDB is the size of its
+	// database in bytes, not a number of keys.
+	commands = map[string]command{
+		"ping":     {0, 1, ping},
+		"echo":     {1, 1, func(_ context.Context, _ Client, args []string) interface{} { return args[0] }},
+		"select":   {1, 1, selectDB},
+		"command":  {0, -1, func(context.Context, Client, []string) interface{} { return []interface{}{} }},
+		"flushdb":  {0, 1, ssdbOK("flushdb")},
+		"flushall": {0, 1, ssdbOK("flushdb")},
+
+		"get":     {1, 1, ssdbBulk("get")},
+		"set":     {2, -1, set},
+		"setex":   {3, 3, setex(1)},
+		"psetex":  {3, 3, setex(1000)},
+		"setnx":   {2, 2, ssdbInt("setnx")},
+		"getset":  {2, 2, ssdbBulk("getset")},
+		"mget":    {1, -1, multiGet("multi_get")},
+		"mset":    {2, -1, mset},
+		"strlen":  {1, 1, strlen},
+		"incr":    {1, 1, incrBy("incr", "1")},
+		"decr":    {1, 1, incrBy("decr", "1")},
+		"incrby":  {2, 2, incrBy("incr", "")},
+		"decrby":  {2, 2, incrBy("decr", "")},
+		"del":     {1, -1, del},
+		"unlink":  {1, -1, del},
+		"exists":  {1, -1, exists},
+		"type":    {1, 1, keyType},
+		"expire":  {2, 2, expire(1)},
+		"pexpire": {2, 2, expire(1000)},
+		"ttl":     {1, 1, ttl(1)},
+		"pttl":    {1, 1, ttl(1000)},
+
+		"hset":    {3, -1, sumEach("hset", 2)},
+		"hget":    {2, 2, ssdbBulk("hget")},
+		"hmset":   {3, -1, hmset},
+		"hmget":   {2, -1, multiGet("multi_hget")},
+		"hgetall": {1, 1, ssdbArray("hgetall")},
+		"hdel":    {2, -1, sumEach("hdel", 1)},
+		"hexists": {2, 2, ssdbInt("hexists")},
+		"hlen":    {1, 1, ssdbInt("hsize")},
+		"hincrby": {3, 3, hincrBy},
+		"hkeys":   {1, 1, hkeys},
+		"hvals":   {1, 1, hvals},
+
+		"zadd":      {3, -1, zadd},
+		"zscore":    {2, 2, ssdbBulk("zget")},
+		"zincrby":   {3, 3, zincrBy},
+		"zrem":      {2, -1, sumEach("zdel", 1)},
+		"zcard":     {1, 1, ssdbInt("zsize")},
+		"zrange":    {3, 4, zrange("zrange")},
+		"zrevrange": {3, 4, zrange("zrrange")},
+
+		"lpush":  {2, -1, ssdbInt("qpush_front")},
+		"rpush":  {2, -1, ssdbInt("qpush_back")},
+		"lpop":   {1, 2, pop("qpop_front")},
+		"rpop":   {1, 2, pop("qpop_back")},
+		"llen":   {1, 1, ssdbInt("qsize")},
+		"lindex": {2, 2, lindex},
+		"lrange": {3, 3, lrange},
+	}
+}
+
+var (
+	errNotInt   = respError("ERR value is not an integer or out of range")
+	errSyntax   = respError("ERR syntax error")
+	errIntScore = respError("ERR SSDB supports integer scores only")
+)
+
+func errArity(name string) respError {
+	return respError("ERR wrong number of arguments for '" + name + "' command")
+}
+
+func errOption(name, option string) respError {
+	return respError("ERR " + strings.ToUpper(name) + " option '" + strings.ToUpper(option) +
+		"' is not supported by SSDB")
+}
+
+// errReply translates an error of the client to a Redis error.
+func errReply(err error) respError {
+	var serverErr *ssdb.ServerError
+	var clientErr *ssdb.ClientError
+	switch {
+	case errors.As(err, &serverErr) && serverErr.Message != "":
+		return respError("ERR " + serverErr.Message)
+	case errors.As(err, &clientErr) && clientErr.Message != "":
+		return respError("ERR " + clientErr.Message)
+	}
+	return respError("ERR " + err.Error())
+}
+
+func do(ctx context.Context, c Client, args ...interface{}) ([]string, error) {
+	cmd := c.Do(ctx, args...)
+	vals, _ := cmd.Val().([]string)
+	return vals, cmd.Err()
+}
+
+func toArgs(name string, args []string) []interface{} {
+	vals := make([]interface{}, 0, 1+len(args))
+	vals = append(vals, name)
+	for _, arg := range args {
+		vals = append(vals, arg)
+	}
+	return vals
+}
+
+func parseInt(s string) (int64, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+// intReply returns the first value as an integer.
+func intReply(vals []string, err error) interface{} {
+	if err != nil {
+		return errReply(err)
+	}
+	if len(vals) == 0 {
+		return int64(0)
+	}
+	n, ok := parseInt(vals[0])
+	if !ok {
+		return respError("ERR unexpected SSDB reply " + strconv.Quote(vals[0]))
+	}
+	return n
+}
+
+// bulkReply returns the first value, or the null bulk string if it is not
+// found.
+func bulkReply(vals []string, err error) interface{} {
+	if err == ssdb.Nil || err == nil && len(vals) == 0 {
+		return nil
+	}
+	if err != nil {
+		return errReply(err)
+	}
+	return vals[0]
+}
+
+// ssdbOK executes the SSDB command name with the arguments and replies OK.
+func ssdbOK(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		if _, err := do(ctx, c, name); err != nil {
+			return errReply(err)
+		}
+		return status("OK")
+	}
+}
+
+func ssdbInt(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		return intReply(do(ctx, c, toArgs(name, args)...))
+	}
+}
+
+func ssdbBulk(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		return bulkReply(do(ctx, c, toArgs(name, args)...))
+	}
+}
+
+func ssdbArray(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		vals, err := do(ctx, c, toArgs(name, args)...)
+		if err != nil {
+			return errReply(err)
+		}
+		return vals
+	}
+}
+
+// sumEach executes the SSDB command name for each group of n arguments after
+// the key in a pipeline and replies with the sum of the results, e.g. the
+// number of fields added by HSET.
+func sumEach(name string, n int) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		if (len(args)-1)%n != 0 {
+			return errArity(strings.ToLower(name))
+		}
+		return sumPipeline(ctx, c, name, args[0], args[1:], n)
+	}
+}
+
+func sumPipeline(ctx context.Context, c Client, name, key string, args []string, n int) interface{} {
+	pipe := c.Pipeline()
+	cmds := make([]*ssdb.Cmd, 0, len(args)/n)
+	for i := 0; i < len(args); i += n {
+		cmds = append(cmds, pipe.Do(ctx, toArgs(name, append([]string{key}, args[i:i+n]...))...))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errReply(err)
+	}
+	var sum int64
+	for _, cmd := range cmds {
+		vals, _ := cmd.Val().([]string)
+		if len(vals) > 0 {
+			n, _ := parseInt(vals[0])
+			sum += n
+		}
+	}
+	return sum
+}
+
+//------------------------------------------------------------------------------
+
+func ping(ctx context.Context, c Client, args []string) interface{} {
+	if _, err := do(ctx, c, "ping"); err != nil {
+		return errReply(err)
+	}
+	if len(args) == 1 {
+		return args[0]
+	}
+	return status("PONG")
+}
+
+func selectDB(_ context.Context, _ Client, args []string) interface{} {
+	if args[0] != "0" {
+		return respError("ERR DB index is out of range, SSDB has a single database")
+	}
+	return status("OK")
+}
+
+// set supports the EX, PX and NX options.
+func set(ctx context.Context, c Client, args []string) interface{} {
+	key, val := args[0], args[1]
+	var ttl int64
+	var nx bool
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); opt {
+		case "nx":
+			nx = true
+		case "ex", "px":
+			if i+1 == len(args) {
+				return errSyntax
+			}
+			i++
+			n, ok := parseInt(args[i])
+			if !ok {
+				return errNotInt
+			}
+			if n <= 0 {
+				return respError("ERR invalid expire time in 'set' command")
+			}
+			if opt == "px" {
+				n = msToSeconds(n)
+			}
+			ttl = n
+		default:
+			return errOption("set", args[i])
+		}
+	}
+
+	switch {
+	case nx && ttl > 0:
+		return respError("ERR SET with NX and an expiration is not supported by SSDB")
+	case nx:
+		vals, err := do(ctx, c, "setnx", key, val)
+		if err != nil {
+			return errReply(err)
+		}
+		if len(vals) == 0 || vals[0] != "1" {
+			return nil
+		}
+	case ttl > 0:
+		if _, err := do(ctx, c, "setx", key, val, ttl); err != nil {
+			return errReply(err)
+		}
+	default:
+		if _, err := do(ctx, c, "set", key, val); err != nil {
+			return errReply(err)
+		}
+	}
+	return status("OK")
+}
+
+// msToSeconds rounds up milliseconds to seconds, the unit of the SSDB TTLs.
+func msToSeconds(ms int64) int64 {
+	return (ms + 999) / 1000
+}
+
+func setex(unit int64) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		ttl, ok := parseInt(args[1])
+		if !ok {
+			return errNotInt
+		}
+		if ttl <= 0 {
+			return respError("ERR invalid expire time")
+		}
+		if unit == 1000 {
+			ttl = msToSeconds(ttl)
+		}
+		if _, err := do(ctx, c, "setx", args[0], args[2], ttl); err != nil {
+			return errReply(err)
+		}
+		return status("OK")
+	}
+}
+
+// multiGet translates MGET and HMGET to multi_get and multi_hget, which
+// return the pairs of the keys that are found.
+func multiGet(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		vals, err := do(ctx, c, toArgs(name, args)...)
+		if err != nil {
+			return errReply(err)
+		}
+		found := make(map[string]string, len(vals)/2)
+		for i := 0; i+1 < len(vals); i += 2 {
+			found[vals[i]] = vals[i+1]
+		}
+
+		keys := args
+		if name == "multi_hget" {
+			keys = args[1:]
+		}
+		reply := make([]interface{}, len(keys))
+		for i, key := range keys {
+			if val, ok := found[key]; ok {
+				reply[i] = val
+			}
+		}
+		return reply
+	}
+}
+
+func mset(ctx context.Context, c Client, args []string) interface{} {
+	if len(args)%2 != 0 {
+		return errArity("mset")
+	}
+	if _, err := do(ctx, c, toArgs("multi_set", args)...); err != nil {
+		return errReply(err)
+	}
+	return status("OK")
+}
+
+func strlen(ctx context.Context, c Client, args []string) interface{} {
+	vals, err := do(ctx, c, "get", args[0])
+	if err == ssdb.Nil {
+		return int64(0)
+	}
+	if err != nil {
+		return errReply(err)
+	}
+	return int64(len(vals[0]))
+}
+
+// incrBy returns INCR, DECR, INCRBY or DECRBY; by is the increment of INCR
+// and DECR.
+func incrBy(name, by string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		n := by
+		if n == "" {
+			n = args[1]
+		}
+		if _, ok := parseInt(n); !ok {
+			return errNotInt
+		}
+		return intReply(do(ctx, c, name, args[0], n))
+	}
+}
+
+// types are the SSDB commands that report whether a key exists as a kv key,
+// a hash, a sorted set or a queue, and the commands that delete them.
+var types = []struct {
+	redisType   string
+	size, clear string
+}{
+	{"string", "exists", "del"},
+	{"hash", "hsize", "hclear"},
+	{"zset", "zsize", "zclear"},
+	{"list", "qsize", "qclear"},
+}
+
+// keyTypes returns, for each key, the indexes in types of the types of data
+// that use the key.
+func keyTypes(ctx context.Context, c Client, keys []string) ([][]int, error) {
+	pipe := c.Pipeline()
+	cmds := make([]*ssdb.Cmd, 0, len(keys)*len(types))
+	for _, key := range keys {
+		for _, typ := range types {
+			cmds = append(cmds, pipe.Do(ctx, typ.size, key))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	found := make([][]int, len(keys))
+	for i, cmd := range cmds {
+		vals, _ := cmd.Val().([]string)
+		if len(vals) > 0 && vals[0] != "0" {
+			found[i/len(types)] = append(found[i/len(types)], i%len(types))
+		}
+	}
+	return found, nil
+}
+
+// del deletes the keys of every type and replies with the number of keys that
+// existed.
+func del(ctx context.Context, c Client, args []string) interface{} {
+	found, err := keyTypes(ctx, c, args)
+	if err != nil {
+		return errReply(err)
+	}
+
+	pipe := c.Pipeline()
+	var n int64
+	for i, key := range args {
+		for _, t := range found[i] {
+			pipe.Do(ctx, types[t].clear, key)
+		}
+		if len(found[i]) > 0 {
+			n++
+		}
+	}
+	if pipe.Len() > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return errReply(err)
+		}
+	}
+	return n
+}
+
+func exists(ctx context.Context, c Client, args []string) interface{} {
+	found, err := keyTypes(ctx, c, args)
+	if err != nil {
+		return errReply(err)
+	}
+	var n int64
+	for _, f := range found {
+		if len(f) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// keyType replies with the first type of data that uses the key.
+func keyType(ctx context.Context, c Client, args []string) interface{} {
+	found, err := keyTypes(ctx, c, args)
+	if err != nil {
+		return errReply(err)
+	}
+	if len(found[0]) == 0 {
+		return status("none")
+	}
+	return status(types[found[0][0]].redisType)
+}
+
+func expire(unit int64) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		ttl, ok := parseInt(args[1])
+		if !ok {
+			return errNotInt
+		}
+		if unit == 1000 {
+			ttl = msToSeconds(ttl)
+		}
+		return intReply(do(ctx, c, "expire", args[0], ttl))
+	}
+}
+
+// ttl replies -1 for the keys without a TTL and -2 for the missing keys,
+// which SSDB does not tell apart.
+func ttl(unit int64) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		reply := intReply(do(ctx, c, "ttl", args[0]))
+		n, ok := reply.(int64)
+		if !ok {
+			return reply
+		}
+		if n > 0 {
+			return n * unit
+		}
+
+		found, err := keyTypes(ctx, c, args)
+		if err != nil {
+			return errReply(err)
+		}
+		if len(found[0]) == 0 {
+			return int64(-2)
+		}
+		return int64(-1)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func hmset(ctx context.Context, c Client, args []string) interface{} {
+	if len(args)%2 != 1 {
+		return errArity("hmset")
+	}
+	if _, err := do(ctx, c, toArgs("multi_hset", args)...); err != nil {
+		return errReply(err)
+	}
+	return status("OK")
+}
+
+func hincrBy(ctx context.Context, c Client, args []string) interface{} {
+	if _, ok := parseInt(args[2]); !ok {
+		return errNotInt
+	}
+	return intReply(do(ctx, c, "hincr", args[0], args[1], args[2]))
+}
+
+func hkeys(ctx context.Context, c Client, args []string) interface{} {
+	vals, err := do(ctx, c, "hkeys", args[0], "", "", -1)
+	if err != nil {
+		return errReply(err)
+	}
+	return vals
+}
+
+func hvals(ctx context.Context, c Client, args []string) interface{} {
+	vals, err := do(ctx, c, "hgetall", args[0])
+	if err != nil {
+		return errReply(err)
+	}
+	values := make([]string, 0, len(vals)/2)
+	for i := 1; i < len(vals); i += 2 {
+		values = append(values, vals[i])
+	}
+	return values
+}
+
+//------------------------------------------------------------------------------
+
+// parseScore parses a score, which must be an integer in SSDB.
+func parseScore(s string) (int64, bool) {
+	if n, ok := parseInt(s); ok {
+		return n, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > 1<<63-1 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func zadd(ctx context.Context, c Client, args []string) interface{} {
+	switch opt := strings.ToLower(args[1]); opt {
+	case "nx", "xx", "gt", "lt", "ch", "incr":
+		return errOption("zadd", opt)
+	}
+	if (len(args)-1)%2 != 0 {
+		return errSyntax
+	}
+
+	// zset takes the member before the score.
+	members := make([]string, 0, len(args)-1)
+	for i := 1; i < len(args); i += 2 {
+		score, ok := parseScore(args[i])
+		if !ok {
+			return errIntScore
+		}
+		members = append(members, args[i+1], strconv.FormatInt(score, 10))
+	}
+	return sumPipeline(ctx, c, "zset", args[0], members, 2)
+}
+
+func zincrBy(ctx context.Context, c Client, args []string) interface{} {
+	by, ok := parseScore(args[1])
+	if !ok {
+		return errIntScore
+	}
+	return bulkReply(do(ctx, c, "zincr", args[0], args[2], by))
+}
+
+// zrange translates ZRANGE and ZREVRANGE by index to zrange and zrrange, which
+// take an offset and a limit.
+func zrange(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		withScores := false
+		if len(args) == 4 {
+			if !strings.EqualFold(args[3], "withscores") {
+				return errOption(name, args[3])
+			}
+			withScores = true
+		}
+		offset, limit, reply := indexRange(ctx, c, "zsize", args)
+		if reply != nil {
+			return reply
+		}
+		vals, err := do(ctx, c, name, args[0], offset, limit)
+		if err != nil {
+			return errReply(err)
+		}
+		if withScores {
+			return vals
+		}
+		members := make([]string, 0, len(vals)/2)
+		for i := 0; i < len(vals); i += 2 {
+			members = append(members, vals[i])
+		}
+		return members
+	}
+}
+
+// indexRange converts the start and stop indexes of args, which can be
+// negative, to an offset and a limit. It returns a reply instead if the range
+// is invalid or empty.
+func indexRange(ctx context.Context, c Client, size string, args []string) (offset, limit int64, reply interface{}) {
+	start, ok1 := parseInt(args[1])
+	stop, ok2 := parseInt(args[2])
+	if !ok1 || !ok2 {
+		return 0, 0, errNotInt
+	}
+	sizeReply := intReply(do(ctx, c, size, args[0]))
+	n, ok := sizeReply.(int64)
+	if !ok {
+		return 0, 0, sizeReply
+	}
+
+	if start < 0 {
+		start = max(start+n, 0)
+	}
+	if stop < 0 {
+		stop += n
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return 0, 0, []string{}
+	}
+	return start, stop - start + 1, nil
+}
+
+//------------------------------------------------------------------------------
+
+// pop translates LPOP and RPOP with an optional count.
+func pop(name string) func(ctx context.Context, c Client, args []string) interface{} {
+	return func(ctx context.Context, c Client, args []string) interface{} {
+		if len(args) == 1 {
+			return bulkReply(do(ctx, c, name, args[0], 1))
+		}
+
+		count, ok := parseInt(args[1])
+		if !ok || count < 0 {
+			return respError("ERR value is out of range, must be positive")
+		}
+		vals, err := do(ctx, c, name, args[0], count)
+		if err == ssdb.Nil || err == nil && len(vals) == 0 {
+			return []interface{}(nil)
+		}
+		if err != nil {
+			return errReply(err)
+		}
+		return vals
+	}
+}
+
+func lindex(ctx context.Context, c Client, args []string) interface{} {
+	if _, ok := parseInt(args[1]); !ok {
+		return errNotInt
+	}
+	return bulkReply(do(ctx, c, "qget", args[0], args[1]))
+}
+
+func lrange(ctx context.Context, c Client, args []string) interface{} {
+	offset, limit, reply := indexRange(ctx, c, "qsize", args)
+	if reply != nil {
+		return reply
+	}
+	vals, err := do(ctx, c, "qrange", args[0], offset, limit)
+	if err != nil {
+		return errReply(err)
+	}
+	return vals
+}
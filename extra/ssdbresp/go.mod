@@ -0,0 +1,7 @@
+module github.com/ssdb-go/ssdb/extra/ssdbresp
+
+go 1.21
+
+replace github.com/ssdb-go/ssdb => ../..
+
+require github.com/ssdb-go/ssdb v1.0.0
@@ -0,0 +1,224 @@
+// Package ssdbresp implements a proxy that serves the Redis protocol (RESP2)
+// in front of SSDB, so that Redis clients can use an SSDB server unchanged.
+//
+// The Redis commands are translated to SSDB commands executed with a Client,
+// and the SSDB replies are translated back to Redis replies. The commands
+// that SSDB can't support, e.g. MULTI or SUBSCRIBE, are rejected with an
+// error that names them. A few differences remain:
+//
+//   - the kv keys, hashes, sorted sets and lists (SSDB queues) are separate
+//     namespaces in SSDB, so a key name can be used by several types;
+//   - the TTLs apply to the kv keys only, and are rounded up to seconds;
+//   - the sorted set scores are integers;
+//   - the commands translated to several SSDB commands, e.g. DEL, are not
+//     atomic.
+package ssdbresp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// Client is the subset of *ssdb.Client used by the proxy.
+type Client interface {
+	Do(ctx context.Context, args ...interface{}) *ssdb.Cmd
+	Pipeline() ssdb.Pipeliner
+}
+
+var _ Client = (*ssdb.Client)(nil)
+
+// Options configure a Proxy.
+type Options struct {
+	// Password, if set, must be sent with AUTH before any other command.
+	// It is the password of the proxy; the password of the SSDB server is
+	// configured on the Client.
+	Password string
+	// Logger logs the connection errors. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+func (opt *Options) init() {
+	if opt.Logger == nil {
+		opt.Logger = slog.Default()
+	}
+}
+
+// Proxy serves RESP2 connections with a Client.
+type Proxy struct {
+	c   Client
+	opt *Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewProxy returns a proxy that executes the commands with c.
+func NewProxy(c Client, opt *Options) *Proxy {
+	if opt == nil {
+		opt = &Options{}
+	}
+	o := *opt
+	o.init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Proxy{
+		c:      c,
+		opt:    &o,
+		ctx:    ctx,
+		cancel: cancel,
+		conns:  make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts the connections of l and serves them until l or the proxy
+// is closed.
+func (p *Proxy) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go p.ServeConn(conn)
+	}
+}
+
+// ServeConn executes the commands read from conn until it is closed.
+func (p *Proxy) ServeConn(conn net.Conn) {
+	if !p.track(conn) {
+		conn.Close()
+		return
+	}
+	defer p.untrack(conn)
+	defer conn.Close()
+
+	rd := newReader(conn)
+	wr := newWriter(conn)
+	s := &session{authed: p.opt.Password == ""}
+	for {
+		args, err := rd.ReadCommand()
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				wr.WriteValue(respError("ERR " + err.Error()))
+				wr.Flush()
+			}
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				p.opt.Logger.Debug("ssdbresp: read failed", "remote", conn.RemoteAddr().String(), "error", err)
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(args[0])
+		wr.WriteValue(p.exec(s, name, args[1:]))
+		if name == "quit" {
+			wr.Flush()
+			return
+		}
+		if rd.Buffered() > 0 {
+			continue // pipelined command
+		}
+		if err := wr.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// session is the state of a connection.
+type session struct {
+	authed bool
+}
+
+func (p *Proxy) exec(s *session, name string, args []string) interface{} {
+	switch name {
+	case "auth":
+		// AUTH password or AUTH username password.
+		if len(args) < 1 || len(args) > 2 {
+			return errArity(name)
+		}
+		if p.opt.Password == "" {
+			return respError("ERR AUTH called without any password configured")
+		}
+		if args[len(args)-1] != p.opt.Password {
+			return respError("WRONGPASS invalid username-password pair or user is disabled.")
+		}
+		s.authed = true
+		return status("OK")
+	case "quit":
+		return status("OK")
+	}
+	if !s.authed {
+		return respError("NOAUTH Authentication required.")
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return respError("ERR command '" + name + "' is not supported by SSDB")
+	}
+	if len(args) < cmd.minArgs || cmd.maxArgs >= 0 && len(args) > cmd.maxArgs {
+		return errArity(name)
+	}
+	return cmd.fn(p.ctx, p.c, args)
+}
+
+// Close closes the connections being served. The pending commands are
+// canceled.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	for conn := range p.conns {
+		conn.Close()
+	}
+	p.mu.Unlock()
+
+	p.cancel()
+	p.wg.Wait()
+	return nil
+}
+
+func (p *Proxy) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	p.wg.Add(1)
+	return true
+}
+
+func (p *Proxy) untrack(conn net.Conn) {
+	p.mu.Lock()
+	delete(p.conns, conn)
+	p.mu.Unlock()
+	p.wg.Done()
+}
+
+// Commands returns the names of the supported Redis commands.
+func Commands() []string {
+	names := make([]string, 0, len(commands)+2)
+	names = append(names, "auth", "quit")
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
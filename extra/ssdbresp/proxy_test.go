@@ -0,0 +1,218 @@
+package ssdbresp
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+// respClient is a minimal Redis client.
+type respClient struct {
+	conn net.Conn
+	rd   *reader
+	wr   *writer
+}
+
+func newProxy(t *testing.T, opt *Options) (*ssdbtest.Server, func() *respClient) {
+	srv := ssdbtest.NewServer()
+	now := time.Unix(1000, 0)
+	srv.Now = func() time.Time { return now }
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: srv.Dialer(),
+	})
+	p := NewProxy(sdb, opt)
+	t.Cleanup(func() {
+		p.Close()
+		sdb.Close()
+		srv.Close()
+	})
+
+	return srv, func() *respClient {
+		client, server := net.Pipe()
+		go p.ServeConn(server)
+		t.Cleanup(func() { client.Close() })
+		return &respClient{conn: client, rd: newReader(client), wr: newWriter(client)}
+	}
+}
+
+func (c *respClient) do(t *testing.T, args ...string) interface{} {
+	t.Helper()
+	c.wr.WriteCommand(args...)
+	if err := c.wr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.rd.ReadValue()
+	if err != nil {
+		t.Fatalf("%q: %v", args, err)
+	}
+	return v
+}
+
+type list = []interface{}
+
+func TestProxy(t *testing.T) {
+	srv, connect := newProxy(t, nil)
+	c := connect()
+
+	for _, test := range []struct {
+		args  []string
+		reply interface{}
+	}{
+		{[]string{"PING"}, status("PONG")},
+		{[]string{"ping", "hello"}, "hello"},
+		{[]string{"SELECT", "0"}, status("OK")},
+		{[]string{"SELECT", "1"}, respError("ERR DB index is out of range, SSDB has a single database")},
+
+		{[]string{"SET", "a", "1"}, status("OK")},
+		{[]string{"GET", "a"}, "1"},
+		{[]string{"GET", "missing"}, nil},
+		{[]string{"SET", "a", "2", "NX"}, nil},
+		{[]string{"SET", "b", "2", "nx"}, status("OK")},
+		{[]string{"SET", "s", "v", "EX", "10"}, status("OK")},
+		{[]string{"TTL", "s"}, int64(10)},
+		{[]string{"PSETEX", "p", "1500", "v"}, status("OK")},
+		{[]string{"PTTL", "p"}, int64(2000)},
+		{[]string{"TTL", "a"}, int64(-1)},
+		{[]string{"TTL", "missing"}, int64(-2)},
+		{[]string{"SET", "a", "1", "XX"}, respError("ERR SET option 'XX' is not supported by SSDB")},
+		{[]string{"SET", "a", "1", "EX", "10", "NX"}, respError("ERR SET with NX and an expiration is not supported by SSDB")},
+		{[]string{"SET", "a", "1", "EX"}, respError("ERR syntax error")},
+		{[]string{"SETNX", "a", "3"}, int64(0)},
+		{[]string{"GETSET", "a", "3"}, "1"},
+		{[]string{"MSET", "c", "3", "d", "4"}, status("OK")},
+		{[]string{"MGET", "a", "missing", "d"}, list{"3", nil, "4"}},
+		{[]string{"STRLEN", "s"}, int64(1)},
+		{[]string{"STRLEN", "missing"}, int64(0)},
+		{[]string{"INCR", "n"}, int64(1)},
+		{[]string{"INCRBY", "n", "10"}, int64(11)},
+		{[]string{"DECRBY", "n", "x"}, respError("ERR value is not an integer or out of range")},
+		{[]string{"DECR", "n"}, int64(10)},
+		{[]string{"EXPIRE", "n", "5"}, int64(1)},
+		{[]string{"EXPIRE", "missing", "5"}, int64(0)},
+
+		{[]string{"HSET", "h", "f1", "1", "f2", "2"}, int64(2)},
+		{[]string{"HSET", "h", "f1", "10", "f3", "3"}, int64(1)},
+		{[]string{"HSET", "h", "f1"}, respError("ERR wrong number of arguments for 'hset' command")},
+		{[]string{"HGET", "h", "f1"}, "10"},
+		{[]string{"HMSET", "h", "f4", "4"}, status("OK")},
+		{[]string{"HMGET", "h", "f4", "missing", "f2"}, list{"4", nil, "2"}},
+		{[]string{"HDEL", "h", "f4", "missing"}, int64(1)},
+		{[]string{"HLEN", "h"}, int64(3)},
+		{[]string{"HEXISTS", "h", "f2"}, int64(1)},
+		{[]string{"HINCRBY", "h", "f2", "5"}, int64(7)},
+		{[]string{"HGETALL", "h"}, list{"f1", "10", "f2", "7", "f3", "3"}},
+		{[]string{"HKEYS", "h"}, list{"f1", "f2", "f3"}},
+		{[]string{"HVALS", "h"}, list{"10", "7", "3"}},
+
+		{[]string{"ZADD", "z", "1", "a", "2.0", "b", "3", "c"}, int64(3)},
+		{[]string{"ZADD", "z", "1.5", "a"}, respError("ERR SSDB supports integer scores only")},
+		{[]string{"ZADD", "z", "NX", "1", "a"}, respError("ERR ZADD option 'NX' is not supported by SSDB")},
+		{[]string{"ZSCORE", "z", "b"}, "2"},
+		{[]string{"ZSCORE", "z", "missing"}, nil},
+		{[]string{"ZINCRBY", "z", "10", "a"}, "11"},
+		{[]string{"ZCARD", "z"}, int64(3)},
+		{[]string{"ZRANGE", "z", "0", "-1"}, list{"b", "c", "a"}},
+		{[]string{"ZRANGE", "z", "-2", "-1", "WITHSCORES"}, list{"c", "3", "a", "11"}},
+		{[]string{"ZRANGE", "z", "5", "10"}, list{}},
+		{[]string{"ZRANGE", "z", "0", "1", "BYSCORE"}, respError("ERR ZRANGE option 'BYSCORE' is not supported by SSDB")},
+		{[]string{"ZREVRANGE", "z", "0", "0"}, list{"a"}},
+		{[]string{"ZREM", "z", "a", "missing"}, int64(1)},
+
+		{[]string{"RPUSH", "l", "1", "2"}, int64(2)},
+		{[]string{"LPUSH", "l", "0", "-1"}, int64(4)},
+		{[]string{"LRANGE", "l", "0", "-1"}, list{"-1", "0", "1", "2"}},
+		{[]string{"LRANGE", "l", "1", "2"}, list{"0", "1"}},
+		{[]string{"LINDEX", "l", "-1"}, "2"},
+		{[]string{"LINDEX", "l", "10"}, nil},
+		{[]string{"LLEN", "l"}, int64(4)},
+		{[]string{"LPOP", "l"}, "-1"},
+		{[]string{"RPOP", "l", "2"}, list{"2", "1"}},
+		{[]string{"RPOP", "missing"}, nil},
+		{[]string{"RPOP", "missing", "2"}, nil},
+
+		{[]string{"TYPE", "h"}, status("hash")},
+		{[]string{"TYPE", "l"}, status("list")},
+		{[]string{"TYPE", "missing"}, status("none")},
+		{[]string{"EXISTS", "a", "h", "z", "l", "missing"}, int64(4)},
+		{[]string{"DEL", "a", "h", "z", "l", "missing"}, int64(4)},
+		{[]string{"EXISTS", "a", "h", "z", "l"}, int64(0)},
+
+		{[]string{"MULTI"}, respError("ERR command 'multi' is not supported by SSDB")},
+		{[]string{"DBSIZE"}, respError("ERR command 'dbsize' is not supported by SSDB")},
+		{[]string{"GET"}, respError("ERR wrong number of arguments for 'get' command")},
+	} {
+		if got := c.do(t, test.args...); !reflect.DeepEqual(got, test.reply) {
+			t.Errorf("%q: got %#v, wanted %#v", test.args, got, test.reply)
+		}
+	}
+
+	srv.Hook = func(args []string) []string {
+		return []string{"error", "disk full"}
+	}
+	if got := c.do(t, "SET", "a", "1"); got != respError("ERR disk full") {
+		t.Fatalf("got %#v, wanted the error of the server", got)
+	}
+}
+
+func TestProxyPipeline(t *testing.T) {
+	_, connect := newProxy(t, nil)
+	c := connect()
+
+	// Commands sent together, inline commands included, are replied in
+	// order.
+	_, err := c.conn.Write([]byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\nGET k\r\nQUIT\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replies []interface{}
+	for i := 0; i < 3; i++ {
+		v, err := c.rd.ReadValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, v)
+	}
+	if wanted := (list{status("OK"), "v", status("OK")}); !reflect.DeepEqual(replies, wanted) {
+		t.Fatalf("got %#v, wanted %#v", replies, wanted)
+	}
+	if _, err := c.rd.ReadValue(); err == nil {
+		t.Fatal("got nil, wanted the connection closed by QUIT")
+	}
+
+	c = connect()
+	if _, err := c.conn.Write([]byte("*1\r\n+PING\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.rd.ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := v.(respError); !ok || !strings.Contains(string(e), "protocol error") {
+		t.Fatalf("got %#v, wanted a protocol error", v)
+	}
+}
+
+func TestProxyAuth(t *testing.T) {
+	_, connect := newProxy(t, &Options{Password: "secret"})
+	c := connect()
+
+	for _, test := range []struct {
+		args  []string
+		reply interface{}
+	}{
+		{[]string{"GET", "a"}, respError("NOAUTH Authentication required.")},
+		{[]string{"AUTH", "wrong"}, respError("WRONGPASS invalid username-password pair or user is disabled.")},
+		{[]string{"AUTH", "default", "secret"}, status("OK")},
+		{[]string{"GET", "a"}, nil},
+	} {
+		if got := c.do(t, test.args...); !reflect.DeepEqual(got, test.reply) {
+			t.Errorf("%q: got %#v, wanted %#v", test.args, got, test.reply)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package ssdbresp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The RESP2 values are represented by:
+//
+//	simple string  status
+//	error          respError
+//	integer        int64
+//	bulk string    string, nil for the null bulk string
+//	array          []interface{} or []string, nil []interface{} for the
+//	               null array
+type (
+	status    string
+	respError string
+)
+
+func (e respError) Error() string { return string(e) }
+
+var errProtocol = errors.New("ssdbresp: protocol error")
+
+const (
+	maxBulkLen  = 512 << 20
+	maxArrayLen = 1 << 20
+)
+
+type reader struct {
+	rd *bufio.Reader
+}
+
+func newReader(rd io.Reader) *reader {
+	return &reader{rd: bufio.NewReader(rd)}
+}
+
+func (r *reader) Buffered() int {
+	return r.rd.Buffered()
+}
+
+// ReadCommand reads a command sent as an array of bulk strings or as an
+// inline command.
+func (r *reader) ReadCommand() ([]string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := parseLen(line[1:], maxArrayLen)
+	if err != nil {
+		return nil, err
+	}
+	// The arrays and the bulk strings grow as their elements and bytes
+	// arrive, so a client has to send the elements and bytes it claims.
+	var args []string
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got %q", errProtocol, line)
+		}
+		arg, err := r.readBulk(line)
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, fmt.Errorf("%w: null bulk string in a command", errProtocol)
+		}
+		args = append(args, *arg)
+	}
+	return args, nil
+}
+
+// ReadValue reads a value of any type.
+func (r *reader) ReadValue() (interface{}, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("%w: empty line", errProtocol)
+	}
+
+	switch line[0] {
+	case '+':
+		return status(line[1:]), nil
+	case '-':
+		return respError(line[1:]), nil
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %q", errProtocol, line)
+		}
+		return n, nil
+	case '$':
+		s, err := r.readBulk(line)
+		if err != nil || s == nil {
+			return nil, err
+		}
+		return *s, nil
+	case '*':
+		if line == "*-1" {
+			return nil, nil
+		}
+		n, err := parseLen(line[1:], maxArrayLen)
+		if err != nil {
+			return nil, err
+		}
+		vals := []interface{}{}
+		for i := 0; i < n; i++ {
+			val, err := r.ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+		}
+		return vals, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected %q", errProtocol, line)
+}
+
+func (r *reader) readBulk(line string) (*string, error) {
+	if line == "$-1" {
+		return nil, nil
+	}
+	n, err := parseLen(line[1:], maxBulkLen)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r.rd, int64(n)+2); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	b := buf.Bytes()
+	if b[n] != '\r' || b[n+1] != '\n' {
+		return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+	}
+	s := string(b[:n])
+	return &s, nil
+}
+
+func (r *reader) readLine() (string, error) {
+	line, err := r.rd.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return "", fmt.Errorf("%w: line too long", errProtocol)
+	}
+	if err != nil {
+		return "", err
+	}
+	n := len(line) - 1
+	if n > 0 && line[n-1] == '\r' {
+		n--
+	}
+	return string(line[:n]), nil
+}
+
+func parseLen(s string, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > max {
+		return 0, fmt.Errorf("%w: invalid length %q", errProtocol, s)
+	}
+	return n, nil
+}
+
+//------------------------------------------------------------------------------
+
+type writer struct {
+	*bufio.Writer
+	buf []byte
+}
+
+func newWriter(wr io.Writer) *writer {
+	return &writer{Writer: bufio.NewWriter(wr)}
+}
+
+// WriteValue writes v, which is one of the types of the RESP2 values.
+func (w *writer) WriteValue(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case status:
+		w.writeLine('+', string(v))
+	case respError:
+		w.writeLine('-', string(v))
+	case int64:
+		w.writeInt(':', v)
+	case int:
+		w.writeInt(':', int64(v))
+	case string:
+		w.writeBulk(v)
+	case []string:
+		w.writeInt('*', int64(len(v)))
+		for _, s := range v {
+			w.writeBulk(s)
+		}
+	case []interface{}:
+		if v == nil {
+			w.WriteString("*-1\r\n") // the null array
+			return
+		}
+		w.writeInt('*', int64(len(v)))
+		for _, v := range v {
+			w.WriteValue(v)
+		}
+	default:
+		panic(fmt.Sprintf("ssdbresp: can't write %T", v))
+	}
+}
+
+// WriteCommand writes args as an array of bulk strings.
+func (w *writer) WriteCommand(args ...string) {
+	w.WriteValue(args)
+}
+
+func (w *writer) writeLine(prefix byte, s string) {
+	// The simple strings and the errors can't contain a line break.
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	w.WriteByte(prefix)
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) writeInt(prefix byte, n int64) {
+	w.buf = append(w.buf[:0], prefix)
+	w.buf = strconv.AppendInt(w.buf, n, 10)
+	w.buf = append(w.buf, '\r', '\n')
+	w.Write(w.buf)
+}
+
+func (w *writer) writeBulk(s string) {
+	w.writeInt('$', int64(len(s)))
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
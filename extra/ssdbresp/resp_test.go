@@ -0,0 +1,29 @@
+package ssdbresp
+
+import (
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReaderClaimedLength(t *testing.T) {
+	for _, req := range []string{
+		"*1\r\n$" + strconv.Itoa(maxBulkLen) + "\r\nabc",
+		"*" + strconv.Itoa(maxArrayLen) + "\r\n$3\r\nget\r\n",
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := newReader(strings.NewReader(req)).ReadCommand()
+		runtime.ReadMemStats(&after)
+
+		if err != io.ErrUnexpectedEOF && err != io.EOF {
+			t.Fatalf("%.20q: got %v, wanted EOF", req, err)
+		}
+		// The memory grows with the bytes sent, not with the lengths.
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+			t.Fatalf("%.20q: got %d bytes allocated, wanted less than 1MB", req, alloc)
+		}
+	}
+}
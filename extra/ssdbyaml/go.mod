@@ -0,0 +1,10 @@
+module github.com/ssdb-go/ssdb/extra/ssdbyaml
+
+go 1.21
+
+replace github.com/ssdb-go/ssdb => ../..
+
+require (
+	github.com/ssdb-go/ssdb v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
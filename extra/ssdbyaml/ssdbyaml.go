@@ -0,0 +1,34 @@
+// Package ssdbyaml loads ssdb.Options from YAML files, so that the core
+// module does not depend on a YAML parser.
+package ssdbyaml
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ssdb-go/ssdb"
+)
+
+// OptionsFromFile builds Options from a YAML file with a flat set of options
+// named like the options of ssdb.OptionsFromFile:
+//
+//	addr: localhost:8888
+//	pool_size: 20
+//	read_timeout: 1s
+//
+// Unknown options are reported as errors. The options are validated with
+// Options.Validate.
+func OptionsFromFile(path string) (*ssdb.Options, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("ssdbyaml: invalid config file %s: %w", path, err)
+	}
+	return ssdb.OptionsFromMap(m)
+}
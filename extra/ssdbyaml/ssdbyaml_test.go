@@ -0,0 +1,50 @@
+package ssdbyaml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOptionsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ssdb.yaml")
+	content := `
+addr: db.local:8888
+password: secret
+pool_size: 20
+min_idle_conns: 5
+read_timeout: 1.5s
+dial_timeout: 3
+pool_fifo: true
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := OptionsFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Addr != "db.local:8888" || o.Password != "secret" || o.PoolSize != 20 || o.MinIdleConns != 5 ||
+		o.ReadTimeout != 1500*time.Millisecond || o.DialTimeout != 3*time.Second || !o.PoolFIFO {
+		t.Fatalf("got %+v", o)
+	}
+
+	path = filepath.Join(dir, "unknown.yaml")
+	if err := os.WriteFile(path, []byte("adr: db.local:8888\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OptionsFromFile(path); err == nil || err.Error() != "ssdb: unexpected option: adr" {
+		t.Fatalf("got %v, wanted an unexpected option error", err)
+	}
+
+	path = filepath.Join(dir, "list.yaml")
+	if err := os.WriteFile(path, []byte("- addr\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OptionsFromFile(path); err == nil {
+		t.Fatal("got nil, wanted an invalid config error")
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/ssdb-go/ssdb
 
-go 1.17
+go 1.21
 
 require (
 	github.com/onsi/ginkgo v1.16.5
@@ -0,0 +1,291 @@
+// Package chaos injects network faults into the connections of a Dialer for
+// the tests of the retry and bad connection handling of the client and of
+// the pool.
+//
+// The faults are drawn from a seeded pseudo-random generator: latency before
+// reads and writes, partial writes, torn reads that return fewer bytes than
+// available, EOFs in the middle of a reply, connection resets and dial
+// failures. A conn that failed is broken: it is closed and its later reads
+// and writes fail, as a TCP connection reset by the server. A run is
+// reproducible from its seed as long as the conns are used by a single
+// goroutine at a time in the same order.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrInjected is the cause of the errors of the injected faults.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Options are the faults injected. The probabilities are in [0, 1].
+type Options struct {
+	// Seed seeds the generator of the faults.
+	Seed int64
+
+	// Latency is the maximum delay added before each read and write.
+	Latency time.Duration
+
+	// DialFailure is the probability that a dial fails.
+	DialFailure float64
+	// PartialWrite is the probability that a write sends part of the
+	// bytes and breaks the conn.
+	PartialWrite float64
+	// TornRead is the probability that a read returns part of the bytes
+	// it could read. The conn is not broken.
+	TornRead float64
+	// EOF is the probability that a read returns part of the bytes it read
+	// and that the next one returns io.EOF.
+	EOF float64
+	// Reset is the probability that a read or a write fails with a reset
+	// before transferring any byte.
+	Reset float64
+}
+
+// Stats are the counters of a Dialer.
+type Stats struct {
+	Dials  uint32 // successful dials
+	Open   uint32 // conns dialed and not closed yet
+	Closed uint32 // conns closed by their user
+
+	DialFailures  uint32
+	PartialWrites uint32
+	TornReads     uint32
+	EOFs          uint32
+	Resets        uint32
+}
+
+// Dialer wraps the conns of a dial function with the faults of its Options.
+type Dialer struct {
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	opt  Options
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+
+	disabled uint32 // atomic
+	stats    Stats  // atomic
+}
+
+// NewDialer returns a Dialer that injects the faults of opt into the conns
+// dialed with dial.
+func NewDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), opt Options) *Dialer {
+	return &Dialer{
+		dial: dial,
+		opt:  opt,
+		rnd:  rand.New(rand.NewSource(opt.Seed)),
+	}
+}
+
+// SetEnabled enables or disables the faults. The conns that are broken
+// stay broken.
+func (d *Dialer) SetEnabled(enabled bool) {
+	var disabled uint32
+	if !enabled {
+		disabled = 1
+	}
+	atomic.StoreUint32(&d.disabled, disabled)
+}
+
+func (d *Dialer) isEnabled() bool {
+	return atomic.LoadUint32(&d.disabled) == 0
+}
+
+// Stats returns the counters of the dialer.
+func (d *Dialer) Stats() Stats {
+	return Stats{
+		Dials:         atomic.LoadUint32(&d.stats.Dials),
+		Open:          atomic.LoadUint32(&d.stats.Open),
+		Closed:        atomic.LoadUint32(&d.stats.Closed),
+		DialFailures:  atomic.LoadUint32(&d.stats.DialFailures),
+		PartialWrites: atomic.LoadUint32(&d.stats.PartialWrites),
+		TornReads:     atomic.LoadUint32(&d.stats.TornReads),
+		EOFs:          atomic.LoadUint32(&d.stats.EOFs),
+		Resets:        atomic.LoadUint32(&d.stats.Resets),
+	}
+}
+
+// Dial dials a conn that injects faults, to be set as ssdb.Options.Dialer.
+func (d *Dialer) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.sleep()
+	if d.roll(d.opt.DialFailure) {
+		atomic.AddUint32(&d.stats.DialFailures, 1)
+		return nil, &net.OpError{Op: "dial", Net: network, Err: ErrInjected}
+	}
+
+	nc, err := d.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddUint32(&d.stats.Dials, 1)
+	atomic.AddUint32(&d.stats.Open, 1)
+
+	c := &conn{Conn: nc, d: d}
+	if sc, ok := nc.(syscall.Conn); ok {
+		// The pool checks the health of the idle conns on the socket.
+		return &sysConn{conn: c, sc: sc}, nil
+	}
+	return c, nil
+}
+
+// roll reports whether an event of probability p happens.
+func (d *Dialer) roll(p float64) bool {
+	if p <= 0 || !d.isEnabled() {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rnd.Float64() < p
+}
+
+// intn returns a number in [0, n).
+func (d *Dialer) intn(n int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rnd.Intn(n)
+}
+
+func (d *Dialer) sleep() {
+	if d.opt.Latency <= 0 || !d.isEnabled() {
+		return
+	}
+	d.mu.Lock()
+	delay := time.Duration(d.rnd.Int63n(int64(d.opt.Latency)))
+	d.mu.Unlock()
+	time.Sleep(delay)
+}
+
+//------------------------------------------------------------------------------
+
+type conn struct {
+	net.Conn
+	d *Dialer
+
+	mu     sync.Mutex
+	broken bool
+	eof    bool // the reads of the broken conn return io.EOF
+
+	closeOnce sync.Once
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.d.sleep()
+	if err := c.err("read"); err != nil {
+		return 0, err
+	}
+
+	if c.d.roll(c.d.opt.Reset) {
+		atomic.AddUint32(&c.d.stats.Resets, 1)
+		return 0, c.breakConn("read", false)
+	}
+	if len(b) > 1 && c.d.roll(c.d.opt.TornRead) {
+		atomic.AddUint32(&c.d.stats.TornReads, 1)
+		b = b[:1+c.d.intn(len(b)-1)]
+	}
+
+	n, err := c.Conn.Read(b)
+	if n > 0 && err == nil && c.d.roll(c.d.opt.EOF) {
+		atomic.AddUint32(&c.d.stats.EOFs, 1)
+		n = c.d.intn(n)
+		c.breakConn("read", true)
+		if n == 0 {
+			return 0, io.EOF
+		}
+	}
+	return n, err
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	c.d.sleep()
+	if err := c.err("write"); err != nil {
+		return 0, err
+	}
+
+	if c.d.roll(c.d.opt.Reset) {
+		atomic.AddUint32(&c.d.stats.Resets, 1)
+		return 0, c.breakConn("write", false)
+	}
+	if len(b) > 1 && c.d.roll(c.d.opt.PartialWrite) {
+		atomic.AddUint32(&c.d.stats.PartialWrites, 1)
+		n, err := c.Conn.Write(b[:1+c.d.intn(len(b)-1)])
+		if err != nil {
+			return n, err
+		}
+		return n, c.breakConn("write", false)
+	}
+	return c.Conn.Write(b)
+}
+
+// err returns the error of an operation on a broken conn.
+func (c *conn) err(op string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.eof && op == "read":
+		return io.EOF
+	case c.broken:
+		return c.opError(op, ErrInjected)
+	}
+	return nil
+}
+
+// breakConn closes the underlying conn so that the server sees the fault,
+// and makes the later reads and writes fail, the reads with io.EOF if eof.
+// It returns the error of the operation op that broke the conn.
+func (c *conn) breakConn(op string, eof bool) error {
+	c.mu.Lock()
+	c.broken = true
+	c.eof = eof
+	c.mu.Unlock()
+
+	_ = c.Conn.Close()
+	return c.opError(op, ErrInjected)
+}
+
+func (c *conn) opError(op string, err error) error {
+	return &net.OpError{
+		Op:     op,
+		Net:    c.LocalAddr().Network(),
+		Source: c.LocalAddr(),
+		Addr:   c.RemoteAddr(),
+		Err:    err,
+	}
+}
+
+func (c *conn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		atomic.AddUint32(&c.d.stats.Open, ^uint32(0))
+		atomic.AddUint32(&c.d.stats.Closed, 1)
+	})
+	if c.isBroken() {
+		// The underlying conn was closed when the conn broke.
+		return nil
+	}
+	return err
+}
+
+func (c *conn) isBroken() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.broken
+}
+
+// sysConn is a conn whose underlying conn is a socket.
+type sysConn struct {
+	*conn
+	sc syscall.Conn
+}
+
+func (c *sysConn) SyscallConn() (syscall.RawConn, error) {
+	return c.sc.SyscallConn()
+}
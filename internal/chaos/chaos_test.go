@@ -0,0 +1,163 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+var ctx = context.Background()
+
+// pipeDialer returns a dialer of net.Pipe conns whose server side is sent to
+// servers.
+func pipeDialer(servers chan<- net.Conn) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		servers <- server
+		return client, nil
+	}
+}
+
+func dial(t *testing.T, opt Options) (*Dialer, net.Conn, net.Conn) {
+	t.Helper()
+	servers := make(chan net.Conn, 1)
+	d := NewDialer(pipeDialer(servers), opt)
+	conn, err := d.Dial(ctx, "tcp", "ssdb:8888")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := <-servers
+	t.Cleanup(func() {
+		conn.Close()
+		server.Close()
+	})
+	return d, conn, server
+}
+
+func TestDialFailure(t *testing.T) {
+	d := NewDialer(pipeDialer(make(chan net.Conn, 1)), Options{DialFailure: 1})
+	if _, err := d.Dial(ctx, "tcp", "ssdb:8888"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("got %v, wanted ErrInjected", err)
+	}
+	if stats := d.Stats(); stats.DialFailures != 1 || stats.Dials != 0 {
+		t.Fatalf("got %+v", stats)
+	}
+}
+
+func TestPartialWrite(t *testing.T) {
+	d, conn, server := dial(t, Options{PartialWrite: 1})
+
+	go func() {
+		_, _ = conn.Write([]byte("3\nget\n1\na\n\n"))
+	}()
+	b, _ := io.ReadAll(server)
+	if len(b) == 0 || len(b) >= 13 {
+		t.Fatalf("got %q, wanted part of the request", b)
+	}
+
+	// The conn is broken.
+	if _, err := conn.Write([]byte("x")); !errors.Is(err, ErrInjected) {
+		t.Fatalf("got %v, wanted ErrInjected", err)
+	}
+	if stats := d.Stats(); stats.PartialWrites != 1 {
+		t.Fatalf("got %+v", stats)
+	}
+}
+
+func TestTornRead(t *testing.T) {
+	d, conn, server := dial(t, Options{TornRead: 1})
+
+	reply := []byte("2\nok\n5\nhello\n\n")
+	go func() {
+		_, _ = server.Write(reply)
+	}()
+
+	var got []byte
+	buf := make([]byte, 64)
+	for len(got) < len(reply) {
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if string(got) != string(reply) {
+		t.Fatalf("got %q, wanted %q", got, reply)
+	}
+	if stats := d.Stats(); stats.TornReads < 2 {
+		t.Fatalf("got %+v, wanted the reply torn", stats)
+	}
+}
+
+func TestEOF(t *testing.T) {
+	d, conn, server := dial(t, Options{EOF: 1})
+
+	reply := []byte("2\nok\n5\nhello\n\n")
+	go func() {
+		_, _ = server.Write(reply)
+	}()
+
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) >= len(reply) || string(got) != string(reply[:len(got)]) {
+		t.Fatalf("got %q, wanted a prefix of %q", got, reply)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("got %v, wanted io.EOF", err)
+	}
+	if _, err := conn.Write([]byte("x")); !errors.Is(err, ErrInjected) {
+		t.Fatalf("got %v, wanted ErrInjected", err)
+	}
+	if stats := d.Stats(); stats.EOFs != 1 {
+		t.Fatalf("got %+v", stats)
+	}
+}
+
+func TestReset(t *testing.T) {
+	d, conn, server := dial(t, Options{Reset: 1})
+
+	if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, ErrInjected) {
+		t.Fatalf("got %v, wanted ErrInjected", err)
+	}
+	// The server sees the conn closed.
+	if _, err := server.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("got %v, wanted io.EOF", err)
+	}
+
+	d.SetEnabled(false)
+	if _, err := conn.Write([]byte("x")); !errors.Is(err, ErrInjected) {
+		t.Fatalf("got %v, wanted the broken conn to stay broken", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := d.Stats(); stats.Resets != 1 || stats.Open != 0 || stats.Closed != 1 {
+		t.Fatalf("got %+v", stats)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	faults := func(seed int64) []bool {
+		d := NewDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, _ := net.Pipe()
+			return client, nil
+		}, Options{Seed: seed, DialFailure: 0.5})
+		var failed []bool
+		for i := 0; i < 32; i++ {
+			_, err := d.Dial(ctx, "tcp", "ssdb:8888")
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+
+	a, b := faults(1), faults(1)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("dial %d: got %t and %t with the same seed", i, a[i], b[i])
+		}
+	}
+}
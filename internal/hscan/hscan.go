@@ -97,6 +97,63 @@ func Scan(dst interface{}, keys []interface{}, vals []interface{}) error {
 	return nil
 }
 
+// ScanPairs scans a list of (key, value) pairs, e.g. a hgetall or scan reply,
+// to dst. If dst is a pointer to a struct, the keys are matched to the
+// struct's fields with the `ssdb` tag. If dst is a pointer to a slice of
+// structs or struct pointers, every pair is appended as one element: the key
+// goes to the field tagged `ssdb:"key"` and the value to `ssdb:"value"`.
+func ScanPairs(dst interface{}, pairs []string) error {
+	if len(pairs)%2 != 0 {
+		return fmt.Errorf("ssdb.Scan(odd number of values %d)", len(pairs))
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("ssdb.Scan(non-pointer %T)", dst)
+	}
+	if v.Elem().Kind() != reflect.Slice {
+		strct, err := Struct(dst)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(pairs); i += 2 {
+			if err := strct.Scan(pairs[i], pairs[i+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("ssdb.Scan(non-struct slice %T)", dst)
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		elem := reflect.New(elemType)
+		strct := StructValue{
+			spec:  globalStructMap.get(elemType),
+			value: elem.Elem(),
+		}
+		if err := strct.Scan("key", pairs[i]); err != nil {
+			return err
+		}
+		if err := strct.Scan("value", pairs[i+1]); err != nil {
+			return err
+		}
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return nil
+}
+
 func decodeBool(f reflect.Value, s string) error {
 	b, err := strconv.ParseBool(s)
 	if err != nil {
@@ -175,4 +175,35 @@ var _ = Describe("Scan", func() {
 		Expect(Scan(&d, i{"bool"}, i{""})).To(HaveOccurred())
 		Expect(Scan(&d, i{"bool"}, i{"123"})).To(HaveOccurred())
 	})
+
+	It("scans pairs to a struct", func() {
+		var d data
+
+		Expect(ScanPairs(&d, []string{"string", "str!", "int", "123", "empty", "x"})).NotTo(HaveOccurred())
+		Expect(d).To(Equal(data{String: "str!", Int: 123}))
+
+		Expect(ScanPairs(&d, []string{"int"})).To(HaveOccurred())
+		Expect(ScanPairs(&d, []string{"int", "a"})).To(HaveOccurred())
+		Expect(ScanPairs(d, []string{"int", "1"})).To(HaveOccurred())
+	})
+
+	It("scans pairs to a slice of structs", func() {
+		type kv struct {
+			Key   string `ssdb:"key"`
+			Value int    `ssdb:"value"`
+		}
+		pairs := []string{"a", "1", "b", "2"}
+
+		var kvs []kv
+		Expect(ScanPairs(&kvs, pairs)).NotTo(HaveOccurred())
+		Expect(kvs).To(Equal([]kv{{"a", 1}, {"b", 2}}))
+
+		var ptrs []*kv
+		Expect(ScanPairs(&ptrs, pairs)).NotTo(HaveOccurred())
+		Expect(ptrs).To(Equal([]*kv{{"a", 1}, {"b", 2}}))
+
+		var ints []int
+		Expect(ScanPairs(&ints, pairs)).To(HaveOccurred())
+		Expect(ScanPairs(&kvs, []string{"c", "x"})).To(HaveOccurred())
+	})
 })
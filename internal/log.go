@@ -3,8 +3,12 @@ package internal
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
 )
 
 type Logging interface {
@@ -24,3 +28,22 @@ func (l *logger) Printf(ctx context.Context, format string, v ...interface{}) {
 var Logger Logging = &logger{
 	log: log.New(os.Stderr, "ssdb: ", log.LstdFlags|log.Lshortfile),
 }
+
+// DefaultLogger is the structured logger used when none is configured. It
+// writes the records at warn level and above to the stderr, formatted as
+// text, with the file and line of the caller.
+var DefaultLogger = newDefaultLogger(os.Stderr)
+
+func newDefaultLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelWarn,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// The short file name, as log.Lshortfile.
+			if src, ok := a.Value.Any().(*slog.Source); ok && len(groups) == 0 && a.Key == slog.SourceKey {
+				return slog.String(slog.SourceKey, filepath.Base(src.File)+":"+strconv.Itoa(src.Line))
+			}
+			return a
+		},
+	}).WithAttrs([]slog.Attr{slog.String("logger", "ssdb")}))
+}
@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDefaultLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newDefaultLogger(&buf)
+
+	logger.Info("ignored")
+	logger.With("addr", "localhost:8888").Warn("dial failed", "error", "connection refused")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %q, wanted 1 line", lines)
+	}
+	// The time varies.
+	line := lines[0][strings.Index(lines[0], " ")+1:]
+	want := `level=WARN source=log_test.go:14 msg="dial failed" logger=ssdb addr=localhost:8888 error="connection refused"`
+	if line != want {
+		t.Fatalf("got %q, wanted %q", line, want)
+	}
+}
@@ -4,25 +4,41 @@ import (
 	"bufio"
 	"context"
 	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ssdb-go/ssdb/internal/proto"
 )
 
-var noDeadline = time.Time{}
+var (
+	noDeadline = time.Time{}
+	// aLongTimeAgo is a deadline in the past that unblocks the pending I/O.
+	aLongTimeAgo = time.Unix(1, 0)
+)
 
 type Conn struct {
-	usedAt  int64 // atomic
-	netConn net.Conn
+	usedAt       int64  // atomic
+	bytesRead    uint64 // atomic
+	bytesWritten uint64 // atomic
+	netConn      net.Conn
 
 	rd *proto.Reader
 	bw *bufio.Writer
 	wr *proto.Writer
 
+	// pending is set when a request was written and its reply was not
+	// fully read yet.
+	pending bool
+
+	interruptFn func() // cn.interrupt, allocated once
+	stopWatch   func() bool
+	watching    sync.WaitGroup
+
 	Inited    bool
 	pooled    bool
 	createdAt time.Time
+	turnAt    time.Time // when the pool handed out the conn
 }
 
 func NewConn(netConn net.Conn) *Conn {
@@ -30,9 +46,10 @@ func NewConn(netConn net.Conn) *Conn {
 		netConn:   netConn,
 		createdAt: time.Now(),
 	}
-	cn.rd = proto.NewReader(netConn)
-	cn.bw = bufio.NewWriter(netConn)
+	cn.rd = proto.NewReader(connReader{cn})
+	cn.bw = bufio.NewWriter(connWriter{cn})
 	cn.wr = proto.NewWriter(cn.bw)
+	cn.interruptFn = cn.interrupt
 	cn.SetUsedAt(time.Now())
 	return cn
 }
@@ -48,12 +65,46 @@ func (cn *Conn) SetUsedAt(tm time.Time) {
 
 func (cn *Conn) SetNetConn(netConn net.Conn) {
 	cn.netConn = netConn
-	cn.rd.Reset(netConn)
-	cn.bw.Reset(netConn)
+	cn.rd.Reset(connReader{cn})
+	cn.bw.Reset(connWriter{cn})
+}
+
+// BytesRead returns the number of bytes read from the connection.
+func (cn *Conn) BytesRead() uint64 {
+	return atomic.LoadUint64(&cn.bytesRead)
+}
+
+// BytesWritten returns the number of bytes written to the connection.
+func (cn *Conn) BytesWritten() uint64 {
+	return atomic.LoadUint64(&cn.bytesWritten)
+}
+
+// connReader reads from the network connection of cn, counts the bytes and
+// wraps the errors in NetworkError.
+type connReader struct {
+	cn *Conn
+}
+
+func (r connReader) Read(b []byte) (int, error) {
+	n, err := r.cn.netConn.Read(b)
+	atomic.AddUint64(&r.cn.bytesRead, uint64(n))
+	return n, connError(r.cn, "read", err)
+}
+
+// connWriter writes to the network connection of cn, counts the bytes and
+// wraps the errors in NetworkError.
+type connWriter struct {
+	cn *Conn
+}
+
+func (w connWriter) Write(b []byte) (int, error) {
+	n, err := w.cn.netConn.Write(b)
+	atomic.AddUint64(&w.cn.bytesWritten, uint64(n))
+	return n, connError(w.cn, "write", err)
 }
 
 func (cn *Conn) Write(b []byte) (int, error) {
-	return cn.netConn.Write(b)
+	return connWriter{cn}.Write(b)
 }
 
 func (cn *Conn) RemoteAddr() net.Addr {
@@ -63,28 +114,49 @@ func (cn *Conn) RemoteAddr() net.Addr {
 	return nil
 }
 
+func (cn *Conn) LocalAddr() net.Addr {
+	if cn.netConn != nil {
+		return cn.netConn.LocalAddr()
+	}
+	return nil
+}
+
+func (cn *Conn) CreatedAt() time.Time {
+	return cn.createdAt
+}
+
 func (cn *Conn) WithReader(ctx context.Context, timeout time.Duration, fn func(rd *proto.Reader) error) error {
-	if timeout != 0 {
-		if err := cn.netConn.SetReadDeadline(cn.deadline(ctx, timeout)); err != nil {
-			return err
-		}
+	if err := cn.netConn.SetReadDeadline(cn.deadline(ctx, timeout)); err != nil {
+		return err
 	}
-	return fn(cn.rd)
+	// Checked after the deadline is set, so an interruption that raced
+	// with SetReadDeadline is not lost.
+	if err := ctxErr(ctx); err != nil {
+		return err
+	}
+
+	err := fn(cn.rd)
+	if err == nil || isSsdbError(err) {
+		cn.pending = false
+	}
+	return err
 }
 
 func (cn *Conn) WithWriter(
 	ctx context.Context, timeout time.Duration, fn func(wr *proto.Writer) error,
 ) error {
-	if timeout != 0 {
-		if err := cn.netConn.SetWriteDeadline(cn.deadline(ctx, timeout)); err != nil {
-			return err
-		}
+	if err := cn.netConn.SetWriteDeadline(cn.deadline(ctx, timeout)); err != nil {
+		return err
+	}
+	if err := ctxErr(ctx); err != nil {
+		return err
 	}
 
 	if cn.bw.Buffered() > 0 {
-		cn.bw.Reset(cn.netConn)
+		cn.bw.Reset(connWriter{cn})
 	}
 
+	cn.pending = true
 	if err := fn(cn.wr); err != nil {
 		return err
 	}
@@ -92,6 +164,42 @@ func (cn *Conn) WithWriter(
 	return cn.bw.Flush()
 }
 
+// Pending reports whether a request was written on the connection and its
+// reply was not fully read, in which case the connection can't be reused.
+func (cn *Conn) Pending() bool {
+	return cn.pending
+}
+
+// Watch makes ctx interrupt the blocking reads and writes on the connection
+// until Unwatch is called. It does not start a goroutine: the connection
+// deadlines are moved to the past when ctx is done.
+func (cn *Conn) Watch(ctx context.Context) {
+	cn.watching.Add(1)
+	cn.stopWatch = context.AfterFunc(ctx, cn.interruptFn)
+}
+
+func (cn *Conn) interrupt() {
+	defer cn.watching.Done()
+	_ = cn.netConn.SetDeadline(aLongTimeAgo)
+}
+
+// Unwatch stops watching the context passed to Watch and reports whether the
+// context interrupted the connection. If it did, Unwatch waits for the
+// interruption to complete and clears the connection deadlines.
+func (cn *Conn) Unwatch() bool {
+	stop := cn.stopWatch
+	cn.stopWatch = nil
+
+	if stop() {
+		cn.watching.Done()
+		return false
+	}
+
+	cn.watching.Wait()
+	_ = cn.netConn.SetDeadline(noDeadline)
+	return true
+}
+
 func (cn *Conn) Close() error {
 	return cn.netConn.Close()
 }
@@ -123,3 +231,17 @@ func (cn *Conn) deadline(ctx context.Context, timeout time.Duration) time.Time {
 
 	return noDeadline
 }
+
+func ctxErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// isSsdbError reports whether err was replied by the server, in which case
+// the reply was fully read.
+func isSsdbError(err error) bool {
+	_, ok := err.(interface{ SsdbError() })
+	return ok
+}
@@ -0,0 +1,92 @@
+package pool_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ssdb-go/ssdb/internal/pool"
+	"github.com/ssdb-go/ssdb/internal/proto"
+)
+
+var _ = Describe("Conn context watch", func() {
+	var client, server net.Conn
+	var cn *pool.Conn
+
+	BeforeEach(func() {
+		client, server = net.Pipe()
+		cn = pool.NewConn(client)
+	})
+
+	AfterEach(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+
+	writePing := func(ctx context.Context) error {
+		return cn.WithWriter(ctx, time.Second, func(wr *proto.Writer) error {
+			return wr.WriteArgs([]interface{}{"version"})
+		})
+	}
+
+	It("interrupts a blocking read without a goroutine per command", func() {
+		go func() {
+			buf := make([]byte, 64)
+			_, _ = server.Read(buf)
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cn.Watch(ctx)
+
+		Expect(writePing(ctx)).NotTo(HaveOccurred())
+		Expect(cn.Pending()).To(BeTrue())
+
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		err := cn.WithReader(ctx, 0, func(rd *proto.Reader) error {
+			_, err := rd.ReadReply()
+			return err
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+		Expect(cn.Unwatch()).To(BeTrue())
+		Expect(cn.Pending()).To(BeTrue())
+	})
+
+	It("does not write when the context is already done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		cn.Watch(ctx)
+		Expect(writePing(ctx)).To(Equal(context.Canceled))
+		Expect(cn.Unwatch()).To(BeTrue())
+		Expect(cn.Pending()).To(BeFalse())
+
+		// The deadlines are cleared, so the connection can be reused.
+		go func() {
+			buf := make([]byte, 64)
+			_, _ = server.Read(buf)
+			_, _ = server.Write([]byte("2\nok\n\n"))
+		}()
+		ctx = context.Background()
+		Expect(writePing(ctx)).NotTo(HaveOccurred())
+		err := cn.WithReader(ctx, 0, func(rd *proto.Reader) error {
+			_, err := rd.ReadReply()
+			return err
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cn.Pending()).To(BeFalse())
+	})
+
+	It("reports no interruption when the context is not done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		cn.Watch(ctx)
+		Expect(cn.Unwatch()).To(BeFalse())
+	})
+})
@@ -0,0 +1,69 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// TimeoutError is returned by Get when no connection became available
+// within Options.PoolTimeout. It matches ErrPoolTimeout with errors.Is.
+type TimeoutError struct {
+	// Timeout is the time Get waited for a connection.
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return ErrPoolTimeout.Error() + " after " + e.Timeout.String()
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrPoolTimeout
+}
+
+// NetworkError is returned when a connection can't be dialed, or fails
+// while a request is written or a reply read.
+type NetworkError struct {
+	// Op is the failed operation: "dial", "read" or "write".
+	Op string
+	// Addr is the address of the server.
+	Addr string
+	// Err is the underlying error, usually a net.Error or io.EOF.
+	Err error
+}
+
+// NewNetworkError wraps err in a NetworkError unless it is nil or a context
+// error.
+func NewNetworkError(op, addr string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return &NetworkError{Op: op, Addr: addr, Err: err}
+}
+
+func (e *NetworkError) Error() string {
+	return "ssdb: " + e.Op + " " + e.Addr + ": " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether the operation timed out.
+func (e *NetworkError) Timeout() bool {
+	var netErr net.Error
+	return errors.As(e.Err, &netErr) && netErr.Timeout()
+}
+
+// connError wraps the errors of the network connection of cn.
+func connError(cn *Conn, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var addr string
+	if remoteAddr := cn.RemoteAddr(); remoteAddr != nil {
+		addr = remoteAddr.String()
+	}
+	return NewNetworkError(op, addr, err)
+}
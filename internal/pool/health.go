@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// healthCheckLoop checks the idle connections every HealthCheckInterval
+// until the pool is closed.
+func (p *ConnPool) healthCheckLoop() {
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.closedCh:
+			return
+		case <-ticker.C:
+			p.checkIdleConns()
+		}
+	}
+}
+
+// checkIdleConns runs HealthCheck on the idle connections that were not used
+// during the last HealthCheckInterval, least recently used first, and closes
+// the ones that fail. The connections that pass keep their position and
+// their UsedAt, so the checks don't keep them from expiring.
+func (p *ConnPool) checkIdleConns() {
+	p.connsMu.Lock()
+	n := len(p.idleConns)
+	p.connsMu.Unlock()
+
+	for i, checked := 0, 0; checked < n; checked++ {
+		cn := p.takeIdleForCheck(i)
+		if cn == nil {
+			return
+		}
+
+		usedAt := cn.UsedAt()
+		ctx, cancel := context.WithTimeout(context.Background(), p.cfg.HealthCheckInterval)
+		err := p.cfg.HealthCheck(ctx, cn)
+		cancel()
+		cn.SetUsedAt(usedAt)
+
+		p.connsMu.Lock()
+		if p.closed() {
+			// Close closed cn with the other connections.
+			p.connsMu.Unlock()
+			return
+		}
+		if err == nil {
+			p.insertIdle(i, cn)
+			p.connsMu.Unlock()
+			i++
+			continue
+		}
+		p.idleConnsLen--
+		p.removeConn(cn)
+		p.connsMu.Unlock()
+
+		_ = p.closeConn(cn, CloseReasonHealthCheck, err)
+	}
+}
+
+// takeIdleForCheck removes the idle connection at position i, the least
+// recently used first, from the pool if it needs to be checked. The
+// connection still counts as idle, so checkMinIdleConns does not replace it
+// during the check.
+func (p *ConnPool) takeIdleForCheck(i int) *Conn {
+	p.connsMu.Lock()
+	defer p.connsMu.Unlock()
+
+	if p.closed() || i >= len(p.idleConns) {
+		return nil
+	}
+
+	cn := p.idleConns[i]
+	if time.Since(cn.UsedAt()) < p.cfg.HealthCheckInterval {
+		return nil
+	}
+	p.idleConns = append(p.idleConns[:i], p.idleConns[i+1:]...)
+	return cn
+}
+
+// insertIdle puts cn back at position i of the idle connections, or last if
+// connections were taken from the pool during its check.
+func (p *ConnPool) insertIdle(i int, cn *Conn) {
+	if i > len(p.idleConns) {
+		i = len(p.idleConns)
+	}
+	p.idleConns = append(p.idleConns, nil)
+	copy(p.idleConns[i+1:], p.idleConns[i:])
+	p.idleConns[i] = cn
+}
@@ -0,0 +1,192 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ssdb-go/ssdb/internal/pool"
+)
+
+var _ = Describe("health check", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	var mu sync.Mutex
+	var checked map[*pool.Conn]int
+	var failing *pool.Conn
+	var reasons []pool.CloseReason
+
+	BeforeEach(func() {
+		checked = make(map[*pool.Conn]int)
+		failing = nil
+		reasons = nil
+
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        10,
+			MinIdleConns:    3,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+
+			HealthCheckInterval: 20 * time.Millisecond,
+			HealthCheck: func(ctx context.Context, cn *pool.Conn) error {
+				mu.Lock()
+				defer mu.Unlock()
+				checked[cn]++
+				if failing == nil {
+					failing = cn
+				}
+				if cn == failing {
+					return errors.New("version failed")
+				}
+				return nil
+			},
+			OnClose: func(cn *pool.Conn, reason pool.CloseReason, err error) {
+				mu.Lock()
+				reasons = append(reasons, reason)
+				mu.Unlock()
+			},
+		})
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("evicts the idle connections that fail and replaces them", func() {
+		Eventually(func() []pool.CloseReason {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]pool.CloseReason(nil), reasons...)
+		}).Should(Equal([]pool.CloseReason{pool.CloseReasonHealthCheck}))
+
+		Eventually(func() int {
+			return connPool.IdleLen()
+		}).Should(Equal(3))
+
+		mu.Lock()
+		Expect(checked[failing]).To(Equal(1))
+		Expect(len(checked)).To(BeNumerically(">=", 3))
+		mu.Unlock()
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cn).NotTo(BeIdenticalTo(failing))
+		connPool.Put(ctx, cn)
+	})
+
+	It("skips the connections in use", func() {
+		var cns []*pool.Conn
+		Eventually(func() int {
+			return connPool.IdleLen()
+		}).Should(Equal(3))
+		for i := 0; i < 3; i++ {
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			cns = append(cns, cn)
+		}
+
+		mu.Lock()
+		before := make([]int, len(cns))
+		for i, cn := range cns {
+			before[i] = checked[cn]
+		}
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		for i, cn := range cns {
+			Expect(checked[cn]).To(Equal(before[i]))
+		}
+		mu.Unlock()
+
+		for _, cn := range cns {
+			connPool.Put(ctx, cn)
+		}
+	})
+})
+
+var _ = Describe("health check with ConnMaxIdleTime", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	var mu sync.Mutex
+	var reasons []pool.CloseReason
+
+	BeforeEach(func() {
+		reasons = nil
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        10,
+			PoolFIFO:        true,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: 5 * time.Second,
+
+			HealthCheckInterval: 10 * time.Millisecond,
+			HealthCheck: func(ctx context.Context, cn *pool.Conn) error {
+				// As the ping of a client.
+				cn.SetUsedAt(time.Now())
+				return nil
+			},
+			OnClose: func(cn *pool.Conn, reason pool.CloseReason, err error) {
+				mu.Lock()
+				reasons = append(reasons, reason)
+				mu.Unlock()
+			},
+		})
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("closes the idle connections that expired", func() {
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn1)
+		connPool.Put(ctx, cn2)
+		cn1.SetUsedAt(time.Now().Add(-5 * time.Second))
+		cn2.SetUsedAt(time.Now().Add(-5 * time.Second))
+
+		// Several rounds of checks.
+		time.Sleep(100 * time.Millisecond)
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cn).NotTo(BeIdenticalTo(cn1))
+		Expect(cn).NotTo(BeIdenticalTo(cn2))
+		connPool.Put(ctx, cn)
+
+		mu.Lock()
+		Expect(reasons).To(Equal([]pool.CloseReason{pool.CloseReasonIdle, pool.CloseReasonIdle}))
+		mu.Unlock()
+	})
+
+	It("keeps the order of the idle connections", func() {
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn1)
+		connPool.Put(ctx, cn2)
+		// cn1 is checked, cn2 is not due.
+		cn1.SetUsedAt(time.Now().Add(-2 * time.Second))
+		cn2.SetUsedAt(time.Now().Add(time.Minute))
+
+		time.Sleep(50 * time.Millisecond)
+
+		// PoolFIFO takes the least recently used connection first.
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cn).To(BeIdenticalTo(cn1))
+		connPool.Put(ctx, cn)
+	})
+})
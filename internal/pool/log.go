@@ -0,0 +1,44 @@
+package pool
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// dialStatsAttr returns the dial stats of the pool as a log attribute. It
+// does not lock the pool.
+func (p *ConnPool) dialStatsAttr() slog.Attr {
+	return slog.Group("pool",
+		"dials", atomic.LoadUint32(&p.stats.Dials),
+		"dial_errors", atomic.LoadUint32(&p.stats.DialErrors),
+		"max_conns", atomic.LoadInt32(&p.maxTurnsAtomic),
+	)
+}
+
+// logClose logs a closed connection: at info level when it failed, at debug
+// level when it expired or did not fit into the pool. The connections closed
+// with the pool are not logged.
+func (p *ConnPool) logClose(cn *Conn, reason CloseReason, err error) {
+	level := slog.LevelDebug
+	switch reason {
+	case CloseReasonPoolClosed:
+		return
+	case CloseReasonBadConn, CloseReasonHealthCheck:
+		level = slog.LevelInfo
+	}
+
+	ctx := context.Background()
+	if !p.cfg.Logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("reason", string(reason)),
+		slog.Duration("conn_age", time.Since(cn.CreatedAt())),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	p.cfg.Logger.LogAttrs(ctx, level, "connection closed", attrs...)
+}
@@ -3,6 +3,7 @@ package pool
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -16,7 +17,12 @@ var (
 	ErrClosed = errors.New("ssdb: client is closed")
 
 	// ErrPoolTimeout timed out waiting to get a connection from the connection pool.
+	// Get returns a TimeoutError that matches it.
 	ErrPoolTimeout = errors.New("ssdb: connection pool timeout")
+
+	// ErrPoolOverload is returned by Get, with Options.PoolShedLoad, when the
+	// estimated wait for a connection exceeds the deadline of the context.
+	ErrPoolOverload = errors.New("ssdb: connection pool overloaded")
 )
 
 var timers = sync.Pool{
@@ -27,17 +33,6 @@ var timers = sync.Pool{
 	},
 }
 
-// Stats contains pool state information and accumulated stats.
-type Stats struct {
-	Hits     uint32 // number of times free connection was found in the pool
-	Misses   uint32 // number of times free connection was NOT found in the pool
-	Timeouts uint32 // number of times a wait timeout occurred
-
-	TotalConns uint32 // number of total connections in the pool
-	IdleConns  uint32 // number of idle connections in the pool
-	StaleConns uint32 // number of stale connections removed from the pool
-}
-
 type Pooler interface {
 	NewConn(context.Context) (*Conn, error)
 	CloseConn(*Conn) error
@@ -53,17 +48,59 @@ type Pooler interface {
 	Close() error
 }
 
+// CloseReason describes why the pool closed a connection.
+type CloseReason string
+
+const (
+	// CloseReasonIdle is used when the connection exceeded ConnMaxIdleTime.
+	CloseReasonIdle CloseReason = "idle"
+	// CloseReasonLifetime is used when the connection exceeded ConnMaxLifetime.
+	CloseReasonLifetime CloseReason = "lifetime"
+	// CloseReasonBadConn is used when the connection is broken or was
+	// removed after a failed command.
+	CloseReasonBadConn CloseReason = "bad_conn"
+	// CloseReasonOverflow is used when the connection does not fit into the
+	// pool, e.g. because of MaxIdleConns.
+	CloseReasonOverflow CloseReason = "overflow"
+	// CloseReasonPoolClosed is used when the pool itself is closed.
+	CloseReasonPoolClosed CloseReason = "pool_closed"
+	// CloseReasonHealthCheck is used when an idle connection failed the
+	// background health check.
+	CloseReasonHealthCheck CloseReason = "health_check"
+)
+
 type Options struct {
-	Dialer  func(context.Context) (net.Conn, error)
-	OnClose func(*Conn) error
+	Dialer func(context.Context) (net.Conn, error)
+
+	// OnCheckout is called when a connection is handed out by Get.
+	OnCheckout func(context.Context, *Conn)
+	// OnReturn is called when a connection is given back with Put or Remove.
+	OnReturn func(context.Context, *Conn)
+	// OnClose is called before the pool closes a connection.
+	OnClose func(cn *Conn, reason CloseReason, err error)
+
+	// Logger logs the dial failures and the closed connections.
+	// Default is internal.DefaultLogger.
+	Logger *slog.Logger
+
+	PoolFIFO     bool
+	PoolSize     int
+	PoolTimeout  time.Duration
+	PoolShedLoad bool
+	PoolAdaptive bool
+	MinPoolSize  int
 
-	PoolFIFO        bool
-	PoolSize        int
-	PoolTimeout     time.Duration
 	MinIdleConns    int
 	MaxIdleConns    int
 	ConnMaxIdleTime time.Duration
 	ConnMaxLifetime time.Duration
+
+	// HealthCheckInterval is the period of the background checks of the
+	// idle connections. Zero disables them.
+	HealthCheckInterval time.Duration
+	// HealthCheck checks an idle connection. The connections that fail are
+	// closed.
+	HealthCheck func(context.Context, *Conn) error
 }
 
 type lastDialErrorWrap struct {
@@ -71,12 +108,25 @@ type lastDialErrorWrap struct {
 }
 
 type ConnPool struct {
+	// 64-bit atomics first, to be aligned on 32-bit platforms.
+	waitDuration int64  // atomic
+	dialDuration int64  // atomic
+	bytesRead    uint64 // atomic, read by the removed connections
+	bytesWritten uint64 // atomic, written by the removed connections
+
 	cfg *Options
 
-	dialErrorsNum uint32 // atomic
-	lastDialError atomic.Value
+	dialErrorsNum  uint32 // atomic
+	maxTurnsAtomic int32  // atomic copy of maxTurns
+	lastDialError  atomic.Value
 
-	queue chan struct{}
+	turnsMu   sync.Mutex
+	turns     int // number of turns in use
+	maxTurns  int // PoolSize, or the current size of an adaptive pool
+	waiters   waiterHeap
+	waiterSeq uint64
+	holdTime  time.Duration // moving average of the time a turn is held
+	window    adaptWindow
 
 	connsMu   sync.Mutex
 	conns     []*Conn
@@ -94,19 +144,33 @@ type ConnPool struct {
 var _ Pooler = (*ConnPool)(nil)
 
 func NewConnPool(opt *Options) *ConnPool {
+	if opt.Logger == nil {
+		opt.Logger = internal.DefaultLogger
+	}
+
 	p := &ConnPool{
 		cfg: opt,
 
-		queue:     make(chan struct{}, opt.PoolSize),
 		conns:     make([]*Conn, 0, opt.PoolSize),
 		idleConns: make([]*Conn, 0, opt.PoolSize),
 		closedCh:  make(chan struct{}),
 	}
 
+	p.window.start = time.Now()
+	if opt.PoolAdaptive {
+		p.setMaxTurnsLocked(p.minTurns())
+	} else {
+		p.setMaxTurnsLocked(opt.PoolSize)
+	}
+
 	p.connsMu.Lock()
 	p.checkMinIdleConns()
 	p.connsMu.Unlock()
 
+	if opt.HealthCheckInterval > 0 && opt.HealthCheck != nil {
+		go p.healthCheckLoop()
+	}
+
 	return p
 }
 
@@ -141,7 +205,7 @@ func (p *ConnPool) addIdleConn() error {
 
 	// It is not allowed to add new connections to the closed connection pool.
 	if p.closed() {
-		_ = cn.Close()
+		_ = p.closeConn(cn, CloseReasonPoolClosed, ErrClosed)
 		return ErrClosed
 	}
 
@@ -165,7 +229,7 @@ func (p *ConnPool) newConn(ctx context.Context, pooled bool) (*Conn, error) {
 
 	// It is not allowed to add new connections to the closed connection pool.
 	if p.closed() {
-		_ = cn.Close()
+		_ = p.closeConn(cn, CloseReasonPoolClosed, ErrClosed)
 		return nil, ErrClosed
 	}
 
@@ -191,8 +255,11 @@ func (p *ConnPool) dialConn(ctx context.Context, pooled bool) (*Conn, error) {
 		return nil, p.getLastDialError()
 	}
 
+	start := time.Now()
 	netConn, err := p.cfg.Dialer(ctx)
+	p.recordDial(time.Since(start), err)
 	if err != nil {
+		p.cfg.Logger.InfoContext(ctx, "dial failed", "error", err, p.dialStatsAttr())
 		p.setLastDialError(err)
 		if atomic.AddUint32(&p.dialErrorsNum, 1) == uint32(p.cfg.PoolSize) {
 			go p.tryDial()
@@ -259,12 +326,15 @@ func (p *ConnPool) Get(ctx context.Context) (*Conn, error) {
 			break
 		}
 
-		if !p.isHealthyConn(cn) {
-			_ = p.CloseConn(cn)
+		if reason, err := p.checkConn(cn); reason != "" {
+			p.removeConnWithLock(cn)
+			_ = p.closeConn(cn, reason, err)
 			continue
 		}
 
 		atomic.AddUint32(&p.stats.Hits, 1)
+		cn.turnAt = time.Now()
+		p.checkout(ctx, cn)
 		return cn, nil
 	}
 
@@ -272,51 +342,25 @@ func (p *ConnPool) Get(ctx context.Context) (*Conn, error) {
 
 	newcn, err := p.newConn(ctx, true)
 	if err != nil {
-		p.freeTurn()
+		p.freeTurn(0)
 		return nil, err
 	}
 
+	newcn.turnAt = time.Now()
+	p.checkout(ctx, newcn)
 	return newcn, nil
 }
 
-func (p *ConnPool) waitTurn(ctx context.Context) error {
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
-	select {
-	case p.queue <- struct{}{}:
-		return nil
-	default:
-	}
-
-	timer := timers.Get().(*time.Timer)
-	timer.Reset(p.cfg.PoolTimeout)
-
-	select {
-	case <-ctx.Done():
-		if !timer.Stop() {
-			<-timer.C
-		}
-		timers.Put(timer)
-		return ctx.Err()
-	case p.queue <- struct{}{}:
-		if !timer.Stop() {
-			<-timer.C
-		}
-		timers.Put(timer)
-		return nil
-	case <-timer.C:
-		timers.Put(timer)
-		atomic.AddUint32(&p.stats.Timeouts, 1)
-		return ErrPoolTimeout
+func (p *ConnPool) checkout(ctx context.Context, cn *Conn) {
+	if p.cfg.OnCheckout != nil {
+		p.cfg.OnCheckout(ctx, cn)
 	}
 }
 
-func (p *ConnPool) freeTurn() {
-	<-p.queue
+func (p *ConnPool) giveBack(ctx context.Context, cn *Conn) {
+	if p.cfg.OnReturn != nil {
+		p.cfg.OnReturn(ctx, cn)
+	}
 }
 
 func (p *ConnPool) popIdle() (*Conn, error) {
@@ -344,14 +388,17 @@ func (p *ConnPool) popIdle() (*Conn, error) {
 }
 
 func (p *ConnPool) Put(ctx context.Context, cn *Conn) {
+	p.giveBack(ctx, cn)
+	hold := heldFor(cn)
+
 	if cn.rd.Buffered() > 0 {
-		internal.Logger.Printf(ctx, "Conn has unread data")
-		p.Remove(ctx, cn, BadConnError{})
+		p.cfg.Logger.WarnContext(ctx, "connection has unread data")
+		p.remove(cn, BadConnError{}, hold)
 		return
 	}
 
 	if !cn.pooled {
-		p.Remove(ctx, cn, nil)
+		p.remove(cn, nil, hold)
 		return
 	}
 
@@ -359,7 +406,7 @@ func (p *ConnPool) Put(ctx context.Context, cn *Conn) {
 
 	p.connsMu.Lock()
 
-	if p.cfg.MaxIdleConns == 0 || p.idleConnsLen < p.cfg.MaxIdleConns {
+	if p.idleConnsLen < p.idleLimit() {
 		p.idleConns = append(p.idleConns, cn)
 		p.idleConnsLen++
 	} else {
@@ -369,22 +416,54 @@ func (p *ConnPool) Put(ctx context.Context, cn *Conn) {
 
 	p.connsMu.Unlock()
 
-	p.freeTurn()
+	p.freeTurn(hold)
 
 	if shouldCloseConn {
-		_ = p.closeConn(cn)
+		_ = p.closeConn(cn, CloseReasonOverflow, nil)
 	}
 }
 
+// idleLimit is the maximum number of idle connections: MaxIdleConns, and
+// no more than the current size of an adaptive pool.
+func (p *ConnPool) idleLimit() int {
+	n := p.cfg.MaxIdleConns
+	if p.cfg.PoolAdaptive {
+		if max := int(atomic.LoadInt32(&p.maxTurnsAtomic)); n == 0 || max < n {
+			n = max
+		}
+	}
+	if n == 0 {
+		return p.cfg.PoolSize
+	}
+	return n
+}
+
 func (p *ConnPool) Remove(ctx context.Context, cn *Conn, reason error) {
+	p.giveBack(ctx, cn)
+	p.remove(cn, reason, heldFor(cn))
+}
+
+func (p *ConnPool) remove(cn *Conn, reason error, hold time.Duration) {
 	p.removeConnWithLock(cn)
-	p.freeTurn()
-	_ = p.closeConn(cn)
+	p.freeTurn(hold)
+	_ = p.closeConn(cn, removeReason(reason), reason)
+}
+
+// removeReason maps the error passed to Remove to a CloseReason.
+func removeReason(err error) CloseReason {
+	switch err {
+	case nil:
+		return CloseReasonOverflow
+	case ErrClosed:
+		return CloseReasonPoolClosed
+	default:
+		return CloseReasonBadConn
+	}
 }
 
 func (p *ConnPool) CloseConn(cn *Conn) error {
 	p.removeConnWithLock(cn)
-	return p.closeConn(cn)
+	return p.closeConn(cn, CloseReasonBadConn, nil)
 }
 
 func (p *ConnPool) removeConnWithLock(cn *Conn) {
@@ -397,6 +476,7 @@ func (p *ConnPool) removeConn(cn *Conn) {
 	for i, c := range p.conns {
 		if c == cn {
 			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			p.retireBytes(cn)
 			if cn.pooled {
 				p.poolSize--
 				p.checkMinIdleConns()
@@ -406,9 +486,11 @@ func (p *ConnPool) removeConn(cn *Conn) {
 	}
 }
 
-func (p *ConnPool) closeConn(cn *Conn) error {
+func (p *ConnPool) closeConn(cn *Conn, reason CloseReason, err error) error {
+	p.recordClose(reason)
+	p.logClose(cn, reason, err)
 	if p.cfg.OnClose != nil {
-		_ = p.cfg.OnClose(cn)
+		p.cfg.OnClose(cn, reason, err)
 	}
 	return cn.Close()
 }
@@ -429,19 +511,6 @@ func (p *ConnPool) IdleLen() int {
 	return n
 }
 
-func (p *ConnPool) Stats() *Stats {
-	idleLen := p.IdleLen()
-	return &Stats{
-		Hits:     atomic.LoadUint32(&p.stats.Hits),
-		Misses:   atomic.LoadUint32(&p.stats.Misses),
-		Timeouts: atomic.LoadUint32(&p.stats.Timeouts),
-
-		TotalConns: uint32(p.Len()),
-		IdleConns:  uint32(idleLen),
-		StaleConns: atomic.LoadUint32(&p.stats.StaleConns),
-	}
-}
-
 func (p *ConnPool) closed() bool {
 	return atomic.LoadUint32(&p._closed) == 1
 }
@@ -453,7 +522,7 @@ func (p *ConnPool) Filter(fn func(*Conn) bool) error {
 	var firstErr error
 	for _, cn := range p.conns {
 		if fn(cn) {
-			if err := p.closeConn(cn); err != nil && firstErr == nil {
+			if err := p.closeConn(cn, CloseReasonBadConn, nil); err != nil && firstErr == nil {
 				firstErr = err
 			}
 		}
@@ -470,7 +539,8 @@ func (p *ConnPool) Close() error {
 	var firstErr error
 	p.connsMu.Lock()
 	for _, cn := range p.conns {
-		if err := p.closeConn(cn); err != nil && firstErr == nil {
+		p.retireBytes(cn)
+		if err := p.closeConn(cn, CloseReasonPoolClosed, ErrClosed); err != nil && firstErr == nil {
 			firstErr = err
 		}
 	}
@@ -483,21 +553,24 @@ func (p *ConnPool) Close() error {
 	return firstErr
 }
 
-func (p *ConnPool) isHealthyConn(cn *Conn) bool {
+// checkConn returns an empty CloseReason if cn can be reused. Otherwise it
+// returns the reason why cn must be closed.
+func (p *ConnPool) checkConn(cn *Conn) (CloseReason, error) {
 	now := time.Now()
 
 	if p.cfg.ConnMaxLifetime > 0 && now.Sub(cn.createdAt) >= p.cfg.ConnMaxLifetime {
-		return false
+		atomic.AddUint32(&p.stats.StaleConns, 1)
+		return CloseReasonLifetime, nil
 	}
 	if p.cfg.ConnMaxIdleTime > 0 && now.Sub(cn.UsedAt()) >= p.cfg.ConnMaxIdleTime {
-		atomic.AddUint32(&p.stats.IdleConns, 1)
-		return false
+		atomic.AddUint32(&p.stats.StaleConns, 1)
+		return CloseReasonIdle, nil
 	}
 
-	if connCheck(cn.netConn) != nil {
-		return false
+	if err := connCheck(cn.netConn); err != nil {
+		return CloseReasonBadConn, err
 	}
 
 	cn.SetUsedAt(now)
-	return true
+	return "", nil
 }
@@ -2,6 +2,7 @@ package pool_test
 
 import (
 	"context"
+	"errors"
 	"net"
 	"sync"
 	"testing"
@@ -56,14 +57,15 @@ var _ = Describe("ConnPool", func() {
 		// We wait for 1 second and believe that checkMinIdleConns has been executed.
 		time.Sleep(time.Second)
 
-		Expect(connPool.Stats()).To(Equal(&pool.Stats{
-			Hits:       0,
-			Misses:     0,
-			Timeouts:   0,
-			TotalConns: 0,
-			IdleConns:  0,
-			StaleConns: 0,
-		}))
+		stats := connPool.Stats()
+		Expect(stats.Hits).To(Equal(uint32(0)))
+		Expect(stats.Misses).To(Equal(uint32(0)))
+		Expect(stats.Timeouts).To(Equal(uint32(0)))
+		Expect(stats.TotalConns).To(Equal(uint32(0)))
+		Expect(stats.IdleConns).To(Equal(uint32(0)))
+		Expect(stats.StaleConns).To(Equal(uint32(0)))
+		Expect(stats.Dials).To(Equal(uint32(minIdleConns)))
+		Expect(stats.Closed).To(Equal(pool.ClosedStats{PoolClosed: minIdleConns}))
 	})
 
 	It("should unblock client when conn is removed", func() {
@@ -284,6 +286,83 @@ var _ = Describe("MinIdleConns", func() {
 	})
 })
 
+var _ = Describe("lifecycle callbacks", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	var mu sync.Mutex
+	var checkouts, returns int
+	var reasons []pool.CloseReason
+
+	BeforeEach(func() {
+		checkouts, returns, reasons = 0, 0, nil
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer: dummyDialer,
+			OnCheckout: func(context.Context, *pool.Conn) {
+				mu.Lock()
+				checkouts++
+				mu.Unlock()
+			},
+			OnReturn: func(context.Context, *pool.Conn) {
+				mu.Lock()
+				returns++
+				mu.Unlock()
+			},
+			OnClose: func(cn *pool.Conn, reason pool.CloseReason, err error) {
+				mu.Lock()
+				reasons = append(reasons, reason)
+				mu.Unlock()
+			},
+			PoolSize:        10,
+			MaxIdleConns:    1,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+			ConnMaxLifetime: time.Hour,
+		})
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("reports checkout and return", func() {
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn)
+
+		cn, err = connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Remove(ctx, cn, errors.New("boom"))
+
+		Expect(checkouts).To(Equal(2))
+		Expect(returns).To(Equal(2))
+		Expect(reasons).To(Equal([]pool.CloseReason{pool.CloseReasonBadConn}))
+	})
+
+	It("reports close reasons", func() {
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		connPool.Put(ctx, cn1)
+		connPool.Put(ctx, cn2) // exceeds MaxIdleConns
+
+		cn1.SetCreatedAt(time.Now().Add(-2 * time.Hour))
+		cn, err := connPool.Get(ctx) // cn1 is too old
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn)
+
+		Expect(connPool.Close()).NotTo(HaveOccurred())
+
+		Expect(reasons).To(Equal([]pool.CloseReason{
+			pool.CloseReasonOverflow,
+			pool.CloseReasonLifetime,
+			pool.CloseReasonPoolClosed,
+		}))
+	})
+})
+
 var _ = Describe("race", func() {
 	ctx := context.Background()
 	var connPool *pool.ConnPool
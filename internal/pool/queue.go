@@ -0,0 +1,300 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Priority orders the callers waiting for a connection: callers with a higher
+// priority are served first, callers with the same priority in arrival order.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx that makes Get wait for a connection
+// with the priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// waiter is a caller of Get waiting for a turn.
+type waiter struct {
+	priority Priority
+	seq      uint64
+	index    int // in ConnPool.waiters, -1 once the turn is granted
+	ready    chan struct{}
+}
+
+type waiterHeap []*waiter
+
+var _ heap.Interface = (*waiterHeap)(nil)
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*h = old[:n-1]
+	return w
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	// adaptInterval is the period over which an adaptive pool observes the
+	// waits before it changes its size.
+	adaptInterval = 100 * time.Millisecond
+	// holdTimeWeight is the weight of the last sample in the moving average
+	// of the time a connection is held.
+	holdTimeWeight = 0.2
+)
+
+// adaptWindow collects the observations of the current adaptInterval.
+type adaptWindow struct {
+	start    time.Time
+	acquired int
+	waited   int
+	peak     int
+	// growHoldTime is the hold time when the pool last grew. The pool stops
+	// growing if more connections made the commands slower.
+	growHoldTime time.Duration
+}
+
+// waitTurn reserves one of the turns of the pool, waiting in the priority
+// queue if all of them are in use.
+func (p *ConnPool) waitTurn(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	p.turnsMu.Lock()
+	if p.turns < p.maxTurns && len(p.waiters) == 0 {
+		p.acquireLocked()
+		p.turnsMu.Unlock()
+		return nil
+	}
+
+	if p.cfg.PoolShedLoad {
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(p.estimateWaitLocked()).After(deadline) {
+			p.turnsMu.Unlock()
+			atomic.AddUint32(&p.stats.Overloads, 1)
+			return ErrPoolOverload
+		}
+	}
+
+	w := &waiter{
+		priority: priorityFromContext(ctx),
+		seq:      p.waiterSeq,
+		ready:    make(chan struct{}, 1),
+	}
+	p.waiterSeq++
+	heap.Push(&p.waiters, w)
+	p.turnsMu.Unlock()
+
+	start := time.Now()
+	timer := timers.Get().(*time.Timer)
+	timer.Reset(p.cfg.PoolTimeout)
+
+	var err error
+	select {
+	case <-w.ready:
+		if !timer.Stop() {
+			<-timer.C
+		}
+	case <-ctx.Done():
+		if !timer.Stop() {
+			<-timer.C
+		}
+		err = ctx.Err()
+	case <-timer.C:
+		atomic.AddUint32(&p.stats.Timeouts, 1)
+		err = &TimeoutError{Timeout: p.cfg.PoolTimeout}
+	}
+	timers.Put(timer)
+
+	p.recordWait(time.Since(start))
+
+	if err != nil {
+		p.cancelWait(w)
+	}
+	return err
+}
+
+// cancelWait removes w from the queue. If the turn was granted meanwhile,
+// it is handed over to the next waiter.
+func (p *ConnPool) cancelWait(w *waiter) {
+	p.turnsMu.Lock()
+	defer p.turnsMu.Unlock()
+
+	if w.index >= 0 {
+		heap.Remove(&p.waiters, w.index)
+		return
+	}
+	p.releaseLocked()
+}
+
+// heldFor returns how long cn was used since Get handed it out. It must be
+// called before cn goes back to the idle connections.
+func heldFor(cn *Conn) time.Duration {
+	if cn.turnAt.IsZero() {
+		return 0
+	}
+	d := time.Since(cn.turnAt)
+	cn.turnAt = time.Time{}
+	return d
+}
+
+// freeTurn releases the turn reserved by waitTurn. hold is the time the
+// connection was used during the turn, or 0 if none was obtained.
+func (p *ConnPool) freeTurn(hold time.Duration) {
+	p.turnsMu.Lock()
+	defer p.turnsMu.Unlock()
+
+	if hold > 0 {
+		if p.holdTime == 0 {
+			p.holdTime = hold
+		} else {
+			p.holdTime += time.Duration(holdTimeWeight * float64(hold-p.holdTime))
+		}
+	}
+
+	p.releaseLocked()
+	if p.cfg.PoolAdaptive {
+		p.adaptLocked()
+	}
+}
+
+// acquireLocked takes a free turn.
+func (p *ConnPool) acquireLocked() {
+	p.turns++
+	p.window.acquired++
+	if p.turns > p.window.peak {
+		p.window.peak = p.turns
+	}
+}
+
+// releaseLocked hands the turn over to the first waiter or, if there is no
+// waiter or the pool shrank, gives it back.
+func (p *ConnPool) releaseLocked() {
+	if p.turns <= p.maxTurns && p.grantLocked() {
+		return
+	}
+	p.turns--
+}
+
+// grantLocked passes a turn, already counted in p.turns, to the first
+// waiter. It reports whether there was one.
+func (p *ConnPool) grantLocked() bool {
+	if len(p.waiters) == 0 {
+		return false
+	}
+	w := heap.Pop(&p.waiters).(*waiter)
+	p.window.acquired++
+	p.window.waited++
+	w.ready <- struct{}{}
+	return true
+}
+
+// estimateWaitLocked estimates how long a new waiter would wait for a turn
+// from the number of waiters and the time a connection is usually held.
+func (p *ConnPool) estimateWaitLocked() time.Duration {
+	if p.maxTurns == 0 {
+		return 0
+	}
+	return p.holdTime * time.Duration(len(p.waiters)+1) / time.Duration(p.maxTurns)
+}
+
+// adaptLocked resizes an adaptive pool at the end of each adaptInterval.
+// The pool grows when the callers had to wait, unless the commands got slower
+// since the last time it grew, and shrinks when the turns were underused.
+func (p *ConnPool) adaptLocked() {
+	now := time.Now()
+	if now.Sub(p.window.start) < adaptInterval {
+		return
+	}
+	win := p.window
+	p.window = adaptWindow{start: now, peak: p.turns, growHoldTime: win.growHoldTime}
+
+	switch {
+	case win.waited*10 > win.acquired && p.maxTurns < p.cfg.PoolSize:
+		if win.growHoldTime > 0 && p.holdTime > win.growHoldTime*3/2 {
+			return
+		}
+		step := p.maxTurns / 4
+		if step < 1 {
+			step = 1
+		}
+		p.setMaxTurnsLocked(p.maxTurns + step)
+		p.window.growHoldTime = p.holdTime
+
+		for p.turns < p.maxTurns && p.grantLocked() {
+			p.turns++
+		}
+	case win.waited == 0 && win.peak < p.maxTurns/2 && p.maxTurns > p.minTurns():
+		p.setMaxTurnsLocked(p.maxTurns - (p.maxTurns-win.peak+1)/2)
+		p.window.growHoldTime = 0
+	}
+}
+
+func (p *ConnPool) setMaxTurnsLocked(n int) {
+	if n > p.cfg.PoolSize {
+		n = p.cfg.PoolSize
+	}
+	if min := p.minTurns(); n < min {
+		n = min
+	}
+	p.maxTurns = n
+	atomic.StoreInt32(&p.maxTurnsAtomic, int32(n))
+}
+
+// minTurns is the size under which an adaptive pool does not shrink.
+func (p *ConnPool) minTurns() int {
+	n := p.cfg.MinPoolSize
+	if n < p.cfg.MinIdleConns {
+		n = p.cfg.MinIdleConns
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > p.cfg.PoolSize {
+		n = p.cfg.PoolSize
+	}
+	return n
+}
@@ -0,0 +1,190 @@
+package pool_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ssdb-go/ssdb/internal/pool"
+)
+
+var _ = Describe("waiters queue", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	BeforeEach(func() {
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        1,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+		})
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("serves higher priorities first", func() {
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		var mu sync.Mutex
+		var order []pool.Priority
+		var wg sync.WaitGroup
+
+		priorities := []pool.Priority{pool.PriorityLow, pool.PriorityNormal, pool.PriorityLow, pool.PriorityHigh}
+		for i, p := range priorities {
+			wg.Add(1)
+			go func(p pool.Priority) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				cn, err := connPool.Get(pool.WithPriority(ctx, p))
+				Expect(err).NotTo(HaveOccurred())
+				mu.Lock()
+				order = append(order, p)
+				mu.Unlock()
+				connPool.Put(ctx, cn)
+			}(p)
+
+			Eventually(func() uint32 {
+				return connPool.Stats().Waiters
+			}).Should(Equal(uint32(i + 1)))
+		}
+
+		connPool.Put(ctx, cn)
+		wg.Wait()
+
+		Expect(order).To(Equal([]pool.Priority{
+			pool.PriorityHigh, pool.PriorityNormal, pool.PriorityLow, pool.PriorityLow,
+		}))
+
+		stats := connPool.Stats()
+		Expect(stats.WaitCount).To(Equal(uint32(4)))
+		Expect(stats.WaitDuration).To(BeNumerically(">", 0))
+		Expect(stats.Waiters).To(Equal(uint32(0)))
+	})
+
+	It("hands the turn over when a waiter gives up", func() {
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx1, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = connPool.Get(ctx1)
+		Expect(err).To(Equal(context.DeadlineExceeded))
+
+		connPool.Put(ctx, cn)
+
+		cn, err = connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn)
+		Expect(connPool.Stats().Timeouts).To(Equal(uint32(0)))
+	})
+})
+
+var _ = Describe("load shedding", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	BeforeEach(func() {
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        1,
+			PoolTimeout:     time.Hour,
+			PoolShedLoad:    true,
+			ConnMaxIdleTime: time.Hour,
+		})
+
+		// Teach the pool that a connection is held for about 50ms.
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		time.Sleep(50 * time.Millisecond)
+		connPool.Put(ctx, cn)
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("fails early when the wait exceeds the deadline", func() {
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		defer connPool.Put(ctx, cn)
+
+		ctx1, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err = connPool.Get(ctx1)
+		Expect(err).To(Equal(pool.ErrPoolOverload))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Millisecond))
+		Expect(connPool.Stats().Overloads).To(Equal(uint32(1)))
+	})
+
+	It("waits when the deadline is far enough", func() {
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		time.AfterFunc(10*time.Millisecond, func() {
+			connPool.Put(ctx, cn)
+		})
+
+		ctx1, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		cn, err = connPool.Get(ctx1)
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn)
+		Expect(connPool.Stats().Overloads).To(Equal(uint32(0)))
+	})
+})
+
+var _ = Describe("adaptive pool", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	BeforeEach(func() {
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        16,
+			PoolAdaptive:    true,
+			MinPoolSize:     2,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+		})
+	})
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	load := func(workers int, d time.Duration) {
+		deadline := time.Now().Add(d)
+		perform(workers, func(int) {
+			for time.Now().Before(deadline) {
+				cn, err := connPool.Get(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				time.Sleep(time.Millisecond)
+				connPool.Put(ctx, cn)
+			}
+		})
+	}
+
+	It("grows under contention and shrinks when idle", func() {
+		Expect(connPool.Stats().MaxConns).To(Equal(uint32(2)))
+
+		load(16, time.Second)
+		grown := connPool.Stats().MaxConns
+		Expect(grown).To(BeNumerically(">", 2))
+		Expect(grown).To(BeNumerically("<=", 16))
+
+		load(1, time.Second)
+		stats := connPool.Stats()
+		Expect(stats.MaxConns).To(BeNumerically("<", grown))
+		Expect(stats.IdleConns).To(BeNumerically("<=", grown))
+	})
+})
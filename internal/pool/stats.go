@@ -0,0 +1,204 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WaitBuckets are the upper bounds of the buckets of Stats.WaitHistogram.
+// They must not be modified.
+var WaitBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Stats contains pool state information and accumulated stats.
+type Stats struct {
+	Hits     uint32 // number of times free connection was found in the pool
+	Misses   uint32 // number of times free connection was NOT found in the pool
+	Timeouts uint32 // number of times a wait timeout occurred
+
+	WaitCount    uint32        // number of times a caller waited for a connection
+	WaitDuration time.Duration // total time callers waited for a connection
+	// WaitHistogram counts the waits by duration: WaitHistogram[i] is the
+	// number of waits up to WaitBuckets[i], the last element the number of
+	// longer waits.
+	WaitHistogram [len(WaitBuckets) + 1]uint32
+	Overloads     uint32 // number of times Get failed early with ErrPoolOverload
+
+	Dials        uint32        // number of connections dialed
+	DialErrors   uint32        // number of failed dials
+	DialDuration time.Duration // total time spent dialing, failed dials included
+
+	Closed ClosedStats // number of connections closed, by reason
+
+	BytesRead    uint64 // number of bytes read by the connections
+	BytesWritten uint64 // number of bytes written by the connections
+
+	TotalConns uint32 // number of total connections in the pool
+	IdleConns  uint32 // number of idle connections in the pool
+	StaleConns uint32 // number of stale connections removed from the pool
+	InUse      uint32 // number of connections handed out by the pool
+	Waiters    uint32 // number of callers waiting for a connection
+	MaxConns   uint32 // current connection limit, below PoolSize if the pool is adaptive
+}
+
+// ClosedStats counts the closed connections by CloseReason.
+type ClosedStats struct {
+	Idle        uint32
+	Lifetime    uint32
+	BadConn     uint32
+	Overflow    uint32
+	PoolClosed  uint32
+	HealthCheck uint32
+}
+
+func (s *ClosedStats) counter(reason CloseReason) *uint32 {
+	switch reason {
+	case CloseReasonIdle:
+		return &s.Idle
+	case CloseReasonLifetime:
+		return &s.Lifetime
+	case CloseReasonOverflow:
+		return &s.Overflow
+	case CloseReasonPoolClosed:
+		return &s.PoolClosed
+	case CloseReasonHealthCheck:
+		return &s.HealthCheck
+	default:
+		return &s.BadConn
+	}
+}
+
+func (s *ClosedStats) load() ClosedStats {
+	return ClosedStats{
+		Idle:        atomic.LoadUint32(&s.Idle),
+		Lifetime:    atomic.LoadUint32(&s.Lifetime),
+		BadConn:     atomic.LoadUint32(&s.BadConn),
+		Overflow:    atomic.LoadUint32(&s.Overflow),
+		PoolClosed:  atomic.LoadUint32(&s.PoolClosed),
+		HealthCheck: atomic.LoadUint32(&s.HealthCheck),
+	}
+}
+
+// Sub returns the stats accumulated since prev, an earlier snapshot of the
+// same pool. The counters are the differences between s and prev, the
+// current state, such as TotalConns or InUse, is the one of s.
+func (s *Stats) Sub(prev *Stats) *Stats {
+	d := *s
+
+	d.Hits -= prev.Hits
+	d.Misses -= prev.Misses
+	d.Timeouts -= prev.Timeouts
+
+	d.WaitCount -= prev.WaitCount
+	d.WaitDuration -= prev.WaitDuration
+	for i := range d.WaitHistogram {
+		d.WaitHistogram[i] -= prev.WaitHistogram[i]
+	}
+	d.Overloads -= prev.Overloads
+
+	d.Dials -= prev.Dials
+	d.DialErrors -= prev.DialErrors
+	d.DialDuration -= prev.DialDuration
+
+	d.Closed.Idle -= prev.Closed.Idle
+	d.Closed.Lifetime -= prev.Closed.Lifetime
+	d.Closed.BadConn -= prev.Closed.BadConn
+	d.Closed.Overflow -= prev.Closed.Overflow
+	d.Closed.PoolClosed -= prev.Closed.PoolClosed
+	d.Closed.HealthCheck -= prev.Closed.HealthCheck
+
+	d.BytesRead -= prev.BytesRead
+	d.BytesWritten -= prev.BytesWritten
+
+	d.StaleConns -= prev.StaleConns
+
+	return &d
+}
+
+func (p *ConnPool) recordWait(d time.Duration) {
+	atomic.AddUint32(&p.stats.WaitCount, 1)
+	atomic.AddInt64(&p.waitDuration, int64(d))
+
+	i := 0
+	for i < len(WaitBuckets) && d > WaitBuckets[i] {
+		i++
+	}
+	atomic.AddUint32(&p.stats.WaitHistogram[i], 1)
+}
+
+func (p *ConnPool) recordDial(d time.Duration, err error) {
+	atomic.AddInt64(&p.dialDuration, int64(d))
+	if err != nil {
+		atomic.AddUint32(&p.stats.DialErrors, 1)
+	} else {
+		atomic.AddUint32(&p.stats.Dials, 1)
+	}
+}
+
+func (p *ConnPool) recordClose(reason CloseReason) {
+	atomic.AddUint32(p.stats.Closed.counter(reason), 1)
+}
+
+// retireBytes adds the bytes transferred by cn, which is removed from the
+// pool, to the totals of the pool.
+func (p *ConnPool) retireBytes(cn *Conn) {
+	atomic.AddUint64(&p.bytesRead, cn.BytesRead())
+	atomic.AddUint64(&p.bytesWritten, cn.BytesWritten())
+}
+
+func (p *ConnPool) Stats() *Stats {
+	p.connsMu.Lock()
+	totalConns := len(p.conns)
+	idleLen := p.idleConnsLen
+	// Read the totals with connsMu held, so that a connection removed
+	// meanwhile is not counted twice or missed.
+	bytesRead := atomic.LoadUint64(&p.bytesRead)
+	bytesWritten := atomic.LoadUint64(&p.bytesWritten)
+	for _, cn := range p.conns {
+		bytesRead += cn.BytesRead()
+		bytesWritten += cn.BytesWritten()
+	}
+	p.connsMu.Unlock()
+
+	p.turnsMu.Lock()
+	inUse := p.turns
+	waiters := len(p.waiters)
+	p.turnsMu.Unlock()
+
+	stats := &Stats{
+		Hits:     atomic.LoadUint32(&p.stats.Hits),
+		Misses:   atomic.LoadUint32(&p.stats.Misses),
+		Timeouts: atomic.LoadUint32(&p.stats.Timeouts),
+
+		WaitCount:    atomic.LoadUint32(&p.stats.WaitCount),
+		WaitDuration: time.Duration(atomic.LoadInt64(&p.waitDuration)),
+		Overloads:    atomic.LoadUint32(&p.stats.Overloads),
+
+		Dials:        atomic.LoadUint32(&p.stats.Dials),
+		DialErrors:   atomic.LoadUint32(&p.stats.DialErrors),
+		DialDuration: time.Duration(atomic.LoadInt64(&p.dialDuration)),
+
+		Closed: p.stats.Closed.load(),
+
+		BytesRead:    bytesRead,
+		BytesWritten: bytesWritten,
+
+		TotalConns: uint32(totalConns),
+		IdleConns:  uint32(idleLen),
+		StaleConns: atomic.LoadUint32(&p.stats.StaleConns),
+		InUse:      uint32(inUse),
+		Waiters:    uint32(waiters),
+		MaxConns:   uint32(atomic.LoadInt32(&p.maxTurnsAtomic)),
+	}
+	for i := range stats.WaitHistogram {
+		stats.WaitHistogram[i] = atomic.LoadUint32(&p.stats.WaitHistogram[i])
+	}
+	return stats
+}
@@ -0,0 +1,169 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ssdb-go/ssdb/internal/pool"
+	"github.com/ssdb-go/ssdb/internal/proto"
+)
+
+var _ = Describe("Stats", func() {
+	ctx := context.Background()
+	var connPool *pool.ConnPool
+
+	AfterEach(func() {
+		connPool.Close()
+	})
+
+	It("counts the waits by duration", func() {
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer:          dummyDialer,
+			PoolSize:        1,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+		})
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		time.AfterFunc(20*time.Millisecond, func() {
+			connPool.Put(ctx, cn)
+		})
+
+		cn, err = connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(connPool.Stats().InUse).To(Equal(uint32(1)))
+		connPool.Put(ctx, cn)
+
+		stats := connPool.Stats()
+		Expect(stats.InUse).To(Equal(uint32(0)))
+		Expect(stats.WaitCount).To(Equal(uint32(1)))
+		Expect(stats.WaitDuration).To(BeNumerically(">=", 20*time.Millisecond))
+
+		var waits uint32
+		for i, n := range stats.WaitHistogram {
+			waits += n
+			if n > 0 {
+				Expect(i).To(BeNumerically(">=", 3)) // above 10ms
+			}
+		}
+		Expect(waits).To(Equal(uint32(1)))
+	})
+
+	It("counts the dials and the closed connections", func() {
+		errRefused := errors.New("connection refused")
+		refuse := true
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer: func(ctx context.Context) (net.Conn, error) {
+				if refuse {
+					return nil, errRefused
+				}
+				return dummyDialer(ctx)
+			},
+			PoolSize:        10,
+			MaxIdleConns:    1,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+			ConnMaxLifetime: time.Hour,
+		})
+
+		_, err := connPool.Get(ctx)
+		Expect(err).To(Equal(errRefused))
+		refuse = false
+
+		cn1, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn2, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cn3, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		connPool.Put(ctx, cn1)
+		connPool.Put(ctx, cn2) // exceeds MaxIdleConns
+		connPool.Remove(ctx, cn3, errors.New("boom"))
+
+		cn1.SetCreatedAt(time.Now().Add(-2 * time.Hour))
+		cn, err := connPool.Get(ctx) // cn1 is too old
+		Expect(err).NotTo(HaveOccurred())
+		connPool.Put(ctx, cn)
+
+		prev := connPool.Stats()
+		Expect(prev.Dials).To(Equal(uint32(4)))
+		Expect(prev.DialErrors).To(Equal(uint32(1)))
+		Expect(prev.DialDuration).To(BeNumerically(">", 0))
+		Expect(prev.StaleConns).To(Equal(uint32(1)))
+		Expect(prev.Closed).To(Equal(pool.ClosedStats{Lifetime: 1, BadConn: 1, Overflow: 1}))
+
+		Expect(connPool.Close()).NotTo(HaveOccurred())
+
+		diff := connPool.Stats().Sub(prev)
+		Expect(diff.Dials).To(Equal(uint32(0)))
+		Expect(diff.Closed).To(Equal(pool.ClosedStats{PoolClosed: 1}))
+		Expect(diff.TotalConns).To(Equal(uint32(0)))
+	})
+
+	It("counts the bytes read and written", func() {
+		connPool = pool.NewConnPool(&pool.Options{
+			Dialer: func(context.Context) (net.Conn, error) {
+				client, server := net.Pipe()
+				go func() {
+					defer server.Close()
+					b := make([]byte, 64)
+					for {
+						if _, err := server.Read(b); err != nil {
+							return
+						}
+						if _, err := io.WriteString(server, "2\nok\n\n"); err != nil {
+							return
+						}
+					}
+				}()
+				return client, nil
+			},
+			PoolSize:        10,
+			PoolTimeout:     time.Hour,
+			ConnMaxIdleTime: time.Hour,
+		})
+
+		roundTrip := func(cn *pool.Conn) {
+			err := cn.WithWriter(ctx, time.Second, func(wr *proto.Writer) error {
+				return wr.WriteArgs([]interface{}{"version"})
+			})
+			Expect(err).NotTo(HaveOccurred())
+			err = cn.WithReader(ctx, time.Second, func(rd *proto.Reader) error {
+				_, err := rd.ReadReply()
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		cn, err := connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		roundTrip(cn)
+		Expect(cn.BytesRead()).To(Equal(uint64(6)))
+		Expect(cn.BytesWritten()).To(Equal(uint64(11)))
+		connPool.Put(ctx, cn)
+
+		prev := connPool.Stats()
+		Expect(prev.BytesRead).To(Equal(uint64(6)))
+		Expect(prev.BytesWritten).To(Equal(uint64(11)))
+
+		cn, err = connPool.Get(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		roundTrip(cn)
+		connPool.Remove(ctx, cn, nil)
+
+		stats := connPool.Stats()
+		Expect(stats.BytesRead).To(Equal(uint64(12)))
+		Expect(stats.BytesWritten).To(Equal(uint64(22)))
+		diff := stats.Sub(prev)
+		Expect(diff.BytesRead).To(Equal(uint64(6)))
+		Expect(diff.BytesWritten).To(Equal(uint64(11)))
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strconv"
 )
 
 // ssdb resp protocol data type.
@@ -33,6 +34,21 @@ func ParseErrorReply(line []byte) error {
 	return SsdbError(line[1:])
 }
 
+// ProtocolError is returned when the reply of the server can't be parsed.
+// The connection is out of sync and can't be reused.
+type ProtocolError struct {
+	Msg string
+	// Data are the offending bytes.
+	Data []byte
+}
+
+func (e *ProtocolError) Error() string {
+	if e.Data == nil {
+		return "ssdb: protocol error: " + e.Msg
+	}
+	return fmt.Sprintf("ssdb: protocol error: %s: %q", e.Msg, e.Data)
+}
+
 //------------------------------------------------------------------------------
 
 type Reader struct {
@@ -59,7 +75,8 @@ func (r *Reader) Reset(rd io.Reader) {
 }
 
 // readLine returns an error if:
-//   - there is a pending read error.
+//   - there is a pending read error;
+//   - the line does not end with a newline.
 func (r *Reader) readLine() ([]byte, error) {
 	b, err := r.rd.ReadSlice('\n')
 	if err != nil {
@@ -79,46 +96,40 @@ func (r *Reader) readLine() ([]byte, error) {
 		b = full
 	}
 	if len(b) < 1 || b[len(b)-1] != '\n' {
-		return nil, fmt.Errorf("ssdb: invalid reply: %q", b)
+		return nil, &ProtocolError{Msg: "invalid reply", Data: b}
 	}
 	return b, nil
 }
 
-// readLines returns an error if:
-//   - there is a pending read line error.
-func (r *Reader) readLines() ([]byte, error) {
-	var data []byte
-	var lastBytes []byte
+// ReadReply reads the blocks of a reply up to the empty line that ends it.
+// Each block is made of its length, a newline, the data and a newline.
+// The first block is the status of the reply.
+func (r *Reader) ReadReply() (interface{}, error) {
+	resp := []string{}
 	for {
-		b, err := r.readLine()
-		//fmt.Println("readLine data: ", string(b), " readLine err:", err)
+		line, err := r.readLine()
 		if err != nil {
 			return nil, err
 		}
-
-		if string(b) == "\n" && string(lastBytes) != "1" {
-			//fmt.Println("readLines end")
-			break
+		line = bytes.TrimSuffix(line[:len(line)-1], []byte{'\r'})
+		if len(line) == 0 {
+			if len(resp) == 0 {
+				return nil, &ProtocolError{Msg: "empty reply"}
+			}
+			return resp, nil
 		}
 
-		data = append(data, b...) //nolint:makezero
-		lastBytes = b
-	}
-
-	return data, nil
-}
-
-func (r *Reader) ReadReply() (interface{}, error) {
-	buf, err := r.readLines()
-	if err != nil {
-		return nil, err
-	}
-
-	resp := []string{}
-	bufArray := bytes.Split(buf, []byte("\n"))
-	for i := 1; i < len(bufArray); i = i + 2 {
-		resp = append(resp, string(bufArray[i]))
+		n, err := strconv.Atoi(string(line))
+		if err != nil || n < 0 {
+			return nil, &ProtocolError{Msg: "invalid block length", Data: line}
+		}
+		block := make([]byte, n+1)
+		if _, err := io.ReadFull(r.rd, block); err != nil {
+			return nil, err
+		}
+		if block[n] != '\n' {
+			return nil, &ProtocolError{Msg: "block is not followed by a newline", Data: block}
+		}
+		resp = append(resp, string(block[:n]))
 	}
-
-	return resp, nil
 }
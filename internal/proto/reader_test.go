@@ -2,11 +2,43 @@ package proto_test
 
 import (
 	"bytes"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ssdb-go/ssdb/internal/proto"
 )
 
+func TestReadReply(t *testing.T) {
+	rd := proto.NewReader(strings.NewReader("2\nok\n5\na\nb\nc\n0\n\n\n"))
+	reply, err := rd.ReadReply()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ok", "a\nb\nc", ""}; !reflect.DeepEqual(reply, want) {
+		t.Fatalf("got %q, wanted %q", reply, want)
+	}
+
+	for _, tc := range []struct {
+		reply string
+		data  string
+	}{
+		{"two\nok\n\n", "two"},
+		{"2\nokay\n\n", "oka"},
+		{"\n", ""},
+	} {
+		_, err := proto.NewReader(strings.NewReader(tc.reply)).ReadReply()
+		var protoErr *proto.ProtocolError
+		if !errors.As(err, &protoErr) {
+			t.Fatalf("%q: got %v, wanted a ProtocolError", tc.reply, err)
+		}
+		if string(protoErr.Data) != tc.data {
+			t.Fatalf("%q: got data %q, wanted %q", tc.reply, protoErr.Data, tc.data)
+		}
+	}
+}
+
 func BenchmarkReader_ParseReply_Status(b *testing.B) {
 	benchmarkParseReply(b, "+OK\r\n", false)
 }
@@ -0,0 +1,827 @@
+package ssdbtest
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+type command struct {
+	// minArgs and maxArgs count the command name; maxArgs 0 is unlimited.
+	minArgs, maxArgs int
+	// pairs commands take pairs of arguments after the first minArgs.
+	pairs bool
+	fn    func(s *Server, args []string) []string
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"ping":    {1, 1, false, func(*Server, []string) []string { return ok() }},
+		"auth":    {2, 2, false, func(*Server, []string) []string { return ok("1") }},
+		"version": {1, 1, false, func(*Server, []string) []string { return ok("1.9.9") }},
+		"dbsize":  {1, 1, false, (*Server).dbsize},
+		"flushdb": {1, 2, false, (*Server).flushdb},
+
+		"set":       {3, 3, false, (*Server).set},
+		"setx":      {4, 4, false, (*Server).setx},
+		"setnx":     {3, 3, false, (*Server).setnx},
+		"get":       {2, 2, false, (*Server).get},
+		"getset":    {3, 3, false, (*Server).getset},
+		"del":       {2, 2, false, (*Server).del},
+		"exists":    {2, 2, false, (*Server).exists},
+		"expire":    {3, 3, false, (*Server).expire},
+		"ttl":       {2, 2, false, (*Server).ttl},
+		"incr":      {2, 3, false, (*Server).incr},
+		"decr":      {2, 3, false, (*Server).decr},
+		"keys":      {4, 4, false, (*Server).keys},
+		"rkeys":     {4, 4, false, (*Server).rkeys},
+		"scan":      {4, 4, false, (*Server).scan},
+		"rscan":     {4, 4, false, (*Server).rscan},
+		"multi_set": {3, 0, true, (*Server).multiSet},
+		"multi_get": {2, 0, false, (*Server).multiGet},
+		"multi_del": {2, 0, false, (*Server).multiDel},
+
+		"hset":       {4, 4, false, (*Server).hset},
+		"hget":       {3, 3, false, (*Server).hget},
+		"hdel":       {3, 3, false, (*Server).hdel},
+		"hincr":      {3, 4, false, (*Server).hincr},
+		"hexists":    {3, 3, false, (*Server).hexists},
+		"hsize":      {2, 2, false, (*Server).hsize},
+		"hlist":      {4, 4, false, listNames((*Server).hashNames, false)},
+		"hrlist":     {4, 4, false, listNames((*Server).hashNames, true)},
+		"hkeys":      {5, 5, false, (*Server).hkeys},
+		"hgetall":    {2, 2, false, (*Server).hgetall},
+		"hscan":      {5, 5, false, (*Server).hscan},
+		"hclear":     {2, 2, false, (*Server).hclear},
+		"multi_hset": {4, 0, true, (*Server).multiHSet},
+		"multi_hget": {3, 0, false, (*Server).multiHGet},
+		"multi_hdel": {3, 0, false, (*Server).multiHDel},
+
+		"zset":       {4, 4, false, (*Server).zset},
+		"zget":       {3, 3, false, (*Server).zget},
+		"zdel":       {3, 3, false, (*Server).zdel},
+		"zincr":      {4, 4, false, (*Server).zincr},
+		"zexists":    {3, 3, false, (*Server).zexists},
+		"zsize":      {2, 2, false, (*Server).zsize},
+		"zlist":      {4, 4, false, listNames((*Server).zsetNames, false)},
+		"zrlist":     {4, 4, false, listNames((*Server).zsetNames, true)},
+		"zscan":      {6, 6, false, (*Server).zscan},
+		"zrange":     {4, 4, false, (*Server).zrange},
+		"zrrange":    {4, 4, false, (*Server).zrrange},
+		"zclear":     {2, 2, false, (*Server).zclear},
+		"multi_zset": {4, 0, true, (*Server).multiZSet},
+		"multi_zget": {3, 0, false, (*Server).multiZGet},
+		"multi_zdel": {3, 0, false, (*Server).multiZDel},
+
+		"qpush":       {3, 0, false, (*Server).qpushBack},
+		"qpush_back":  {3, 0, false, (*Server).qpushBack},
+		"qpush_front": {3, 0, false, (*Server).qpushFront},
+		"qpop":        {2, 3, false, (*Server).qpopFront},
+		"qpop_front":  {2, 3, false, (*Server).qpopFront},
+		"qpop_back":   {2, 3, false, (*Server).qpopBack},
+		"qfront":      {2, 2, false, (*Server).qfront},
+		"qback":       {2, 2, false, (*Server).qback},
+		"qsize":       {2, 2, false, (*Server).qsize},
+		"qget":        {3, 3, false, (*Server).qget},
+		"qrange":      {4, 4, false, (*Server).qrange},
+		"qclear":      {2, 2, false, (*Server).qclear},
+		"qlist":       {4, 4, false, listNames((*Server).queueNames, false)},
+		"qrlist":      {4, 4, false, listNames((*Server).queueNames, true)},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// dbsize estimates the size of the data in bytes, as SSDB returns the size
+// of its database: the lengths of the names, keys and values, and 8 bytes per
+// score.
+func (s *Server) dbsize(args []string) []string {
+	var n int
+	for key, val := range s.kv {
+		n += len(key) + len(val)
+	}
+	for name, h := range s.hashes {
+		n += len(name)
+		for key, val := range h {
+			n += len(key) + len(val)
+		}
+	}
+	for name, z := range s.zsets {
+		n += len(name)
+		for key := range z {
+			n += len(key) + 8
+		}
+	}
+	for name, q := range s.queues {
+		n += len(name)
+		for _, item := range q {
+			n += len(item)
+		}
+	}
+	return okInt(int64(n))
+}
+
+func (s *Server) flushdb(args []string) []string {
+	s.reset()
+	return ok()
+}
+
+// alive reports whether key exists, deleting it if it expired.
+func (s *Server) alive(key string) bool {
+	if _, ok := s.kv[key]; !ok {
+		return false
+	}
+	if at, ok := s.expires[key]; ok && !s.Now().Before(at) {
+		delete(s.kv, key)
+		delete(s.expires, key)
+		return false
+	}
+	return true
+}
+
+func (s *Server) setValue(key, val string) {
+	s.kv[key] = val
+	delete(s.expires, key)
+}
+
+func (s *Server) set(args []string) []string {
+	s.setValue(args[0], args[1])
+	return ok("1")
+}
+
+func (s *Server) setx(args []string) []string {
+	ttl, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || ttl <= 0 {
+		return clientError("invalid ttl")
+	}
+	s.setValue(args[0], args[1])
+	s.expires[args[0]] = s.Now().Add(time.Duration(ttl) * time.Second)
+	return ok("1")
+}
+
+func (s *Server) setnx(args []string) []string {
+	if s.alive(args[0]) {
+		return ok("0")
+	}
+	s.setValue(args[0], args[1])
+	return ok("1")
+}
+
+func (s *Server) get(args []string) []string {
+	if !s.alive(args[0]) {
+		return notFound()
+	}
+	return ok(s.kv[args[0]])
+}
+
+func (s *Server) getset(args []string) []string {
+	reply := s.get(args[:1])
+	s.setValue(args[0], args[1])
+	return reply
+}
+
+func (s *Server) del(args []string) []string {
+	delete(s.kv, args[0])
+	delete(s.expires, args[0])
+	return ok("1")
+}
+
+func (s *Server) exists(args []string) []string {
+	return okBool(s.alive(args[0]))
+}
+
+func (s *Server) expire(args []string) []string {
+	ttl, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return clientError("invalid ttl")
+	}
+	if !s.alive(args[0]) {
+		return ok("0")
+	}
+	s.expires[args[0]] = s.Now().Add(time.Duration(ttl) * time.Second)
+	return ok("1")
+}
+
+func (s *Server) ttl(args []string) []string {
+	at, ok := s.expires[args[0]]
+	if !s.alive(args[0]) || !ok {
+		return okInt(-1)
+	}
+	ttl := at.Sub(s.Now())
+	return okInt(int64((ttl + time.Second - 1) / time.Second))
+}
+
+func (s *Server) incr(args []string) []string {
+	return s.incrBy(args, 1)
+}
+
+func (s *Server) decr(args []string) []string {
+	return s.incrBy(args, -1)
+}
+
+func (s *Server) incrBy(args []string, sign int64) []string {
+	by, errReply := optInt(args, 1, 1)
+	if errReply != nil {
+		return errReply
+	}
+	var n int64
+	if s.alive(args[0]) {
+		var err error
+		n, err = strconv.ParseInt(s.kv[args[0]], 10, 64)
+		if err != nil {
+			return []string{"error", "value is not an integer or out of range"}
+		}
+	}
+	n += sign * by
+	s.kv[args[0]] = strconv.FormatInt(n, 10)
+	return okInt(n)
+}
+
+func (s *Server) liveKeys() []string {
+	keys := make([]string, 0, len(s.kv))
+	for key := range s.kv {
+		if s.alive(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) keys(args []string) []string {
+	return s.kvRange(args, false, false)
+}
+
+func (s *Server) rkeys(args []string) []string {
+	return s.kvRange(args, true, false)
+}
+
+func (s *Server) scan(args []string) []string {
+	return s.kvRange(args, false, true)
+}
+
+func (s *Server) rscan(args []string) []string {
+	return s.kvRange(args, true, true)
+}
+
+func (s *Server) kvRange(args []string, reverse, withValues bool) []string {
+	limit, errReply := parseLimit(args[2])
+	if errReply != nil {
+		return errReply
+	}
+	reply := ok()
+	for _, key := range keyRange(s.liveKeys(), args[0], args[1], limit, reverse) {
+		reply = append(reply, key)
+		if withValues {
+			reply = append(reply, s.kv[key])
+		}
+	}
+	return reply
+}
+
+func (s *Server) multiSet(args []string) []string {
+	for i := 0; i < len(args); i += 2 {
+		s.setValue(args[i], args[i+1])
+	}
+	return okInt(int64(len(args) / 2))
+}
+
+func (s *Server) multiGet(args []string) []string {
+	reply := ok()
+	for _, key := range args {
+		if s.alive(key) {
+			reply = append(reply, key, s.kv[key])
+		}
+	}
+	return reply
+}
+
+func (s *Server) multiDel(args []string) []string {
+	for _, key := range args {
+		delete(s.kv, key)
+		delete(s.expires, key)
+	}
+	return okInt(int64(len(args)))
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) hashNames() []string {
+	return sortedNames(s.hashes)
+}
+
+func (s *Server) hset(args []string) []string {
+	h := s.hashes[args[0]]
+	if h == nil {
+		h = make(map[string]string)
+		s.hashes[args[0]] = h
+	}
+	_, exists := h[args[1]]
+	h[args[1]] = args[2]
+	return okBool(!exists)
+}
+
+func (s *Server) hget(args []string) []string {
+	val, ok := s.hashes[args[0]][args[1]]
+	if !ok {
+		return notFound()
+	}
+	return []string{"ok", val}
+}
+
+func (s *Server) hdel(args []string) []string {
+	h := s.hashes[args[0]]
+	_, exists := h[args[1]]
+	delete(h, args[1])
+	if len(h) == 0 {
+		delete(s.hashes, args[0])
+	}
+	return okBool(exists)
+}
+
+func (s *Server) hincr(args []string) []string {
+	by, errReply := optInt(args, 2, 1)
+	if errReply != nil {
+		return errReply
+	}
+	var n int64
+	if val, ok := s.hashes[args[0]][args[1]]; ok {
+		var err error
+		n, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return []string{"error", "value is not an integer or out of range"}
+		}
+	}
+	n += by
+	s.hset([]string{args[0], args[1], strconv.FormatInt(n, 10)})
+	return okInt(n)
+}
+
+func (s *Server) hexists(args []string) []string {
+	_, exists := s.hashes[args[0]][args[1]]
+	return okBool(exists)
+}
+
+func (s *Server) hsize(args []string) []string {
+	return okInt(int64(len(s.hashes[args[0]])))
+}
+
+func (s *Server) hkeys(args []string) []string {
+	return s.hashRange(args, false)
+}
+
+func (s *Server) hscan(args []string) []string {
+	return s.hashRange(args, true)
+}
+
+func (s *Server) hashRange(args []string, withValues bool) []string {
+	limit, errReply := parseLimit(args[3])
+	if errReply != nil {
+		return errReply
+	}
+	h := s.hashes[args[0]]
+	reply := ok()
+	for _, key := range keyRange(sortedNames(h), args[1], args[2], limit, false) {
+		reply = append(reply, key)
+		if withValues {
+			reply = append(reply, h[key])
+		}
+	}
+	return reply
+}
+
+func (s *Server) hgetall(args []string) []string {
+	return s.hashRange([]string{args[0], "", "", "-1"}, true)
+}
+
+func (s *Server) hclear(args []string) []string {
+	n := len(s.hashes[args[0]])
+	delete(s.hashes, args[0])
+	return okInt(int64(n))
+}
+
+func (s *Server) multiHSet(args []string) []string {
+	var n int64
+	for i := 1; i < len(args); i += 2 {
+		if s.hset([]string{args[0], args[i], args[i+1]})[1] == "1" {
+			n++
+		}
+	}
+	return okInt(n)
+}
+
+func (s *Server) multiHGet(args []string) []string {
+	h := s.hashes[args[0]]
+	reply := ok()
+	for _, key := range args[1:] {
+		if val, ok := h[key]; ok {
+			reply = append(reply, key, val)
+		}
+	}
+	return reply
+}
+
+func (s *Server) multiHDel(args []string) []string {
+	var n int64
+	for _, key := range args[1:] {
+		if s.hdel([]string{args[0], key})[1] == "1" {
+			n++
+		}
+	}
+	return okInt(n)
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) zsetNames() []string {
+	return sortedNames(s.zsets)
+}
+
+func (s *Server) zset(args []string) []string {
+	score, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return clientError("invalid score")
+	}
+	z := s.zsets[args[0]]
+	if z == nil {
+		z = make(map[string]int64)
+		s.zsets[args[0]] = z
+	}
+	_, exists := z[args[1]]
+	z[args[1]] = score
+	return okBool(!exists)
+}
+
+func (s *Server) zget(args []string) []string {
+	score, ok := s.zsets[args[0]][args[1]]
+	if !ok {
+		return notFound()
+	}
+	return okInt(score)
+}
+
+func (s *Server) zdel(args []string) []string {
+	z := s.zsets[args[0]]
+	_, exists := z[args[1]]
+	delete(z, args[1])
+	if len(z) == 0 {
+		delete(s.zsets, args[0])
+	}
+	return okBool(exists)
+}
+
+func (s *Server) zincr(args []string) []string {
+	by, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return clientError("invalid score")
+	}
+	score := s.zsets[args[0]][args[1]] + by
+	s.zset([]string{args[0], args[1], strconv.FormatInt(score, 10)})
+	return okInt(score)
+}
+
+func (s *Server) zexists(args []string) []string {
+	_, exists := s.zsets[args[0]][args[1]]
+	return okBool(exists)
+}
+
+func (s *Server) zsize(args []string) []string {
+	return okInt(int64(len(s.zsets[args[0]])))
+}
+
+type member struct {
+	key   string
+	score int64
+}
+
+// members returns the members of the sorted set name ordered by score and key.
+func (s *Server) members(name string) []member {
+	z := s.zsets[name]
+	ms := make([]member, 0, len(z))
+	for key, score := range z {
+		ms = append(ms, member{key, score})
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].score != ms[j].score {
+			return ms[i].score < ms[j].score
+		}
+		return ms[i].key < ms[j].key
+	})
+	return ms
+}
+
+// zscan returns the members after (score_start, key_start) with a score up to
+// score_end. Empty scores are unbounded.
+func (s *Server) zscan(args []string) []string {
+	limit, errReply := parseLimit(args[4])
+	if errReply != nil {
+		return errReply
+	}
+	keyStart := args[1]
+	scoreStart, startSet, errReply := parseScore(args[2])
+	if errReply != nil {
+		return errReply
+	}
+	scoreEnd, endSet, errReply := parseScore(args[3])
+	if errReply != nil {
+		return errReply
+	}
+
+	reply := ok()
+	n := 0
+	for _, m := range s.members(args[0]) {
+		if limit >= 0 && n >= limit {
+			break
+		}
+		if startSet && (m.score < scoreStart || m.score == scoreStart && keyStart != "" && m.key <= keyStart) {
+			continue
+		}
+		if endSet && m.score > scoreEnd {
+			break
+		}
+		reply = append(reply, m.key, strconv.FormatInt(m.score, 10))
+		n++
+	}
+	return reply
+}
+
+func (s *Server) zrange(args []string) []string {
+	return s.zrangeOf(args, s.members(args[0]))
+}
+
+// zrrange is zrange from the highest score.
+func (s *Server) zrrange(args []string) []string {
+	ms := s.members(args[0])
+	for i, j := 0, len(ms)-1; i < j; i, j = i+1, j-1 {
+		ms[i], ms[j] = ms[j], ms[i]
+	}
+	return s.zrangeOf(args, ms)
+}
+
+func (s *Server) zrangeOf(args []string, ms []member) []string {
+	offset, err := strconv.Atoi(args[1])
+	if err != nil || offset < 0 {
+		return clientError("invalid offset")
+	}
+	limit, errReply := parseLimit(args[2])
+	if errReply != nil {
+		return errReply
+	}
+	reply := ok()
+	for i := offset; i < len(ms) && (limit < 0 || i < offset+limit); i++ {
+		reply = append(reply, ms[i].key, strconv.FormatInt(ms[i].score, 10))
+	}
+	return reply
+}
+
+func (s *Server) zclear(args []string) []string {
+	n := len(s.zsets[args[0]])
+	delete(s.zsets, args[0])
+	return okInt(int64(n))
+}
+
+func (s *Server) multiZSet(args []string) []string {
+	var n int64
+	for i := 1; i < len(args); i += 2 {
+		reply := s.zset([]string{args[0], args[i], args[i+1]})
+		if reply[0] != "ok" {
+			return reply
+		}
+		if reply[1] == "1" {
+			n++
+		}
+	}
+	return okInt(n)
+}
+
+func (s *Server) multiZGet(args []string) []string {
+	z := s.zsets[args[0]]
+	reply := ok()
+	for _, key := range args[1:] {
+		if score, ok := z[key]; ok {
+			reply = append(reply, key, strconv.FormatInt(score, 10))
+		}
+	}
+	return reply
+}
+
+func (s *Server) multiZDel(args []string) []string {
+	var n int64
+	for _, key := range args[1:] {
+		if s.zdel([]string{args[0], key})[1] == "1" {
+			n++
+		}
+	}
+	return okInt(n)
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) queueNames() []string {
+	return sortedNames(s.queues)
+}
+
+func (s *Server) qpushBack(args []string) []string {
+	s.queues[args[0]] = append(s.queues[args[0]], args[1:]...)
+	return okInt(int64(len(s.queues[args[0]])))
+}
+
+func (s *Server) qpushFront(args []string) []string {
+	q := s.queues[args[0]]
+	for _, item := range args[1:] {
+		q = append([]string{item}, q...)
+	}
+	s.queues[args[0]] = q
+	return okInt(int64(len(q)))
+}
+
+func (s *Server) qpopFront(args []string) []string {
+	return s.qpop(args, true)
+}
+
+func (s *Server) qpopBack(args []string) []string {
+	return s.qpop(args, false)
+}
+
+func (s *Server) qpop(args []string, front bool) []string {
+	n, errReply := optInt(args, 1, 1)
+	if errReply != nil {
+		return errReply
+	}
+	q := s.queues[args[0]]
+	if len(q) == 0 {
+		return notFound()
+	}
+	reply := ok()
+	for ; n > 0 && len(q) > 0; n-- {
+		if front {
+			reply = append(reply, q[0])
+			q = q[1:]
+		} else {
+			reply = append(reply, q[len(q)-1])
+			q = q[:len(q)-1]
+		}
+	}
+	if len(q) == 0 {
+		delete(s.queues, args[0])
+	} else {
+		s.queues[args[0]] = q
+	}
+	return reply
+}
+
+func (s *Server) qfront(args []string) []string {
+	return s.qget([]string{args[0], "0"})
+}
+
+func (s *Server) qback(args []string) []string {
+	return s.qget([]string{args[0], "-1"})
+}
+
+func (s *Server) qsize(args []string) []string {
+	return okInt(int64(len(s.queues[args[0]])))
+}
+
+func (s *Server) qget(args []string) []string {
+	i, err := strconv.Atoi(args[1])
+	if err != nil {
+		return clientError("invalid index")
+	}
+	q := s.queues[args[0]]
+	if i < 0 {
+		i += len(q)
+	}
+	if i < 0 || i >= len(q) {
+		return notFound()
+	}
+	return ok(q[i])
+}
+
+// qrange returns limit items from offset; a negative offset counts from the
+// end of the queue.
+func (s *Server) qrange(args []string) []string {
+	offset, err := strconv.Atoi(args[1])
+	if err != nil {
+		return clientError("invalid offset")
+	}
+	limit, errReply := parseLimit(args[2])
+	if errReply != nil {
+		return errReply
+	}
+	q := s.queues[args[0]]
+	if offset < 0 {
+		offset += len(q)
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	reply := ok()
+	for i := offset; i < len(q) && (limit < 0 || i < offset+limit); i++ {
+		reply = append(reply, q[i])
+	}
+	return reply
+}
+
+func (s *Server) qclear(args []string) []string {
+	n := len(s.queues[args[0]])
+	delete(s.queues, args[0])
+	return okInt(int64(n))
+}
+
+//------------------------------------------------------------------------------
+
+// listNames implements hlist, zlist, qlist and their reverse variants.
+func listNames(names func(*Server) []string, reverse bool) func(*Server, []string) []string {
+	return func(s *Server, args []string) []string {
+		limit, errReply := parseLimit(args[2])
+		if errReply != nil {
+			return errReply
+		}
+		return append(ok(), keyRange(names(s), args[0], args[1], limit, reverse)...)
+	}
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyRange returns up to limit of the sorted keys in (start, end], or in
+// [end, start) in reverse order. Empty bounds are unbounded.
+func keyRange(keys []string, start, end string, limit int, reverse bool) []string {
+	var out []string
+	if !reverse {
+		for _, key := range keys {
+			if limit >= 0 && len(out) >= limit {
+				break
+			}
+			if start != "" && key <= start {
+				continue
+			}
+			if end != "" && key > end {
+				break
+			}
+			out = append(out, key)
+		}
+		return out
+	}
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		key := keys[i]
+		if limit >= 0 && len(out) >= limit {
+			break
+		}
+		if start != "" && key >= start {
+			continue
+		}
+		if end != "" && key < end {
+			break
+		}
+		out = append(out, key)
+	}
+	return out
+}
+
+func parseLimit(s string) (int, []string) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, clientError("invalid limit")
+	}
+	return n, nil
+}
+
+func parseScore(s string) (int64, bool, []string) {
+	if s == "" {
+		return 0, false, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false, clientError("invalid score")
+	}
+	return n, true, nil
+}
+
+// optInt parses the optional integer argument at index i.
+func optInt(args []string, i int, def int64) (int64, []string) {
+	if len(args) <= i {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(args[i], 10, 64)
+	if err != nil {
+		return 0, clientError("invalid integer")
+	}
+	return n, nil
+}
+
+func okBool(b bool) []string {
+	if b {
+		return ok("1")
+	}
+	return ok("0")
+}
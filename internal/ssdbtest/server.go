@@ -0,0 +1,200 @@
+// Package ssdbtest implements an in-memory SSDB server for the tests of the
+// client and of the tools built on it. It supports the key value, hash,
+// sorted set and queue commands with the range semantics of SSDB.
+package ssdbtest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ssdb-go/ssdb/internal/proto"
+)
+
+// Server is an in-memory SSDB server. It is safe for concurrent use.
+type Server struct {
+	mu      sync.Mutex
+	kv      map[string]string
+	expires map[string]time.Time
+	hashes  map[string]map[string]string
+	zsets   map[string]map[string]int64
+	queues  map[string][]string
+
+	// Now returns the current time used for the TTLs. It defaults to time.Now.
+	Now func() time.Time
+	// Hook, if set, is called with the arguments of every command before it
+	// is executed. A non-nil reply is sent instead of executing the command.
+	Hook func(args []string) []string
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	closed  bool
+}
+
+// NewServer returns an empty server.
+func NewServer() *Server {
+	s := &Server{
+		Now:   time.Now,
+		conns: make(map[net.Conn]struct{}),
+	}
+	s.reset()
+	return s
+}
+
+func (s *Server) reset() {
+	s.kv = make(map[string]string)
+	s.expires = make(map[string]time.Time)
+	s.hashes = make(map[string]map[string]string)
+	s.zsets = make(map[string]map[string]int64)
+	s.queues = make(map[string][]string)
+}
+
+// Dialer returns a dialer for ssdb.Options that connects the client to s
+// through an in-memory pipe.
+func (s *Server) Dialer() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		if !s.track(server) {
+			client.Close()
+			server.Close()
+			return nil, net.ErrClosed
+		}
+		go s.ServeConn(server)
+		return client, nil
+	}
+}
+
+// Serve accepts the connections of l and serves them until l is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn executes the commands read from conn until it is closed.
+func (s *Server) ServeConn(conn net.Conn) {
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	rd := proto.NewReader(conn)
+	bw := bufio.NewWriter(conn)
+	for {
+		v, err := rd.ReadReply()
+		if err != nil {
+			return
+		}
+		writeReply(bw, s.Do(v.([]string)...))
+		if rd.Buffered() > 0 {
+			continue // pipelined request
+		}
+		if err := bw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Close closes the connections being served.
+func (s *Server) Close() error {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.connsMu.Lock()
+	delete(s.conns, conn)
+	s.connsMu.Unlock()
+}
+
+func writeReply(bw *bufio.Writer, blocks []string) {
+	for _, block := range blocks {
+		bw.WriteString(strconv.Itoa(len(block)))
+		bw.WriteByte('\n')
+		bw.WriteString(block)
+		bw.WriteByte('\n')
+	}
+	bw.WriteByte('\n')
+}
+
+// Do executes the command args and returns the blocks of the reply, the
+// status first.
+func (s *Server) Do(args ...string) []string {
+	if len(args) == 0 {
+		return clientError("empty command")
+	}
+	if s.Hook != nil {
+		if reply := s.Hook(args); reply != nil {
+			return reply
+		}
+	}
+
+	cmd, ok := commands[toLower(args[0])]
+	if !ok {
+		return clientError("Unknown Command: " + args[0])
+	}
+	if len(args) < cmd.minArgs || cmd.maxArgs > 0 && len(args) > cmd.maxArgs ||
+		cmd.pairs && (len(args)-cmd.minArgs)%2 != 0 {
+		return clientError("wrong number of arguments")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cmd.fn(s, args[1:])
+}
+
+func toLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+func ok(vals ...string) []string {
+	return append([]string{"ok"}, vals...)
+}
+
+func okInt(n int64) []string {
+	return ok(strconv.FormatInt(n, 10))
+}
+
+func notFound() []string {
+	return []string{"not_found"}
+}
+
+func clientError(msg string) []string {
+	return []string{"client_error", msg}
+}
@@ -0,0 +1,98 @@
+package ssdbtest_test
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
+)
+
+func TestServer(t *testing.T) {
+	srv := ssdbtest.NewServer()
+	now := time.Unix(1000, 0)
+	srv.Now = func() time.Time { return now }
+
+	cases := []struct {
+		args  []string
+		reply []string
+	}{
+		{[]string{"multi_set", "a", "1", "b", "2", "c", "3"}, []string{"ok", "3"}},
+		{[]string{"scan", "a", "", "10"}, []string{"ok", "b", "2", "c", "3"}},
+		{[]string{"rscan", "", "b", "10"}, []string{"ok", "c", "3", "b", "2"}},
+		{[]string{"setx", "d", "4", "10"}, []string{"ok", "1"}},
+		{[]string{"ttl", "d"}, []string{"ok", "10"}},
+		{[]string{"ttl", "a"}, []string{"ok", "-1"}},
+		{[]string{"get", "missing"}, []string{"not_found"}},
+		{[]string{"multi_set", "a"}, []string{"client_error", "wrong number of arguments"}},
+		{[]string{"unknown"}, []string{"client_error", "Unknown Command: unknown"}},
+
+		{[]string{"multi_hset", "h", "x", "1", "y", "2"}, []string{"ok", "2"}},
+		{[]string{"hscan", "h", "x", "", "10"}, []string{"ok", "y", "2"}},
+		{[]string{"hlist", "", "", "10"}, []string{"ok", "h"}},
+
+		{[]string{"multi_zset", "z", "a", "2", "b", "1", "c", "2"}, []string{"ok", "3"}},
+		{[]string{"zscan", "z", "", "", "", "10"}, []string{"ok", "b", "1", "a", "2", "c", "2"}},
+		{[]string{"zscan", "z", "a", "2", "", "10"}, []string{"ok", "c", "2"}},
+		{[]string{"zrange", "z", "1", "1"}, []string{"ok", "a", "2"}},
+		{[]string{"zrrange", "z", "0", "1"}, []string{"ok", "c", "2"}},
+
+		{[]string{"qpush_back", "q", "1", "2", "3"}, []string{"ok", "3"}},
+		{[]string{"qrange", "q", "-2", "10"}, []string{"ok", "2", "3"}},
+		{[]string{"qpop_front", "q", "2"}, []string{"ok", "1", "2"}},
+		{[]string{"qlist", "", "", "10"}, []string{"ok", "q"}},
+
+		// 8 bytes of kv, 5 of hash, 28 of sorted set and 2 of queue.
+		{[]string{"dbsize"}, []string{"ok", "43"}},
+	}
+	for _, tc := range cases {
+		if reply := srv.Do(tc.args...); !reflect.DeepEqual(reply, tc.reply) {
+			t.Errorf("%q: got %q, wanted %q", tc.args, reply, tc.reply)
+		}
+	}
+
+	now = now.Add(10 * time.Second)
+	if reply := srv.Do("get", "d"); reply[0] != "not_found" {
+		t.Fatalf("got %q, wanted the key to expire", reply)
+	}
+}
+
+func TestServerConn(t *testing.T) {
+	srv := ssdbtest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: srv.Dialer(),
+	})
+	defer sdb.Close()
+
+	cmds, err := sdb.Pipelined(ctx, func(pipe ssdb.Pipeliner) error {
+		pipe.Do(ctx, "set", "key", "value")
+		pipe.Do(ctx, "get", "key")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := cmds[1].(*ssdb.Cmd).Val(); !reflect.DeepEqual(val, []string{"value"}) {
+		t.Fatalf("got %q", val)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l) //nolint:errcheck
+	defer l.Close()
+
+	tcp := ssdb.NewClient(&ssdb.Options{Addr: l.Addr().String()})
+	defer tcp.Close()
+	if err := tcp.Do(ctx, "get", "key").Err(); err != nil {
+		t.Fatal(err)
+	}
+}
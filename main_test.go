@@ -1,13 +1,17 @@
 package ssdb_test
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -357,3 +361,73 @@ func (h *hook) AfterProcessPipeline(ctx context.Context, cmds []ssdb.Cmder) erro
 	}
 	return nil
 }
+
+//------------------------------------------------------------------------------
+
+// fakeServerDialer returns a dialer that connects the client to an in-memory
+// server replying "ok" to every request.
+func fakeServerDialer() func(context.Context, string, string) (net.Conn, error) {
+	return fakeServerDialerFunc(func(args []string) []string {
+		return []string{"ok"}
+	})
+}
+
+// fakeServerDialerFunc returns a dialer that connects the client to an
+// in-memory server replying with the blocks returned by handle.
+func fakeServerDialerFunc(
+	handle func(args []string) []string,
+) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveFakeConn(server, handle)
+		return client, nil
+	}
+}
+
+func serveFakeConn(conn net.Conn, handle func(args []string) []string) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readFakeRequest(rd)
+		if err != nil {
+			return
+		}
+
+		var b []byte
+		for _, block := range handle(args) {
+			b = strconv.AppendInt(b, int64(len(block)), 10)
+			b = append(b, '\n')
+			b = append(b, block...)
+			b = append(b, '\n')
+		}
+		b = append(b, '\n')
+
+		if _, err := conn.Write(b); err != nil {
+			return
+		}
+	}
+}
+
+func readFakeRequest(rd *bufio.Reader) ([]string, error) {
+	var args []string
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return args, nil
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, err
+		}
+		block := make([]byte, n+1)
+		if _, err := io.ReadFull(rd, block); err != nil {
+			return nil, err
+		}
+		args = append(args, string(block[:n]))
+	}
+}
@@ -0,0 +1,364 @@
+package ssdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configNames are the names of the options that can be loaded from the
+// environment, a file or flags. They are the names of the URL query
+// parameters, plus the options set by the other parts of an URL.
+var configNames = []string{
+	"network",
+	"addr",
+	"username",
+	"password",
+	"db",
+	"max_retries",
+	"min_retry_backoff",
+	"max_retry_backoff",
+	"dial_timeout",
+	"read_timeout",
+	"write_timeout",
+	"command_timeouts",
+	"pool_fifo",
+	"pool_size",
+	"pool_timeout",
+	"pool_shed_load",
+	"pool_adaptive",
+	"min_pool_size",
+	"min_idle_conns",
+	"max_idle_conns",
+	"conn_max_idle_time",
+	"conn_max_lifetime",
+	"health_check_interval",
+	"read_only",
+	"role",
+	"tls",
+	"tls_ca",
+	"tls_cert",
+	"tls_key",
+	"tls_server_name",
+	"insecure_skip_verify",
+}
+
+// optionsFromValues builds and validates the Options described by values,
+// which are interpreted like the query parameters of ParseURL.
+func optionsFromValues(values url.Values) (*Options, error) {
+	q := queryOptions{q: values}
+	uo := &urlOptions{opt: new(Options)}
+
+	o := uo.opt
+	o.Network = q.string("network")
+	o.Addr = q.string("addr")
+	o.Username = q.string("username")
+
+	if err := setupParams(&q, uo); err != nil {
+		return nil, err
+	}
+	if uo.role == RoleReplica {
+		o.ReadOnly = true
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// OptionsFromEnv builds Options from the environment variables named after
+// the URL query parameters of ParseURL in upper case, with the given prefix.
+// For example, with the prefix "SSDB_":
+//
+//	SSDB_ADDR=localhost:8888 SSDB_PASSWORD=secret SSDB_POOL_SIZE=20 SSDB_READ_TIMEOUT=1s
+//
+// Besides the query parameters, NETWORK, ADDR, USERNAME and PASSWORD are
+// supported. The options are validated with Options.Validate.
+func OptionsFromEnv(prefix string) (*Options, error) {
+	values := make(url.Values)
+	for _, name := range configNames {
+		if v, ok := os.LookupEnv(prefix + strings.ToUpper(name)); ok {
+			values.Set(name, v)
+		}
+	}
+	return optionsFromValues(values)
+}
+
+// OptionsFromFile builds Options from a file with a flat set of options
+// named like the environment variables of OptionsFromEnv, in lower case.
+// The format is selected by the file extension: .json, or .toml for a
+// TOML-style file made of key = value lines. The YAML files are supported by
+// the extra/ssdbyaml module. Unknown options are reported as errors. The
+// options are validated with Options.Validate.
+func OptionsFromFile(path string) (*Options, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values url.Values
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		values, err = parseJSONConfig(b)
+	case ".toml":
+		values, err = parseTOMLConfig(b)
+	default:
+		return nil, fmt.Errorf("ssdb: unsupported config file extension: %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ssdb: invalid config file %s: %w", path, err)
+	}
+
+	return optionsFromValues(values)
+}
+
+func parseJSONConfig(b []byte) (url.Values, error) {
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return configValues(m)
+}
+
+// OptionsFromMap builds Options from a flat set of options named like the
+// options of OptionsFromFile, e.g. decoded from a configuration file in
+// another format. The values must be scalars. Unknown options are reported
+// as errors. The options are validated with Options.Validate.
+func OptionsFromMap(m map[string]interface{}) (*Options, error) {
+	values, err := configValues(m)
+	if err != nil {
+		return nil, fmt.Errorf("ssdb: invalid options: %w", err)
+	}
+	return optionsFromValues(values)
+}
+
+func configValues(m map[string]interface{}) (url.Values, error) {
+	values := make(url.Values, len(m))
+	for name, v := range m {
+		switch v := v.(type) {
+		case nil:
+			values.Set(name, "")
+		case string:
+			values.Set(name, v)
+		case bool, int, int64, uint64, float64, json.Number:
+			values.Set(name, fmt.Sprint(v))
+		default:
+			return nil, fmt.Errorf("%s: expected a scalar value, got %T", name, v)
+		}
+	}
+	return values, nil
+}
+
+// parseTOMLConfig parses the key = value lines of a TOML file. Tables and
+// arrays are not supported.
+func parseTOMLConfig(b []byte) (url.Values, error) {
+	values := make(url.Values)
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("line %d: expected key = value, got %q", lineNo, line)
+		}
+		name := strings.TrimSpace(line[:i])
+		value, err := parseTOMLValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		values.Set(name, value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func parseTOMLValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	switch s[0] {
+	case '"':
+		end := strings.LastIndexByte(s, '"')
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string: %s", s)
+		}
+		if err := checkTOMLComment(s[end+1:]); err != nil {
+			return "", err
+		}
+		return strconv.Unquote(s[:end+1])
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end == -1 {
+			return "", fmt.Errorf("unterminated string: %s", s)
+		}
+		if err := checkTOMLComment(s[end+2:]); err != nil {
+			return "", err
+		}
+		return s[1 : end+1], nil
+	}
+
+	if i := strings.IndexByte(s, '#'); i != -1 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+func checkTOMLComment(s string) error {
+	s = strings.TrimSpace(s)
+	if s != "" && s[0] != '#' {
+		return fmt.Errorf("unexpected %q after the string", s)
+	}
+	return nil
+}
+
+// FlagOptions defines a flag for each option supported by OptionsFromEnv,
+// named with the given prefix followed by the lower case option name, such
+// as -ssdb.addr or -ssdb.pool_size for the prefix "ssdb.". It returns a
+// function that builds the Options from the flags that were set; call it
+// after fs.Parse.
+func FlagOptions(fs *flag.FlagSet, prefix string) func() (*Options, error) {
+	flags := make(map[string]*string, len(configNames))
+	for _, name := range configNames {
+		flags[prefix+name] = fs.String(prefix+name, "", "ssdb "+name+" option")
+	}
+
+	return func() (*Options, error) {
+		values := make(url.Values)
+		fs.Visit(func(f *flag.Flag) {
+			if v, ok := flags[f.Name]; ok {
+				values.Set(strings.TrimPrefix(f.Name, prefix), *v)
+			}
+		})
+		return optionsFromValues(values)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// redacted replaces the secrets when the options are serialised.
+const redacted = "REDACTED"
+
+// optionsJSON is the serialised form of Options, using the names of
+// OptionsFromFile. Options that can't be serialised, such as the Dialer
+// or the TLS certificates, are left out.
+type optionsJSON struct {
+	Network             string `json:"network,omitempty"`
+	Addr                string `json:"addr,omitempty"`
+	Username            string `json:"username,omitempty"`
+	Password            string `json:"password,omitempty"`
+	DB                  int    `json:"db,omitempty"`
+	MaxRetries          int    `json:"max_retries,omitempty"`
+	MinRetryBackoff     string `json:"min_retry_backoff,omitempty"`
+	MaxRetryBackoff     string `json:"max_retry_backoff,omitempty"`
+	DialTimeout         string `json:"dial_timeout,omitempty"`
+	ReadTimeout         string `json:"read_timeout,omitempty"`
+	WriteTimeout        string `json:"write_timeout,omitempty"`
+	CommandTimeouts     string `json:"command_timeouts,omitempty"`
+	PoolFIFO            bool   `json:"pool_fifo,omitempty"`
+	PoolSize            int    `json:"pool_size,omitempty"`
+	PoolTimeout         string `json:"pool_timeout,omitempty"`
+	PoolShedLoad        bool   `json:"pool_shed_load,omitempty"`
+	PoolAdaptive        bool   `json:"pool_adaptive,omitempty"`
+	MinPoolSize         int    `json:"min_pool_size,omitempty"`
+	MinIdleConns        int    `json:"min_idle_conns,omitempty"`
+	MaxIdleConns        int    `json:"max_idle_conns,omitempty"`
+	ConnMaxIdleTime     string `json:"conn_max_idle_time,omitempty"`
+	ConnMaxLifetime     string `json:"conn_max_lifetime,omitempty"`
+	HealthCheckInterval string `json:"health_check_interval,omitempty"`
+	ReadOnly            bool   `json:"read_only,omitempty"`
+	TLS                 bool   `json:"tls,omitempty"`
+	TLSServerName       string `json:"tls_server_name,omitempty"`
+	InsecureSkipVerify  bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+func newOptionsJSON(opt *Options) *optionsJSON {
+	o := &optionsJSON{
+		Network:         opt.Network,
+		Addr:            opt.Addr,
+		Username:        opt.Username,
+		DB:              opt.DB,
+		MaxRetries:      opt.MaxRetries,
+		MinRetryBackoff: durationString(opt.MinRetryBackoff),
+		MaxRetryBackoff: durationString(opt.MaxRetryBackoff),
+		DialTimeout:     durationString(opt.DialTimeout),
+		ReadTimeout:     durationString(opt.ReadTimeout),
+		WriteTimeout:    durationString(opt.WriteTimeout),
+		CommandTimeouts: durationsString(opt.CommandTimeouts),
+		PoolFIFO:        opt.PoolFIFO,
+		PoolSize:        opt.PoolSize,
+		PoolTimeout:     durationString(opt.PoolTimeout),
+		PoolShedLoad:    opt.PoolShedLoad,
+		PoolAdaptive:    opt.PoolAdaptive,
+		MinPoolSize:     opt.MinPoolSize,
+		MinIdleConns:    opt.MinIdleConns,
+		MaxIdleConns:    opt.MaxIdleConns,
+		ConnMaxIdleTime: durationString(opt.ConnMaxIdleTime),
+		ConnMaxLifetime: durationString(opt.ConnMaxLifetime),
+
+		HealthCheckInterval: durationString(opt.HealthCheckInterval),
+		ReadOnly:            opt.ReadOnly,
+	}
+	if opt.Password != "" {
+		o.Password = redacted
+	}
+	if opt.TLSConfig != nil {
+		o.TLS = true
+		o.TLSServerName = opt.TLSConfig.ServerName
+		o.InsecureSkipVerify = opt.TLSConfig.InsecureSkipVerify
+	}
+	return o
+}
+
+// durationsString formats m like the command_timeouts parameter.
+func durationsString(m map[string]time.Duration) string {
+	pairs := make([]string, 0, len(m))
+	for _, name := range sortedKeys(m) {
+		value := m[name].String()
+		if m[name] == -1 {
+			value = "-1"
+		}
+		pairs = append(pairs, name+":"+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// MarshalJSON serialises the options with the names of OptionsFromFile.
+// The password is redacted.
+func (opt *Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newOptionsJSON(opt))
+}
+
+// MarshalJSON serialises the options like Options.MarshalJSON, with the
+// addresses and the role of the nodes.
+func (opt *ClusterOptions) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Addrs []string `json:"addrs"`
+		Role  Role     `json:"role,omitempty"`
+		*optionsJSON
+	}{opt.Addrs, opt.Role, newOptionsJSON(&opt.Options)})
+}
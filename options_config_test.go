@@ -0,0 +1,222 @@
+package ssdb
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("TEST_SSDB_ADDR", "db.local:8888")
+	t.Setenv("TEST_SSDB_PASSWORD", "secret")
+	t.Setenv("TEST_SSDB_POOL_SIZE", "20")
+	t.Setenv("TEST_SSDB_READ_TIMEOUT", "1s")
+	t.Setenv("TEST_SSDB_ROLE", "replica")
+
+	o, err := OptionsFromEnv("TEST_SSDB_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comprareOptions(t, o, &Options{
+		Addr:        "db.local:8888",
+		Password:    "secret",
+		PoolSize:    20,
+		ReadTimeout: time.Second,
+		ReadOnly:    true,
+	})
+
+	t.Setenv("TEST_SSDB_POOL_SIZE", "twenty")
+	if _, err := OptionsFromEnv("TEST_SSDB_"); err == nil {
+		t.Fatal("expected an error for an invalid pool size")
+	}
+}
+
+func TestOptionsFromFile(t *testing.T) {
+	want := &Options{
+		Addr:         "db.local:8888",
+		Password:     "secret",
+		PoolSize:     20,
+		MinIdleConns: 5,
+		ReadTimeout:  1500 * time.Millisecond,
+		DialTimeout:  3 * time.Second,
+		PoolFIFO:     true,
+	}
+
+	files := map[string]string{
+		"ssdb.json": `{
+	"addr": "db.local:8888",
+	"password": "secret",
+	"pool_size": 20,
+	"min_idle_conns": 5,
+	"read_timeout": "1.5s",
+	"dial_timeout": 3,
+	"pool_fifo": true
+}`,
+		"ssdb.toml": `
+# ssdb client
+addr = "db.local:8888"
+password = 'secret'
+pool_size = 20 # connections
+min_idle_conns = 5
+read_timeout = "1.5s"
+dial_timeout = 3
+pool_fifo = true
+`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			o, err := OptionsFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			comprareOptions(t, o, want)
+		})
+	}
+
+	path := filepath.Join(dir, "unknown.json")
+	if err := os.WriteFile(path, []byte(`{"adr": "db.local:8888"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OptionsFromFile(path); err == nil || err.Error() != "ssdb: unexpected option: adr" {
+		t.Fatalf("got %v, wanted an unexpected option error", err)
+	}
+}
+
+func TestOptionsFromMap(t *testing.T) {
+	o, err := OptionsFromMap(map[string]interface{}{
+		"addr":         "db.local:8888",
+		"pool_size":    20,
+		"read_timeout": "1.5s",
+		"pool_fifo":    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comprareOptions(t, o, &Options{
+		Addr:        "db.local:8888",
+		PoolSize:    20,
+		ReadTimeout: 1500 * time.Millisecond,
+		PoolFIFO:    true,
+	})
+
+	_, err = OptionsFromMap(map[string]interface{}{"addr": []string{"db.local:8888"}})
+	if err == nil || err.Error() != "ssdb: invalid options: addr: expected a scalar value, got []string" {
+		t.Fatalf("got %v, wanted a scalar value error", err)
+	}
+}
+
+func TestFlagOptions(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	load := FlagOptions(fs, "ssdb.")
+	if err := fs.Parse([]string{"-ssdb.addr", "db.local:8888", "-ssdb.max_retries=-1", "-ssdb.read_only=true"}); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	comprareOptions(t, o, &Options{Addr: "db.local:8888", MaxRetries: -1, ReadOnly: true})
+}
+
+func TestOptionsValidate(t *testing.T) {
+	o := &Options{
+		PoolSize:        10,
+		MinIdleConns:    20,
+		ReadTimeout:     -2 * time.Second,
+		MinRetryBackoff: time.Second,
+		MaxRetryBackoff: time.Millisecond,
+		Network:         "udp",
+		CommandTimeouts: map[string]time.Duration{"scan": -time.Second},
+	}
+
+	err := o.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`invalid network "udp"`,
+		"read_timeout must not be negative",
+		"min_retry_backoff 1s is greater than max_retry_backoff 1ms",
+		"min_idle_conns 20 is greater than pool_size 10",
+		"command_timeouts: scan must not be negative",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q does not contain %q", err, want)
+		}
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 5 {
+		t.Errorf("got %d errors, wanted 5", n)
+	}
+
+	if err := (&Options{ReadTimeout: -1, MaxRetries: -1}).Validate(); err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+}
+
+func TestOptionsMarshalJSON(t *testing.T) {
+	o, err := ParseURL("ssdbs://:secret@db.local:8888/2?read_timeout=-1&pool_size=5&command_timeouts=scan:10s,compact:-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Fatalf("password is not redacted: %s", b)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"network":         "tcp",
+		"addr":            "db.local:8888",
+		"password":        redacted,
+		"db":              2.0,
+		"read_timeout":    "-1ns",
+		"pool_size":       5.0,
+		"tls":             true,
+		"tls_server_name": "db.local",
+
+		"command_timeouts": "compact:-1,scan:10s",
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s: got %v, wanted %v", k, m[k], v)
+		}
+	}
+
+	// The serialised options can be loaded back.
+	path := filepath.Join(t.TempDir(), "ssdb.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := OptionsFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ReadTimeout != -1 || loaded.DB != 2 || loaded.TLSConfig == nil ||
+		!reflect.DeepEqual(loaded.CommandTimeouts, o.CommandTimeouts) {
+		t.Fatalf("got %+v", loaded)
+	}
+
+	if err := loaded.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,8 +4,22 @@
 package ssdb
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -72,6 +86,12 @@ func TestParseURL(t *testing.T) {
 			// invalid db format
 			url: "unix://foo:bar@/tmp/ssdb.sock?db=test",
 			err: errors.New(`ssdb: invalid database number: strconv.Atoi: parsing "test": invalid syntax`),
+		}, {
+			url: "ssdb://localhost:123/?pool_size=50&pool_adaptive=true&min_pool_size=5&pool_shed_load=1",
+			o:   &Options{Addr: "localhost:123", PoolSize: 50, PoolAdaptive: true, MinPoolSize: 5, PoolShedLoad: true},
+		}, {
+			url: "ssdb://localhost:123/?min_idle_conns=2&health_check_interval=30s",
+			o:   &Options{Addr: "localhost:123", MinIdleConns: 2, HealthCheckInterval: 30 * time.Second},
 		}, {
 			// invalid int value
 			url: "ssdb://localhost/?pool_size=five",
@@ -105,6 +125,43 @@ func TestParseURL(t *testing.T) {
 		}, {
 			url: "ssdb://localhost/iamadatabase",
 			err: errors.New(`ssdb: invalid database number: "iamadatabase"`),
+		}, {
+			url: "ssdb://localhost:123?password=bar&read_only=true",
+			o:   &Options{Addr: "localhost:123", Password: "bar", ReadOnly: true},
+		}, {
+			url: "ssdb://:bar@localhost:123?password=baz",
+			err: errors.New("ssdb: password is set in both the userinfo and the query"),
+		}, {
+			url: "ssdb://localhost:123?role=replica",
+			o:   &Options{Addr: "localhost:123", ReadOnly: true},
+		}, {
+			url: "ssdb://localhost:123?role=slave",
+			err: errors.New(`ssdb: invalid role: "slave"`),
+		}, {
+			url: "ssdb://localhost:123?tls_server_name=ssdb.local&insecure_skip_verify=true",
+			o:   &Options{Addr: "localhost:123", TLSConfig: &tls.Config{ /* no deep comparison */ }},
+		}, {
+			url: "ssdb://localhost:123?tls_cert=cert.pem",
+			err: errors.New("ssdb: tls_cert and tls_key must be set together"),
+		}, {
+			url: "unix:///tmp/ssdb.sock?insecure_skip_verify=true",
+			err: errors.New("ssdb: TLS parameters require a tcp connection"),
+		}, {
+			url: "ssdb://a:8888,b:8888",
+			err: errors.New("ssdb: URL has multiple hosts, use ParseClusterURL or ParseFailoverURL"),
+		}, {
+			url: "ssdb://localhost:123?command_timeouts=scan:10s,Compact:-1,get:2",
+			o: &Options{Addr: "localhost:123", CommandTimeouts: map[string]time.Duration{
+				"scan":    10 * time.Second,
+				"compact": -1,
+				"get":     2 * time.Second,
+			}},
+		}, {
+			url: "ssdb://localhost:123?command_timeouts=scan",
+			err: errors.New(`ssdb: invalid command_timeouts: expected name:duration, got "scan"`),
+		}, {
+			url: "ssdb://localhost:123?command_timeouts=scan:ten",
+			err: errors.New(`ssdb: invalid command_timeouts duration for scan: time: invalid duration "ten"`),
 		},
 	}
 
@@ -144,6 +201,9 @@ func comprareOptions(t *testing.T, actual, expected *Options) {
 	if actual.TLSConfig != nil && expected.TLSConfig == nil {
 		t.Errorf("got TLSConfig, expected no TLSConfig")
 	}
+	if actual.ReadOnly != expected.ReadOnly {
+		t.Errorf("ReadOnly: got %v, expected %v", actual.ReadOnly, expected.ReadOnly)
+	}
 	if actual.Username != expected.Username {
 		t.Errorf("Username: got %q, expected %q", actual.Username, expected.Username)
 	}
@@ -168,9 +228,24 @@ func comprareOptions(t *testing.T, actual, expected *Options) {
 	if actual.WriteTimeout != expected.WriteTimeout {
 		t.Errorf("WriteTimeout: got %v, expected %v", actual.WriteTimeout, expected.WriteTimeout)
 	}
+	if !reflect.DeepEqual(actual.CommandTimeouts, expected.CommandTimeouts) {
+		t.Errorf("CommandTimeouts: got %v, expected %v", actual.CommandTimeouts, expected.CommandTimeouts)
+	}
 	if actual.PoolFIFO != expected.PoolFIFO {
 		t.Errorf("PoolFIFO: got %v, expected %v", actual.PoolFIFO, expected.PoolFIFO)
 	}
+	if actual.HealthCheckInterval != expected.HealthCheckInterval {
+		t.Errorf("HealthCheckInterval: got %v, expected %v", actual.HealthCheckInterval, expected.HealthCheckInterval)
+	}
+	if actual.PoolShedLoad != expected.PoolShedLoad {
+		t.Errorf("PoolShedLoad: got %v, expected %v", actual.PoolShedLoad, expected.PoolShedLoad)
+	}
+	if actual.PoolAdaptive != expected.PoolAdaptive {
+		t.Errorf("PoolAdaptive: got %v, expected %v", actual.PoolAdaptive, expected.PoolAdaptive)
+	}
+	if actual.MinPoolSize != expected.MinPoolSize {
+		t.Errorf("MinPoolSize: got %v, expected %v", actual.MinPoolSize, expected.MinPoolSize)
+	}
 	if actual.PoolSize != expected.PoolSize {
 		t.Errorf("PoolSize: got %v, expected %v", actual.PoolSize, expected.PoolSize)
 	}
@@ -214,3 +289,149 @@ func TestReadTimeoutOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestLogHandlerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient(&Options{
+		Addr:       "fake:8888",
+		LogHandler: slog.NewTextHandler(&buf, nil),
+	})
+	defer client.Close()
+
+	conn := client.Conn()
+	defer conn.Close()
+	for _, c := range []cmdable{client.cmdable, client.Pipeline().(*Pipeline).cmdable, conn.cmdable} {
+		buf.Reset()
+		if got := c.formatSec(context.Background(), time.Millisecond); got != 1 {
+			t.Fatalf("got %d, wanted 1", got)
+		}
+		if !strings.Contains(buf.String(), "duration is truncated") || !strings.Contains(buf.String(), "addr=fake:8888") {
+			t.Fatalf("got %q, wanted the truncation logged by the client logger", buf.String())
+		}
+	}
+}
+
+func TestParseURLTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	o, err := ParseURL("ssdb://localhost:123?tls_ca=" + certFile +
+		"&tls_cert=" + certFile + "&tls_key=" + keyFile + "&tls_server_name=ssdb.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := o.TLSConfig
+	if conf == nil {
+		t.Fatal("got nil TLSConfig")
+	}
+	if conf.ServerName != "ssdb.local" {
+		t.Errorf("ServerName: got %q, expected %q", conf.ServerName, "ssdb.local")
+	}
+	if conf.RootCAs == nil {
+		t.Error("got nil RootCAs")
+	}
+	if len(conf.Certificates) != 1 {
+		t.Errorf("got %d certificates, expected 1", len(conf.Certificates))
+	}
+
+	o, err = ParseURL("ssdbs://ssdb.local:123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.TLSConfig.ServerName != "ssdb.local" {
+		t.Errorf("ServerName: got %q, expected %q", o.TLSConfig.ServerName, "ssdb.local")
+	}
+
+	if _, err := ParseURL("ssdb://localhost:123?tls_ca=" + keyFile); err == nil {
+		t.Error("expected an error for a CA file without certificate")
+	}
+}
+
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ssdb.local"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestParseClusterURL(t *testing.T) {
+	o, err := ParseClusterURL("ssdbs://:bar@a:123,b,c:456?role=replica&read_timeout=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a:123", "b:8888", "c:456"}; !reflect.DeepEqual(o.Addrs, want) {
+		t.Fatalf("Addrs: got %q, expected %q", o.Addrs, want)
+	}
+	if o.Role != RoleReplica {
+		t.Errorf("Role: got %q, expected %q", o.Role, RoleReplica)
+	}
+
+	node := o.NodeOptions("b:8888")
+	comprareOptions(t, node, &Options{
+		Addr:        "b:8888",
+		Password:    "bar",
+		ReadTimeout: 2 * time.Second,
+		ReadOnly:    true,
+		TLSConfig:   &tls.Config{},
+	})
+	if node.TLSConfig.ServerName != "b" {
+		t.Errorf("ServerName: got %q, expected %q", node.TLSConfig.ServerName, "b")
+	}
+	if o.TLSConfig.ServerName != "" {
+		t.Error("NodeOptions must not modify the shared TLSConfig")
+	}
+
+	anyOpt, err := ParseClusterURL("ssdb://a:123,b:456?role=any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anyOpt.Role != RoleAny || anyOpt.NodeOptions("b:456").ReadOnly {
+		t.Errorf("got role %q, read only %v, expected %q, writable", anyOpt.Role, anyOpt.NodeOptions("b:456").ReadOnly, RoleAny)
+	}
+
+	if _, err := ParseClusterURL("ssdb://a:123,,b:456"); err == nil {
+		t.Error("expected an error for an empty host")
+	}
+}
+
+func TestParseFailoverURL(t *testing.T) {
+	o, err := ParseFailoverURL("ssdb://primary:8888,standby:8888/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"primary:8888", "standby:8888"}; !reflect.DeepEqual(o.Addrs, want) {
+		t.Fatalf("Addrs: got %q, expected %q", o.Addrs, want)
+	}
+	if o.Role != RoleMaster {
+		t.Errorf("Role: got %q, expected %q", o.Role, RoleMaster)
+	}
+	comprareOptions(t, o.NodeOptions("standby:8888"), &Options{Addr: "standby:8888", DB: 2})
+}
@@ -2,6 +2,7 @@ package ssdb
 
 import (
 	"context"
+	"log/slog"
 	"sync"
 )
 
@@ -43,8 +44,8 @@ type Pipeline struct {
 	cmds []Cmder
 }
 
-func (c *Pipeline) init() {
-	c.cmdable = c.Process
+func (c *Pipeline) init(logger *slog.Logger) {
+	c.cmdable = cmdable{process: c.Process, logger: logger}
 	c.statefulCmdable = c.Process
 }
 
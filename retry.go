@@ -0,0 +1,117 @@
+package ssdb
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/ssdb-go/ssdb/internal"
+	"github.com/ssdb-go/ssdb/internal/rand"
+)
+
+// RetryPolicy decides whether a failed command is retried and how long the
+// client waits before the next attempt. The number of attempts is still
+// limited by Options.MaxRetries.
+//
+// A RetryPolicy is shared by all the goroutines using the client, so it
+// must be safe for concurrent use.
+type RetryPolicy interface {
+	// ShouldRetry reports whether cmd should be retried after the attempt
+	// numbered attempt (starting at 0) failed with err.
+	ShouldRetry(cmd Cmder, err error, attempt int) bool
+	// Backoff returns the time to wait before the attempt numbered
+	// attempt (starting at 1).
+	Backoff(attempt int) time.Duration
+}
+
+// isIdempotentCmd reports whether cmd can be executed twice. Unknown
+// commands are assumed not to be.
+func isIdempotentCmd(cmd Cmder) bool {
+	info := cmd.Info()
+	return info != nil && info.Idempotent
+}
+
+// DefaultShouldRetry is the retry decision used by the policies of this
+// package. It retries the network errors and, unless the command has its
+// own read timeout, the read timeouts. The errors replied by the server,
+// e.g. *ServerError, are never retried: SSDB has no status for a transient
+// failure. Non-idempotent commands, such as incr, qpush and zincr, are only
+// retried when the error guarantees that the command was never written to
+// the server.
+func DefaultShouldRetry(cmd Cmder, err error, attempt int) bool {
+	if !shouldRetry(err, cmd.readTimeout() == nil) {
+		return false
+	}
+	if isIdempotentCmd(cmd) {
+		return true
+	}
+	return isNotSentError(err)
+}
+
+// isNotSentError reports whether err happened before the command could
+// be written to the server.
+func isNotSentError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// ExponentialJitterRetry is a RetryPolicy that waits a random time between
+// MinBackoff and an exponentially growing limit, capped at MaxBackoff.
+// It is the default policy built from Options.MinRetryBackoff and
+// Options.MaxRetryBackoff.
+type ExponentialJitterRetry struct {
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var _ RetryPolicy = (*ExponentialJitterRetry)(nil)
+
+func (r *ExponentialJitterRetry) ShouldRetry(cmd Cmder, err error, attempt int) bool {
+	return DefaultShouldRetry(cmd, err, attempt)
+}
+
+func (r *ExponentialJitterRetry) Backoff(attempt int) time.Duration {
+	return internal.RetryBackoff(attempt, r.MinBackoff, r.MaxBackoff)
+}
+
+// DecorrelatedJitterRetry is a RetryPolicy that waits a random time between
+// BaseBackoff and three times the previous backoff, capped at MaxBackoff.
+type DecorrelatedJitterRetry struct {
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+var _ RetryPolicy = (*DecorrelatedJitterRetry)(nil)
+
+func (r *DecorrelatedJitterRetry) ShouldRetry(cmd Cmder, err error, attempt int) bool {
+	return DefaultShouldRetry(cmd, err, attempt)
+}
+
+// Backoff does not keep the previous backoff, because the policy is shared
+// by concurrent commands; it replays the random walk up to attempt instead.
+func (r *DecorrelatedJitterRetry) Backoff(attempt int) time.Duration {
+	if r.BaseBackoff <= 0 {
+		return 0
+	}
+
+	d := r.BaseBackoff
+	for i := 0; i < attempt; i++ {
+		upper := 3 * d
+		if upper < d {
+			upper = d // overflow
+		}
+		if upper <= r.BaseBackoff {
+			upper = r.BaseBackoff + 1
+		}
+		d = r.BaseBackoff + time.Duration(rand.Int63n(int64(upper-r.BaseBackoff)))
+		if r.MaxBackoff > 0 && d > r.MaxBackoff {
+			d = r.MaxBackoff
+		}
+	}
+	return d
+}
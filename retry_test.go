@@ -0,0 +1,100 @@
+package ssdb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ssdb-go/ssdb/internal/pool"
+)
+
+func TestDefaultShouldRetry(t *testing.T) {
+	ctx := context.Background()
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	timeoutErr := &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	cases := []struct {
+		cmd  Cmder
+		err  error
+		want bool
+	}{
+		{NewCmd(ctx, "get", "key"), io.EOF, true},
+		{NewCmd(ctx, "get", "key"), context.Canceled, false},
+		{NewCmd(ctx, "get", "key"), Nil, false},
+		{NewCmd(ctx, "set", "key", "value"), io.EOF, true},
+		{NewCmd(ctx, "incr", "key"), io.EOF, false},
+		{NewCmd(ctx, "qpush", "queue", "value"), io.EOF, false},
+		{NewCmd(ctx, "zincr", "zset", "key", 1), io.EOF, false},
+		{NewCmd(ctx, "incr", "key"), dialErr, true},
+		{NewCmd(ctx, "get", "key"), &pool.TimeoutError{Timeout: time.Second}, false},
+		{NewCmd(ctx, "get", "key"), &ServerError{Status: "error", Cmd: "get"}, false},
+		{NewCmd(ctx, "get", "key"), &ProtocolError{Msg: "invalid reply"}, false},
+		{NewCmd(ctx, "get", "key"), &NetworkError{Op: "read", Err: io.EOF}, true},
+		{NewCmd(ctx, "get", "key"), &NetworkError{Op: "read", Err: timeoutErr}, true},
+		{NewCmd(ctx, "incr", "key"), &NetworkError{Op: "dial", Err: dialErr}, true},
+	}
+
+	for _, tc := range cases {
+		if got := DefaultShouldRetry(tc.cmd, tc.err, 0); got != tc.want {
+			t.Errorf("%s after %v: got %v, wanted %v", tc.cmd.Name(), tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestExponentialJitterRetryBackoff(t *testing.T) {
+	r := &ExponentialJitterRetry{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	for attempt := 1; attempt < 10; attempt++ {
+		d := r.Backoff(attempt)
+		if d < r.MinBackoff || d > r.MaxBackoff {
+			t.Fatalf("attempt %d: backoff %v out of [%v, %v]", attempt, d, r.MinBackoff, r.MaxBackoff)
+		}
+	}
+}
+
+func TestDecorrelatedJitterRetryBackoff(t *testing.T) {
+	r := &DecorrelatedJitterRetry{BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	for attempt := 1; attempt < 100; attempt++ {
+		d := r.Backoff(attempt)
+		if d < r.BaseBackoff || d > r.MaxBackoff {
+			t.Fatalf("attempt %d: backoff %v out of [%v, %v]", attempt, d, r.BaseBackoff, r.MaxBackoff)
+		}
+	}
+
+	if d := (&DecorrelatedJitterRetry{}).Backoff(3); d != 0 {
+		t.Fatalf("got %v, wanted no backoff", d)
+	}
+}
+
+type countingRetryPolicy struct {
+	ExponentialJitterRetry
+	calls int
+}
+
+func (r *countingRetryPolicy) ShouldRetry(cmd Cmder, err error, attempt int) bool {
+	r.calls++
+	return r.ExponentialJitterRetry.ShouldRetry(cmd, err, attempt)
+}
+
+func TestRetryPolicyOption(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	policy := new(countingRetryPolicy)
+	client := NewClient(&Options{
+		MaxRetries:  2,
+		RetryPolicy: policy,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, dialErr
+		},
+	})
+	defer client.Close()
+
+	if err := client.Ping(context.Background()).Err(); !errors.Is(err, dialErr) {
+		t.Fatalf("got %v, wanted %v", err, dialErr)
+	}
+	if policy.calls != 3 {
+		t.Fatalf("ShouldRetry called %d times, wanted 3", policy.calls)
+	}
+}
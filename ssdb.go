@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
+	"net"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -16,7 +18,10 @@ import (
 // Nil reply returned by Ssdb when key does not exist.
 const Nil = proto.Nil
 
-// SetLogger set custom log
+// SetLogger set custom log.
+//
+// Deprecated: the clients log with log/slog and no longer use the logger
+// set by SetLogger. Use Options.Logger or Options.LogHandler instead.
 func SetLogger(logger internal.Logging) {
 	internal.Logger = logger
 }
@@ -124,9 +129,163 @@ func (hs hooks) processTxPipeline(
 
 //------------------------------------------------------------------------------
 
+// CloseReason describes why a connection was closed.
+type CloseReason = pool.CloseReason
+
+const (
+	CloseReasonIdle        = pool.CloseReasonIdle
+	CloseReasonLifetime    = pool.CloseReasonLifetime
+	CloseReasonBadConn     = pool.CloseReasonBadConn
+	CloseReasonOverflow    = pool.CloseReasonOverflow
+	CloseReasonPoolClosed  = pool.CloseReasonPoolClosed
+	CloseReasonHealthCheck = pool.CloseReasonHealthCheck
+)
+
+// ConnInfo describes the connection passed to a ConnHook.
+type ConnInfo struct {
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+	CreatedAt  time.Time
+	// BytesRead and BytesWritten are the numbers of bytes read from and
+	// written to the connection since it was created.
+	BytesRead    uint64
+	BytesWritten uint64
+}
+
+func newConnInfo(cn *pool.Conn) ConnInfo {
+	return ConnInfo{
+		LocalAddr:    cn.LocalAddr(),
+		RemoteAddr:   cn.RemoteAddr(),
+		CreatedAt:    cn.CreatedAt(),
+		BytesRead:    cn.BytesRead(),
+		BytesWritten: cn.BytesWritten(),
+	}
+}
+
+// ConnHook observes the lifecycle of the connections used by a client.
+// Hooks may be called concurrently from different goroutines, including
+// the background goroutines of the connection pool.
+type ConnHook interface {
+	// DialStart is called before a new connection is dialed.
+	// The returned context is passed to DialFinish.
+	DialStart(ctx context.Context, network, addr string) context.Context
+	// DialFinish is called when the dial completes. remoteAddr is nil if
+	// the dial failed.
+	DialFinish(ctx context.Context, network, addr string, remoteAddr net.Addr, err error)
+
+	// ConnCheckout is called when a connection is taken from the pool.
+	ConnCheckout(ctx context.Context, cn ConnInfo)
+	// ConnReturn is called when a connection is given back to the pool.
+	ConnReturn(ctx context.Context, cn ConnInfo)
+	// ConnClose is called when the pool closes a connection.
+	ConnClose(cn ConnInfo, reason CloseReason, err error)
+
+	// AuthResult is called after the connection sent the auth command.
+	// err is nil if the server accepted the password.
+	AuthResult(ctx context.Context, cn ConnInfo, err error)
+}
+
+// NoopConnHook implements ConnHook with methods that do nothing. Embed it to
+// implement only the methods of interest.
+type NoopConnHook struct{}
+
+var _ ConnHook = NoopConnHook{}
+
+func (NoopConnHook) DialStart(ctx context.Context, network, addr string) context.Context {
+	return ctx
+}
+
+func (NoopConnHook) DialFinish(ctx context.Context, network, addr string, remoteAddr net.Addr, err error) {
+}
+
+func (NoopConnHook) ConnCheckout(ctx context.Context, cn ConnInfo)          {}
+func (NoopConnHook) ConnReturn(ctx context.Context, cn ConnInfo)            {}
+func (NoopConnHook) ConnClose(cn ConnInfo, reason CloseReason, err error)   {}
+func (NoopConnHook) AuthResult(ctx context.Context, cn ConnInfo, err error) {}
+
+// connHooks is shared by the client, its clones and its connection pool,
+// so hooks added later are seen by the pool as well.
+type connHooks struct {
+	mu    sync.Mutex
+	hooks atomic.Value // []ConnHook
+}
+
+func (hs *connHooks) add(hook ConnHook) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	old := hs.get()
+	hooks := make([]ConnHook, 0, len(old)+1)
+	hooks = append(hooks, old...)
+	hooks = append(hooks, hook)
+	hs.hooks.Store(hooks)
+}
+
+func (hs *connHooks) get() []ConnHook {
+	if hs == nil {
+		return nil
+	}
+	hooks, _ := hs.hooks.Load().([]ConnHook)
+	return hooks
+}
+
+func (hs *connHooks) dial(
+	ctx context.Context, network, addr string,
+	fn func(ctx context.Context, network, addr string) (net.Conn, error),
+) (net.Conn, error) {
+	hooks := hs.get()
+	if len(hooks) == 0 {
+		return fn(ctx, network, addr)
+	}
+
+	for _, hook := range hooks {
+		ctx = hook.DialStart(ctx, network, addr)
+	}
+
+	conn, err := fn(ctx, network, addr)
+
+	var remoteAddr net.Addr
+	if conn != nil {
+		remoteAddr = conn.RemoteAddr()
+	}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].DialFinish(ctx, network, addr, remoteAddr, err)
+	}
+
+	return conn, err
+}
+
+func (hs *connHooks) checkout(ctx context.Context, cn *pool.Conn) {
+	for _, hook := range hs.get() {
+		hook.ConnCheckout(ctx, newConnInfo(cn))
+	}
+}
+
+func (hs *connHooks) giveBack(ctx context.Context, cn *pool.Conn) {
+	for _, hook := range hs.get() {
+		hook.ConnReturn(ctx, newConnInfo(cn))
+	}
+}
+
+func (hs *connHooks) close(cn *pool.Conn, reason CloseReason, err error) {
+	for _, hook := range hs.get() {
+		hook.ConnClose(newConnInfo(cn), reason, err)
+	}
+}
+
+func (hs *connHooks) auth(ctx context.Context, cn *pool.Conn, err error) {
+	for _, hook := range hs.get() {
+		hook.AuthResult(ctx, newConnInfo(cn), err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
 type baseClient struct {
-	opt      *Options
-	connPool pool.Pooler
+	opt       *Options
+	connPool  pool.Pooler
+	connHooks *connHooks
+	logger    *slog.Logger
 
 	onClose func() error // hook called when client is closed
 }
@@ -135,10 +294,12 @@ func (c *baseClient) Pool() pool.Pooler {
 	return c.connPool
 }
 
-func newBaseClient(opt *Options, connPool pool.Pooler) *baseClient {
+func newBaseClient(opt *Options, connPool pool.Pooler, connHooks *connHooks) *baseClient {
 	return &baseClient{
-		opt:      opt,
-		connPool: connPool,
+		opt:       opt,
+		connPool:  connPool,
+		connHooks: connHooks,
+		logger:    opt.logger(),
 	}
 }
 
@@ -208,9 +369,6 @@ func (c *baseClient) _getConn(ctx context.Context) (*pool.Conn, error) {
 
 	if err := c.initConn(ctx, cn); err != nil {
 		c.connPool.Remove(ctx, cn, err)
-		if err := errors.Unwrap(err); err != nil {
-			return nil, err
-		}
 		return nil, err
 	}
 
@@ -223,22 +381,10 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 	}
 	cn.Inited = true
 
-	username, password := c.opt.Username, c.opt.Password
-	if c.opt.CredentialsProvider != nil {
-		username, password = c.opt.CredentialsProvider()
-	}
-
-	connPool := pool.NewSingleConnPool(c.connPool, cn)
-	conn := newConn(c.opt, connPool)
+	conn := c.internalConn(pool.NewSingleConnPool(c.connPool, cn))
 
-	// For ssdb-server < 6.0 that does not support the Hello command,
-	// we continue to provide services with RESP2.
-	if password != "" {
-		if err := conn.AuthACL(ctx, username, password).Err(); err == nil {
-			//auth := true
-		} else if !strings.HasPrefix(err.Error(), "ERR unknown command") {
-			return err
-		}
+	if err := c.auth(ctx, cn, conn); err != nil {
+		return err
 	}
 
 	if c.opt.OnConnect != nil {
@@ -247,18 +393,112 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 	return nil
 }
 
+// healthCheck returns the check that the pool runs on the idle connections:
+// it initialises the connections dialed in the background and sends them
+// the version command.
+func healthCheck(opt *Options, connHooks *connHooks) func(context.Context, *pool.Conn) error {
+	return func(ctx context.Context, cn *pool.Conn) error {
+		c := newBaseClient(opt, pool.NewSingleConnPool(nil, cn), connHooks)
+		return c.checkConn(ctx, cn)
+	}
+}
+
+// checkConn initialises cn if needed and checks that the server replies to
+// the version command.
+func (c *baseClient) checkConn(ctx context.Context, cn *pool.Conn) error {
+	if err := c.initConn(ctx, cn); err != nil {
+		return err
+	}
+	conn := c.internalConn(pool.NewSingleConnPool(c.connPool, cn))
+	return conn.Ping(ctx).Err()
+}
+
+// internalConn returns a Conn for the commands that the client sends on its
+// own connections: auth, OnConnect and the health checks. They bypass
+// opt.Limiter, which only limits the commands of the users; otherwise a
+// limiter that rejects a command could also reject the auth of the
+// connection the command waits for.
+func (c *baseClient) internalConn(connPool pool.Pooler) *Conn {
+	opt := c.opt
+	if opt.Limiter != nil {
+		opt = opt.clone()
+		opt.Limiter = nil
+	}
+	return newConn(opt, connPool, c.connHooks, c.logger)
+}
+
+func (c *baseClient) password(ctx context.Context) (string, error) {
+	if c.opt.CredentialsProviderContext != nil {
+		_, password, err := c.opt.CredentialsProviderContext(ctx)
+		return password, err
+	}
+	if c.opt.CredentialsProvider != nil {
+		_, password := c.opt.CredentialsProvider()
+		return password, nil
+	}
+	return c.opt.Password, nil
+}
+
+// auth authenticates cn, which conn wraps, if a password is configured.
+func (c *baseClient) auth(ctx context.Context, cn *pool.Conn, conn *Conn) error {
+	password, err := c.password(ctx)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return nil
+	}
+	return c.authenticate(ctx, cn, conn, password)
+}
+
+// reauth authenticates cn again after the server replied noauth, e.g.
+// because the password was changed.
+func (c *baseClient) reauth(ctx context.Context, cn *pool.Conn, noAuthErr error) error {
+	password, err := c.password(ctx)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return noAuthErr
+	}
+
+	conn := c.internalConn(pool.NewSingleConnPool(c.connPool, cn))
+	return c.authenticate(ctx, cn, conn, password)
+}
+
+func (c *baseClient) authenticate(ctx context.Context, cn *pool.Conn, conn *Conn, password string) error {
+	err := conn.Auth(ctx, password).Err()
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		err = &AuthError{Status: serverErr.Status, Message: serverErr.Message}
+	}
+
+	c.connHooks.auth(ctx, cn, err)
+	return err
+}
+
 func (c *baseClient) releaseConn(ctx context.Context, cn *pool.Conn, err error) {
 	if c.opt.Limiter != nil {
 		c.opt.Limiter.ReportResult(err)
 	}
 
-	if isBadConn(err, false, c.opt.Addr) {
+	if isBadConnAfter(cn, err) {
 		c.connPool.Remove(ctx, cn, err)
 	} else {
 		c.connPool.Put(ctx, cn)
 	}
 }
 
+// isBadConnAfter reports whether cn can't be reused after the command
+// failed with err. A connection interrupted by its context is reused when
+// the reply of its last request was fully read.
+func isBadConnAfter(cn *pool.Conn, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return cn.Pending()
+	}
+	return isBadConn(err, false)
+}
+
 func (c *baseClient) withConn(
 	ctx context.Context, fn func(context.Context, *pool.Conn) error,
 ) error {
@@ -271,30 +511,27 @@ func (c *baseClient) withConn(
 		c.releaseConn(ctx, cn, err)
 	}()
 
-	done := ctx.Done() //nolint:ifshort
-
-	if done == nil {
+	if ctx.Done() == nil {
 		err = fn(ctx, cn)
 		return err
 	}
 
-	errc := make(chan error, 1)
-	go func() { errc <- fn(ctx, cn) }()
-
-	select {
-	case <-done:
-		_ = cn.Close()
-		// Wait for the goroutine to finish and send something.
-		<-errc
-
+	cn.Watch(ctx)
+	err = fn(ctx, cn)
+	if cn.Unwatch() && err != nil && !isSsdbError(err) {
+		// The I/O error was caused by the interruption.
 		err = ctx.Err()
-		return err
-	case err = <-errc:
-		return err
 	}
+	return err
 }
 
 func (c *baseClient) process(ctx context.Context, cmd Cmder) error {
+	if c.opt.ReadOnly {
+		if err := checkReadOnly(cmd); err != nil {
+			return err
+		}
+	}
+
 	var lastErr error
 	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
 		attempt := attempt
@@ -305,57 +542,114 @@ func (c *baseClient) process(ctx context.Context, cmd Cmder) error {
 		}
 
 		lastErr = err
+		if attempt < c.opt.MaxRetries {
+			c.logger.InfoContext(ctx, "retrying command",
+				"cmd", cmd.Name(), "attempt", attempt+1, "error", err)
+		}
 	}
 	return lastErr
 }
 
 func (c *baseClient) _process(ctx context.Context, cmd Cmder, attempt int) (bool, error) {
 	if attempt > 0 {
-		if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+		if err := internal.Sleep(ctx, c.opt.RetryPolicy.Backoff(attempt)); err != nil {
 			return false, err
 		}
 	}
 
-	retryTimeout := uint32(1)
 	err := c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
-		err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
-			return writeCmd(wr, cmd)
-		})
-		if err != nil {
-			return err
-		}
-
-		err = cn.WithReader(ctx, c.cmdTimeout(cmd), cmd.readReply)
-		if err != nil {
-			if cmd.readTimeout() == nil {
-				atomic.StoreUint32(&retryTimeout, 1)
+		err := c.roundTrip(ctx, cn, cmd)
+		if isNoAuthError(err) {
+			if err := c.reauth(ctx, cn, err); err != nil {
+				return err
 			}
-			return err
+			err = c.roundTrip(ctx, cn, cmd)
 		}
-
-		return nil
+		return err
 	})
 	if err == nil {
 		return false, nil
 	}
 
-	retry := shouldRetry(err, atomic.LoadUint32(&retryTimeout) == 1)
+	retry := c.opt.RetryPolicy.ShouldRetry(cmd, err, attempt)
 	return retry, err
 }
 
-func (c *baseClient) retryBackoff(attempt int) time.Duration {
-	return internal.RetryBackoff(attempt, c.opt.MinRetryBackoff, c.opt.MaxRetryBackoff)
+func (c *baseClient) roundTrip(ctx context.Context, cn *pool.Conn, cmd Cmder) error {
+	readTimeout, writeTimeout := c.cmdTimeouts(ctx, cmd)
+	err := cn.WithWriter(ctx, writeTimeout, func(wr *proto.Writer) error {
+		return writeCmd(wr, cmd)
+	})
+	if err != nil {
+		return err
+	}
+
+	return cn.WithReader(ctx, readTimeout, cmd.readReply)
+}
+
+// shouldRetryPipeline reports whether the pipeline can be sent again,
+// which requires every command to be retryable.
+func (c *baseClient) shouldRetryPipeline(cmds []Cmder, err error, attempt int) bool {
+	for _, cmd := range cmds {
+		if !c.opt.RetryPolicy.ShouldRetry(cmd, err, attempt) {
+			return false
+		}
+	}
+	return true
+}
+
+type cmdTimeoutKey struct{}
+
+// WithCommandTimeout returns a copy of ctx with which the commands are
+// written and their replies read with the timeout d, instead of the timeouts
+// of the Options. The commands with their own timeouts, set with
+// SetReadTimeout or SetWriteTimeout, keep them. 0 means no timeout.
+func WithCommandTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, cmdTimeoutKey{}, d)
 }
 
-func (c *baseClient) cmdTimeout(cmd Cmder) time.Duration {
-	if timeout := cmd.readTimeout(); timeout != nil {
-		t := *timeout
-		if t == 0 {
-			return 0
+// cmdTimeouts returns the read and write timeouts of cmd, from the command,
+// then ctx, then Options.CommandTimeouts and the default timeouts.
+func (c *baseClient) cmdTimeouts(ctx context.Context, cmd Cmder) (read, write time.Duration) {
+	read, write = c.opt.ReadTimeout, c.opt.WriteTimeout
+	if d, ok := ctx.Value(cmdTimeoutKey{}).(time.Duration); ok {
+		read, write = d, d
+	} else if d, ok := c.opt.CommandTimeouts[cmd.Name()]; ok {
+		read, write = d, d
+	}
+	if d := cmd.readTimeout(); d != nil {
+		read = *d
+	}
+	if d := cmd.writeTimeout(); d != nil {
+		write = *d
+	}
+	// -1 disables the timeouts of Options.CommandTimeouts.
+	return max(read, 0), max(write, 0)
+}
+
+// cmdsTimeouts returns the read and write timeouts of a pipeline: the longest
+// timeouts of its commands, or no timeout if one of them has none.
+func (c *baseClient) cmdsTimeouts(ctx context.Context, cmds []Cmder) (read, write time.Duration) {
+	read, write = c.opt.ReadTimeout, c.opt.WriteTimeout
+	for i, cmd := range cmds {
+		r, w := c.cmdTimeouts(ctx, cmd)
+		if i == 0 {
+			read, write = r, w
+			continue
 		}
-		return t + 10*time.Second
+		read, write = longerTimeout(read, r), longerTimeout(write, w)
 	}
-	return c.opt.ReadTimeout
+	return read, write
+}
+
+func longerTimeout(a, b time.Duration) time.Duration {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > b {
+		return a
+	}
+	return b
 }
 
 // Close closes the client, releasing any open resources.
@@ -403,10 +697,18 @@ func (c *baseClient) generalProcessPipeline(
 func (c *baseClient) _generalProcessPipeline(
 	ctx context.Context, cmds []Cmder, p pipelineProcessor,
 ) error {
+	if c.opt.ReadOnly {
+		for _, cmd := range cmds {
+			if err := checkReadOnly(cmd); err != nil {
+				return err
+			}
+		}
+	}
+
 	var lastErr error
 	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
 		if attempt > 0 {
-			if err := internal.Sleep(ctx, c.retryBackoff(attempt)); err != nil {
+			if err := internal.Sleep(ctx, c.opt.RetryPolicy.Backoff(attempt)); err != nil {
 				return err
 			}
 		}
@@ -417,9 +719,13 @@ func (c *baseClient) _generalProcessPipeline(
 			canRetry, err = p(ctx, cn, cmds)
 			return err
 		})
-		if lastErr == nil || !canRetry || !shouldRetry(lastErr, true) {
+		if lastErr == nil || !canRetry || !c.shouldRetryPipeline(cmds, lastErr, attempt) {
 			return lastErr
 		}
+		if attempt < c.opt.MaxRetries {
+			c.logger.InfoContext(ctx, "retrying pipeline",
+				"cmds", len(cmds), "attempt", attempt+1, "error", lastErr)
+		}
 	}
 	return lastErr
 }
@@ -427,14 +733,37 @@ func (c *baseClient) _generalProcessPipeline(
 func (c *baseClient) pipelineProcessCmds(
 	ctx context.Context, cn *pool.Conn, cmds []Cmder,
 ) (bool, error) {
-	err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
+	canRetry, err := c._pipelineProcessCmds(ctx, cn, cmds)
+	if err != nil || len(cmds) == 0 {
+		return canRetry, err
+	}
+
+	// The server rejects every command of a connection that is not
+	// authenticated, so none of the commands was executed.
+	if noAuthErr := cmds[0].Err(); isNoAuthError(noAuthErr) {
+		if err := c.reauth(ctx, cn, noAuthErr); err != nil {
+			return false, err
+		}
+		for _, cmd := range cmds {
+			cmd.SetErr(nil)
+		}
+		return c._pipelineProcessCmds(ctx, cn, cmds)
+	}
+	return canRetry, nil
+}
+
+func (c *baseClient) _pipelineProcessCmds(
+	ctx context.Context, cn *pool.Conn, cmds []Cmder,
+) (bool, error) {
+	readTimeout, writeTimeout := c.cmdsTimeouts(ctx, cmds)
+	err := cn.WithWriter(ctx, writeTimeout, func(wr *proto.Writer) error {
 		return writeCmds(wr, cmds)
 	})
 	if err != nil {
 		return true, err
 	}
 
-	err = cn.WithReader(ctx, c.opt.ReadTimeout, func(rd *proto.Reader) error {
+	err = cn.WithReader(ctx, readTimeout, func(rd *proto.Reader) error {
 		return pipelineReadCmds(rd, cmds)
 	})
 	return true, err
@@ -454,14 +783,16 @@ func pipelineReadCmds(rd *proto.Reader, cmds []Cmder) error {
 func (c *baseClient) txPipelineProcessCmds(
 	ctx context.Context, cn *pool.Conn, cmds []Cmder,
 ) (bool, error) {
-	err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
+	// The timeouts of multi and exec don't count.
+	readTimeout, writeTimeout := c.cmdsTimeouts(ctx, cmds[1:len(cmds)-1])
+	err := cn.WithWriter(ctx, writeTimeout, func(wr *proto.Writer) error {
 		return writeCmds(wr, cmds)
 	})
 	if err != nil {
 		return true, err
 	}
 
-	err = cn.WithReader(ctx, c.opt.ReadTimeout, func(rd *proto.Reader) error {
+	err = cn.WithReader(ctx, readTimeout, func(rd *proto.Reader) error {
 		Cmd := cmds[0].(*Cmd)
 		// Trim multi and exec.
 		cmds = cmds[1 : len(cmds)-1]
@@ -526,17 +857,18 @@ type Client struct {
 func NewClient(opt *Options) *Client {
 	opt.init()
 
+	connHooks := new(connHooks)
 	c := Client{
-		baseClient: newBaseClient(opt, newConnPool(opt)),
+		baseClient: newBaseClient(opt, newConnPool(opt, connHooks), connHooks),
 	}
-	c.cmdable = c.Process
+	c.cmdable = cmdable{process: c.Process, logger: c.baseClient.logger}
 
 	return &c
 }
 
 func (c *Client) clone() *Client {
 	clone := *c
-	clone.cmdable = clone.Process
+	clone.cmdable.process = clone.Process
 	clone.hooks.lock()
 	return &clone
 }
@@ -548,7 +880,85 @@ func (c *Client) WithTimeout(timeout time.Duration) *Client {
 }
 
 func (c *Client) Conn() *Conn {
-	return newConn(c.opt, pool.NewStickyConnPool(c.connPool))
+	return newConn(c.opt, pool.NewStickyConnPool(c.connPool), c.connHooks, c.baseClient.logger)
+}
+
+// AddConnHook adds a hook that observes the lifecycle of the client
+// connections. The hook is shared with the clones of the client.
+func (c *Client) AddConnHook(hook ConnHook) {
+	c.connHooks.add(hook)
+}
+
+// WaitReady blocks until the pool holds Options.MinIdleConns connections,
+// or one if MinIdleConns is not set, that are established, authenticated and
+// answer the version command. It dials the missing connections and retries
+// with the backoff of Options.RetryPolicy until ctx is done, so it can back
+// a readiness probe.
+func (c *Client) WaitReady(ctx context.Context) error {
+	n := c.opt.MinIdleConns
+	if n < 1 {
+		n = 1
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := internal.Sleep(ctx, c.readyBackoff(attempt)); err != nil {
+				return notReadyError(ctx, lastErr)
+			}
+		}
+
+		lastErr = c.warmUp(ctx, n)
+		if lastErr == nil || lastErr == ErrClosed {
+			return lastErr
+		}
+		if ctx.Err() != nil {
+			return notReadyError(ctx, lastErr)
+		}
+	}
+}
+
+// notReadyError wraps the error of ctx and the last error of WaitReady.
+func notReadyError(ctx context.Context, lastErr error) error {
+	if lastErr == nil || errors.Is(lastErr, ctx.Err()) {
+		return fmt.Errorf("ssdb: client is not ready: %w", ctx.Err())
+	}
+	return fmt.Errorf("ssdb: client is not ready: %w", errors.Join(ctx.Err(), lastErr))
+}
+
+// minReadyBackoff keeps WaitReady from spinning when the retry backoff
+// is disabled.
+const minReadyBackoff = 10 * time.Millisecond
+
+func (c *Client) readyBackoff(attempt int) time.Duration {
+	d := c.opt.RetryPolicy.Backoff(attempt)
+	if d < minReadyBackoff {
+		d = minReadyBackoff
+	}
+	return d
+}
+
+// warmUp takes n connections from the pool at the same time and checks them.
+func (c *Client) warmUp(ctx context.Context, n int) error {
+	cns := make([]*pool.Conn, 0, n)
+	defer func() {
+		for _, cn := range cns {
+			c.connPool.Put(ctx, cn)
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		cn, err := c.connPool.Get(ctx)
+		if err != nil {
+			return err
+		}
+		if err := c.checkConn(ctx, cn); err != nil {
+			c.connPool.Remove(ctx, cn, err)
+			return err
+		}
+		cns = append(cns, cn)
+	}
+	return nil
 }
 
 // Do creates a Cmd from the args and processes the cmd.
@@ -575,8 +985,41 @@ func (c *Client) Options() *Options {
 	return c.opt
 }
 
+// PoolStats is a snapshot of the connection pool state and of its counters
+// since the client was created. Subtract an earlier snapshot with Sub to get
+// the activity of an interval.
 type PoolStats pool.Stats
 
+// PoolClosedStats counts the connections closed by the pool, by CloseReason.
+type PoolClosedStats = pool.ClosedStats
+
+// PoolWaitBuckets are the upper bounds of the buckets of
+// PoolStats.WaitHistogram.
+var PoolWaitBuckets = pool.WaitBuckets
+
+// Sub returns the stats accumulated since prev, an earlier snapshot of the
+// same client. The counters are the differences between s and prev, the
+// current state, such as TotalConns or InUse, is the one of s.
+func (s *PoolStats) Sub(prev *PoolStats) *PoolStats {
+	return (*PoolStats)((*pool.Stats)(s).Sub((*pool.Stats)(prev)))
+}
+
+// PoolPriority orders the commands waiting for a connection of the pool.
+type PoolPriority = pool.Priority
+
+const (
+	PoolPriorityLow    = pool.PriorityLow
+	PoolPriorityNormal = pool.PriorityNormal
+	PoolPriorityHigh   = pool.PriorityHigh
+)
+
+// WithPoolPriority returns a copy of ctx that makes the commands wait for a
+// connection with the priority p when the pool is exhausted: waiting commands
+// with a higher priority are served first, the others in arrival order.
+func WithPoolPriority(ctx context.Context, p PoolPriority) context.Context {
+	return pool.WithPriority(ctx, p)
+}
+
 // PoolStats returns connection pool stats.
 func (c *Client) PoolStats() *PoolStats {
 	stats := c.connPool.Stats()
@@ -591,7 +1034,7 @@ func (c *Client) Pipeline() Pipeliner {
 	pipe := Pipeline{
 		exec: c.processPipeline,
 	}
-	pipe.init()
+	pipe.init(c.baseClient.logger)
 	return &pipe
 }
 
@@ -604,7 +1047,7 @@ func (c *Client) TxPipeline() Pipeliner {
 	pipe := Pipeline{
 		exec: c.processTxPipeline,
 	}
-	pipe.init()
+	pipe.init(c.baseClient.logger)
 	return &pipe
 }
 
@@ -624,16 +1067,18 @@ type Conn struct {
 	*conn
 }
 
-func newConn(opt *Options, connPool pool.Pooler) *Conn {
+func newConn(opt *Options, connPool pool.Pooler, connHooks *connHooks, logger *slog.Logger) *Conn {
 	c := Conn{
 		conn: &conn{
 			baseClient: baseClient{
-				opt:      opt,
-				connPool: connPool,
+				opt:       opt,
+				connPool:  connPool,
+				connHooks: connHooks,
+				logger:    logger,
 			},
 		},
 	}
-	c.cmdable = c.Process
+	c.cmdable = cmdable{process: c.Process, logger: logger}
 	c.statefulCmdable = c.Process
 	return &c
 }
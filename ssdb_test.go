@@ -2,7 +2,13 @@ package ssdb_test
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -10,6 +16,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/ssdb-go/ssdb"
+	"github.com/ssdb-go/ssdb/internal/ssdbtest"
 )
 
 type ssdbHookError struct {
@@ -39,6 +46,623 @@ func TestHookError(t *testing.T) {
 	//fmt.Println(sdb.Ping(ctx).String())
 }
 
+type connHookRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+var _ ssdb.ConnHook = (*connHookRecorder)(nil)
+
+func (h *connHookRecorder) record(event string) {
+	h.mu.Lock()
+	h.events = append(h.events, event)
+	h.mu.Unlock()
+}
+
+func (h *connHookRecorder) DialStart(ctx context.Context, network, addr string) context.Context {
+	h.record("dial_start")
+	return ctx
+}
+
+func (h *connHookRecorder) DialFinish(ctx context.Context, network, addr string, remoteAddr net.Addr, err error) {
+	h.record("dial_finish")
+}
+
+func (h *connHookRecorder) ConnCheckout(ctx context.Context, cn ssdb.ConnInfo) {
+	h.record("checkout")
+}
+
+func (h *connHookRecorder) ConnReturn(ctx context.Context, cn ssdb.ConnInfo) {
+	h.record("return")
+}
+
+func (h *connHookRecorder) ConnClose(cn ssdb.ConnInfo, reason ssdb.CloseReason, err error) {
+	h.record("close " + string(reason))
+}
+
+func (h *connHookRecorder) AuthResult(ctx context.Context, cn ssdb.ConnInfo, err error) {
+	if err != nil {
+		h.record("auth " + err.Error())
+		return
+	}
+	h.record("auth")
+}
+
+func TestConnHook(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:     "fake:8888",
+		Password: "secret",
+		Dialer:   fakeServerDialer(),
+	})
+	hook := new(connHookRecorder)
+	sdb.AddConnHook(hook)
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wanted := []string{
+		"dial_start", "dial_finish", "checkout", "auth", "return",
+		"close " + string(ssdb.CloseReasonPoolClosed),
+	}
+	if !reflect.DeepEqual(hook.events, wanted) {
+		t.Fatalf("got %q, wanted %q", hook.events, wanted)
+	}
+}
+
+type connBytesRecorder struct {
+	ssdb.NoopConnHook
+	returned []ssdb.ConnInfo
+}
+
+func (h *connBytesRecorder) ConnReturn(ctx context.Context, cn ssdb.ConnInfo) {
+	h.returned = append(h.returned, cn)
+}
+
+func TestConnInfoBytes(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: fakeServerDialer(),
+	})
+	defer sdb.Close()
+	hook := new(connBytesRecorder)
+	sdb.AddConnHook(hook)
+
+	for i := 0; i < 2; i++ {
+		if err := sdb.Ping(ctx).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(hook.returned) != 2 {
+		t.Fatalf("got %d returns, wanted 2", len(hook.returned))
+	}
+	first, second := hook.returned[0], hook.returned[1]
+	if wanted := uint64(len("7\nversion\n\n")); first.BytesWritten != wanted {
+		t.Fatalf("got %d bytes written, wanted %d", first.BytesWritten, wanted)
+	}
+	if first.BytesRead == 0 {
+		t.Fatal("got 0 bytes read")
+	}
+	if second.BytesWritten != 2*first.BytesWritten || second.BytesRead != 2*first.BytesRead {
+		t.Fatalf("got %+v after the second ping, wanted twice %+v", second, first)
+	}
+}
+
+// authServer is a fake server requiring a password. Changing the password
+// makes the server forget the authenticated connections.
+type authServer struct {
+	mu       sync.Mutex
+	password string
+	gen      int
+}
+
+func (s *authServer) dialer() func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		authedGen := -1
+		return fakeServerDialerFunc(func(args []string) []string {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if args[0] == "auth" {
+				if args[1] != s.password {
+					return []string{"error", "invalid password"}
+				}
+				authedGen = s.gen
+				return []string{"ok", "1"}
+			}
+			if authedGen != s.gen {
+				return []string{"noauth", "authentication required"}
+			}
+			return []string{"ok", "1.0.0"}
+		})(ctx, network, addr)
+	}
+}
+
+func (s *authServer) setPassword(password string) {
+	s.mu.Lock()
+	s.password = password
+	s.gen++
+	s.mu.Unlock()
+}
+
+func TestAuth(t *testing.T) {
+	srv := &authServer{password: "secret"}
+
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:     "fake:8888",
+		Password: "secret",
+		Dialer:   srv.dialer(),
+	})
+	defer sdb.Close()
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := ssdb.NewClient(&ssdb.Options{
+		Addr:     "fake:8888",
+		Password: "wrong",
+		Dialer:   srv.dialer(),
+	})
+	defer wrong.Close()
+
+	err := wrong.Ping(ctx).Err()
+	if !errors.Is(err, ssdb.ErrAuth) {
+		t.Fatalf("got %v, wanted ErrAuth", err)
+	}
+	var authErr *ssdb.AuthError
+	if !errors.As(err, &authErr) || authErr.Message != "invalid password" {
+		t.Fatalf("got %#v", err)
+	}
+
+	noPassword := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: srv.dialer(),
+	})
+	defer noPassword.Close()
+
+	if err := noPassword.Ping(ctx).Err(); !errors.Is(err, ssdb.ErrAuth) {
+		t.Fatalf("got %v, wanted ErrAuth", err)
+	}
+}
+
+func TestAuthCredentialsRotation(t *testing.T) {
+	srv := &authServer{password: "v1"}
+
+	var mu sync.Mutex
+	password := "v1"
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: srv.dialer(),
+		CredentialsProviderContext: func(ctx context.Context) (string, string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return "", password, nil
+		},
+	})
+	defer sdb.Close()
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pooled connection is authenticated again with the new password
+	// when the server replies noauth.
+	srv.setPassword("v2")
+	mu.Lock()
+	password = "v2"
+	mu.Unlock()
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n := sdb.PoolStats().TotalConns; n != 1 {
+		t.Fatalf("got %d conns, wanted 1", n)
+	}
+
+	srv.setPassword("v3")
+	mu.Lock()
+	password = "v3"
+	mu.Unlock()
+
+	cmds, err := sdb.Pipelined(ctx, func(pipe ssdb.Pipeliner) error {
+		pipe.Ping(ctx)
+		pipe.Ping(ctx)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("got %d cmds, wanted 2", len(cmds))
+	}
+
+	providerErr := errors.New("vault is sealed")
+	failing := ssdb.NewClient(&ssdb.Options{
+		Addr:       "fake:8888",
+		MaxRetries: -1,
+		Dialer:     srv.dialer(),
+		CredentialsProviderContext: func(ctx context.Context) (string, string, error) {
+			return "", "", providerErr
+		},
+	})
+	defer failing.Close()
+
+	if err := failing.Ping(ctx).Err(); err != providerErr {
+		t.Fatalf("got %v, wanted %v", err, providerErr)
+	}
+}
+
+func TestContextCancelInterruptsCommand(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go func() {
+				// Read the request and never reply.
+				_, _ = io.Copy(io.Discard, server)
+			}()
+			return client, nil
+		},
+	})
+	defer sdb.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := sdb.Ping(ctx).Err(); err != context.Canceled {
+		t.Fatalf("got %v, wanted %v", err, context.Canceled)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("command was interrupted after %s", d)
+	}
+	// The reply is still pending, so the connection can't be reused.
+	if n := sdb.PoolStats().TotalConns; n != 0 {
+		t.Fatalf("got %d conns, wanted 0", n)
+	}
+}
+
+// cancelOnCheckout cancels the context of the command when its conn is
+// taken from the pool, before the request is written.
+type cancelOnCheckout struct {
+	ssdb.NoopConnHook
+	cancel context.CancelFunc
+}
+
+func (h *cancelOnCheckout) ConnCheckout(ctx context.Context, cn ssdb.ConnInfo) {
+	h.cancel()
+}
+
+func TestContextCancelKeepsIdleConn(t *testing.T) {
+	srv := ssdbtest.NewServer()
+	defer srv.Close()
+	var dials uint32
+	dial := srv.Dialer()
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddUint32(&dials, 1)
+			return dial(ctx, network, addr)
+		},
+	})
+	defer sdb.Close()
+
+	if err := sdb.Do(ctx, "set", "a", "1").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sdb.AddConnHook(&cancelOnCheckout{cancel: cancel})
+	if err := sdb.Do(ctx, "get", "a").Err(); err != context.Canceled {
+		t.Fatalf("got %v, wanted %v", err, context.Canceled)
+	}
+	// No request was written, so the connection is back in the pool.
+	if ps := sdb.PoolStats(); ps.TotalConns != 1 || ps.IdleConns != 1 {
+		t.Fatalf("got %+v, wanted 1 idle conn", ps)
+	}
+	if n := atomic.LoadUint32(&dials); n != 1 {
+		t.Fatalf("got %d dials, wanted 1", n)
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	var mu sync.Mutex
+	var dials, versions int
+
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:         "fake:8888",
+		MinIdleConns: 3,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dials++
+			n := dials
+			mu.Unlock()
+			if n <= 2 {
+				return nil, errors.New("connection refused")
+			}
+			return fakeServerDialerFunc(func(args []string) []string {
+				if args[0] == "version" {
+					mu.Lock()
+					versions++
+					mu.Unlock()
+				}
+				return []string{"ok", "1.0.0"}
+			})(ctx, network, addr)
+		},
+	})
+	defer sdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sdb.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if versions < 3 {
+		t.Fatalf("got %d version commands, wanted at least 3", versions)
+	}
+	if n := sdb.PoolStats().IdleConns; n < 3 {
+		t.Fatalf("got %d idle conns, wanted at least 3", n)
+	}
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errRefused
+		},
+	})
+	defer sdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := sdb.WaitReady(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errRefused) {
+		t.Fatalf("got %v, wanted a deadline and a dial error", err)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	var mu sync.Mutex
+	healthy := true
+
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:                "fake:8888",
+		HealthCheckInterval: 20 * time.Millisecond,
+		Dialer: fakeServerDialerFunc(func(args []string) []string {
+			mu.Lock()
+			defer mu.Unlock()
+			if !healthy {
+				return []string{"error", "server is shutting down"}
+			}
+			return []string{"ok", "1.0.0"}
+		}),
+	})
+	defer sdb.Close()
+	hook := new(connHookRecorder)
+	sdb.AddConnHook(hook)
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	healthy = false
+	mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sdb.PoolStats().TotalConns != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the unhealthy connection was not closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	wanted := "close " + string(ssdb.CloseReasonHealthCheck)
+	if last := hook.events[len(hook.events)-1]; last != wanted {
+		t.Fatalf("got %q, wanted %q", last, wanted)
+	}
+}
+
+func TestPoolStatsSub(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr:   "fake:8888",
+		Dialer: fakeServerDialer(),
+	})
+	defer sdb.Close()
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	prev := sdb.PoolStats()
+	if prev.Dials != 1 || prev.Misses != 1 || prev.BytesWritten == 0 || prev.BytesRead == 0 {
+		t.Fatalf("got %+v", prev)
+	}
+
+	if err := sdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+	diff := sdb.PoolStats().Sub(prev)
+	if diff.Dials != 0 || diff.Hits != 1 || diff.Misses != 0 {
+		t.Fatalf("got %+v", diff)
+	}
+	if diff.BytesWritten != prev.BytesWritten || diff.BytesRead != prev.BytesRead {
+		t.Fatalf("got %d/%d bytes, wanted %d/%d",
+			diff.BytesWritten, diff.BytesRead, prev.BytesWritten, prev.BytesRead)
+	}
+	if diff.TotalConns != 1 || diff.IdleConns != 1 {
+		t.Fatalf("got %d/%d conns, wanted 1/1", diff.TotalConns, diff.IdleConns)
+	}
+}
+
+func TestCommandTimeouts(t *testing.T) {
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: fakeServerDialerFunc(func(args []string) []string {
+			time.Sleep(50 * time.Millisecond)
+			return []string{"ok"}
+		}),
+		ReadTimeout:     10 * time.Millisecond,
+		MaxRetries:      -1,
+		CommandTimeouts: map[string]time.Duration{"scan": time.Second},
+	})
+	defer sdb.Close()
+
+	isTimeout := func(err error) bool {
+		var timeoutErr interface{ Timeout() bool }
+		return errors.As(err, &timeoutErr) && timeoutErr.Timeout()
+	}
+
+	if err := sdb.Do(ctx, "get", "key").Err(); !isTimeout(err) {
+		t.Fatalf("get: got %v, wanted a timeout", err)
+	}
+	if err := sdb.Do(ctx, "scan", "", "", 10).Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	slowCtx := ssdb.WithCommandTimeout(ctx, time.Second)
+	if err := sdb.Do(slowCtx, "get", "key").Err(); err != nil {
+		t.Fatalf("get with a context timeout: %v", err)
+	}
+	fastCtx := ssdb.WithCommandTimeout(ctx, 10*time.Millisecond)
+	if err := sdb.Do(fastCtx, "scan", "", "", 10).Err(); !isTimeout(err) {
+		t.Fatalf("scan with a context timeout: got %v, wanted a timeout", err)
+	}
+
+	cmd := ssdb.NewCmd(fastCtx, "get", "key")
+	cmd.SetReadTimeout(time.Second)
+	if err := sdb.Process(fastCtx, cmd); err != nil {
+		t.Fatalf("get with a command timeout: %v", err)
+	}
+
+	// A pipeline gets the longest timeout of its commands.
+	_, err := sdb.Pipelined(ctx, func(pipe ssdb.Pipeliner) error {
+		pipe.Do(ctx, "get", "key")
+		pipe.Do(ctx, "scan", "", "", 10)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+}
+
+// logRecorder is a slog.Handler that records the messages and attributes.
+type logRecorder struct {
+	mu      sync.Mutex
+	records []map[string]string
+	attrs   []slog.Attr
+}
+
+func (h *logRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *logRecorder) Handle(ctx context.Context, r slog.Record) error {
+	rec := map[string]string{"level": r.Level.String(), "msg": r.Message}
+	for _, a := range h.attrs {
+		rec[a.Key] = a.Value.String()
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rec[a.Key] = a.Value.String()
+		return true
+	})
+	h.mu.Lock()
+	h.records = append(h.records, rec)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *logRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logRecorderWith{h, attrs}
+}
+
+func (h *logRecorder) WithGroup(name string) slog.Handler { return h }
+
+func (h *logRecorder) find(msg string) map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, rec := range h.records {
+		if rec["msg"] == msg {
+			return rec
+		}
+	}
+	return nil
+}
+
+type logRecorderWith struct {
+	*logRecorder
+	with []slog.Attr
+}
+
+func (h *logRecorderWith) Handle(ctx context.Context, r slog.Record) error {
+	r = r.Clone()
+	r.AddAttrs(h.with...)
+	return h.logRecorder.Handle(ctx, r)
+}
+
+func TestLogger(t *testing.T) {
+	var mu sync.Mutex
+	var dials int
+	logs := new(logRecorder)
+	sdb := ssdb.NewClient(&ssdb.Options{
+		Addr: "fake:8888",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dials++
+			n := dials
+			mu.Unlock()
+
+			switch n {
+			case 1:
+				return nil, errors.New("connection refused")
+			case 2:
+				// Close the connection instead of replying.
+				client, server := net.Pipe()
+				go func() {
+					buf := make([]byte, 64)
+					_, _ = server.Read(buf)
+					server.Close()
+				}()
+				return client, nil
+			default:
+				return fakeServerDialer()(ctx, network, addr)
+			}
+		},
+		MaxRetries:      2,
+		MinRetryBackoff: time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+		LogHandler:      logs,
+	})
+	defer sdb.Close()
+
+	if err := sdb.Do(ctx, "get", "key").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	dial := logs.find("dial failed")
+	if dial == nil || dial["level"] != "INFO" || dial["addr"] != "fake:8888" {
+		t.Fatalf("got %v, wanted a dial failure", dial)
+	}
+	retry := logs.find("retrying command")
+	if retry == nil || retry["level"] != "INFO" || retry["cmd"] != "get" || retry["attempt"] != "1" {
+		t.Fatalf("got %v, wanted a retry", retry)
+	}
+	closed := logs.find("connection closed")
+	if closed == nil || closed["reason"] != string(ssdb.CloseReasonBadConn) {
+		t.Fatalf("got %v, wanted a closed connection", closed)
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var _ = Describe("Client", func() {